@@ -0,0 +1,112 @@
+// Package apperrors gives the errors clients are meant to act on a stable code, everything else is treated as a bug
+// and never shown to them
+package apperrors
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Codes put in the code extension of GraphQL errors
+const (
+	CodeNotFound         = "NOT_FOUND"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeConflict         = "CONFLICT"
+	CodeUnauthenticated  = "UNAUTHENTICATED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeRateLimited      = "RATE_LIMITED"
+	CodeInternal         = "INTERNAL"
+)
+
+// InternalMessage replaces the message of every INTERNAL error, the correlation id is all clients get to see
+const InternalMessage = "internal server error"
+
+// Error
+// A failure with a code clients can rely on, Field is the input path it is about, such as input.name, when there is one
+type Error struct {
+	Code    string
+	Message string
+	Field   string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(code string, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Invalid reports a VALIDATION_FAILED error about the input at field
+func Invalid(field string, message string) *Error {
+	return &Error{Code: CodeValidationFailed, Message: message, Field: field}
+}
+
+// Wrap gives err a code while keeping its message
+func Wrap(code string, err error) *Error {
+	return &Error{Code: code, Message: err.Error(), Err: err}
+}
+
+// CodeOf returns the code of the first Error in err's chain, errors without one are INTERNAL
+func CodeOf(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return CodeInternal
+}
+
+// Present is the GraphQL error presenter. Coded errors keep their message and get code and field extensions,
+// INTERNAL errors are logged with a correlation id which replaces everything else about them in the response.
+func Present(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	var appErr *Error
+	if !errors.As(err, &appErr) {
+		// errors raised by gqlgen itself, parsing, validation and the extensions already describe themselves
+		slog.WarnContext(ctx, "GraphQL error", "error", err)
+		return presented
+	}
+
+	if appErr.Code == CodeInternal {
+		return &gqlerror.Error{
+			Message:    InternalMessage,
+			Path:       presented.Path,
+			Locations:  presented.Locations,
+			Extensions: map[string]interface{}{"code": CodeInternal, "correlationId": Report(ctx, err)},
+		}
+	}
+
+	slog.WarnContext(ctx, "GraphQL error", "error", err, "code", appErr.Code)
+	if presented.Extensions == nil {
+		presented.Extensions = map[string]interface{}{}
+	}
+	presented.Extensions["code"] = appErr.Code
+	if appErr.Field != "" {
+		presented.Extensions["field"] = appErr.Field
+	}
+	return presented
+}
+
+// Report logs an unexpected error and returns the correlation id that ties it to what the client was shown
+func Report(ctx context.Context, err error) string {
+	correlationID := newCorrelationID()
+	slog.ErrorContext(ctx, "Unexpected error", "error", err, "correlation_id", correlationID)
+	return correlationID
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,40 @@
+package apperrors
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Extension
+// Marks every error a resolver returns without a code as INTERNAL so Present masks it. Errors gqlgen raises outside
+// of resolvers, such as invalid arguments, never pass through here and are shown as they are.
+type Extension struct{}
+
+var _ interface {
+	graphql.FieldInterceptor
+	graphql.HandlerExtension
+} = Extension{}
+
+func (e Extension) ExtensionName() string {
+	return "ErrorCodes"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	res, err := next(ctx)
+	if err == nil {
+		return res, nil
+	}
+	var appErr *Error
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &appErr) || errors.As(err, &gqlErr) {
+		return res, err
+	}
+	return res, Wrap(CodeInternal, err)
+}
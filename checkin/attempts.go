@@ -0,0 +1,39 @@
+package checkin
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+)
+
+// Attempts
+// Limits how often each user may type in a rotating code, so the codes can't be guessed from home. Every attempt
+// spends a token, and as a user only gets a code right once per event it's wrong codes that use them up.
+type Attempts struct {
+	store ratelimit.Store
+	limit ratelimit.Limit
+	now   func() time.Time
+}
+
+func NewAttempts(store ratelimit.Store, limit ratelimit.Limit) *Attempts {
+	return &Attempts{store: store, limit: limit, now: time.Now}
+}
+
+// Allow spends an attempt of userID and fails with RATE_LIMITED once they are used up. Unlike the Limiter it fails
+// when the store does, letting attempts through would let the codes be guessed while it is down.
+func (a *Attempts) Allow(ctx context.Context, userID string) error {
+	key := "checkInWithCode:attempts|user:" + userID
+	allowed, retryAfter, err := a.store.Take(ctx, key, a.limit, a.now())
+	if err != nil {
+		return fmt.Errorf("unable to check the check-in attempts of user %s: %w", userID, err)
+	}
+	if !allowed {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		return apperrors.New(apperrors.CodeRateLimited, fmt.Sprintf("too many check-in attempts, try again in %d seconds", seconds))
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package checkin signs the tokens behind the QR code shown at the door of an event. A token names its event and
+// when it expires and is signed with HMAC-SHA256, so only someone who scanned a recent code can check in with it.
+// For rooms where a QR code isn't enough, a presenter display shows a short code that rotates like a TOTP and can
+// only be typed in while it is on screen.
+package checkin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+)
+
+var (
+	InvalidToken  = apperrors.Invalid("token", "check-in code is not valid")
+	ExpiredToken  = apperrors.Invalid("token", "check-in code has expired, scan it again")
+	CheckInClosed = apperrors.New(apperrors.CodeForbidden, "check-in for this event is not open")
+	WrongCode     = apperrors.Invalid("code", "code is not the one on screen")
+)
+
+// Purposes a token can be signed for, a token only verifies for the purpose it was issued for
+const (
+	purposeCheckIn = "check-in"
+	purposeDisplay = "display"
+)
+
+// Code
+// A signed token and its expiry, either what the QR code at the door of an event encodes or what opens its display
+type Code struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Signer
+// Issues and verifies check-in tokens, every replica needs the same secret to accept the tokens the others issue
+type Signer struct {
+	secret []byte
+	// TTL is how long a token is accepted after it was issued, displays have to refresh their code more often
+	TTL time.Duration
+	// OpensBefore is how long before an event starts check-in opens, it stays open until the event ends
+	OpensBefore time.Duration
+	// CodeStep is how long each rotating code is shown for
+	CodeStep time.Duration
+	// DisplayTTL is how long a display token opens the presenter display
+	DisplayTTL time.Duration
+	now        func() time.Time
+}
+
+func NewSigner(secret string, ttl time.Duration, opensBefore time.Duration) *Signer {
+	return &Signer{
+		secret:      []byte(secret),
+		TTL:         ttl,
+		OpensBefore: opensBefore,
+		CodeStep:    30 * time.Second,
+		DisplayTTL:  12 * time.Hour,
+		now:         time.Now,
+	}
+}
+
+// Issue signs a token for eventID that expires after TTL, tokens look like <event id>.<unix expiry>.<signature>
+func (s *Signer) Issue(eventID string) *Code {
+	return s.issue(purposeCheckIn, eventID, s.TTL)
+}
+
+// Verify returns the event id of token, it fails with InvalidToken unless this Signer's secret signed it and with
+// ExpiredToken once it is past its expiry
+func (s *Signer) Verify(token string) (string, error) {
+	return s.verify(purposeCheckIn, token)
+}
+
+// IssueDisplay signs a token that opens the presenter display of eventID until DisplayTTL has passed, it can't be
+// used to check in
+func (s *Signer) IssueDisplay(eventID string) *Code {
+	return s.issue(purposeDisplay, eventID, s.DisplayTTL)
+}
+
+// VerifyDisplay is Verify for tokens from IssueDisplay
+func (s *Signer) VerifyDisplay(token string) (string, error) {
+	return s.verify(purposeDisplay, token)
+}
+
+// RotatingCode returns the code the display of eventID shows right now and when the next one replaces it
+func (s *Signer) RotatingCode(eventID string) (string, time.Time) {
+	step := s.now().Unix() / s.stepSeconds()
+	return s.rotatingCode(eventID, step), time.Unix((step+1)*s.stepSeconds(), 0).UTC()
+}
+
+// CheckRotatingCode reports whether code is the current or the previous code of eventID, so a code typed in just as
+// it rotated still counts
+func (s *Signer) CheckRotatingCode(eventID string, code string) bool {
+	step := s.now().Unix() / s.stepSeconds()
+	current := hmac.Equal([]byte(code), []byte(s.rotatingCode(eventID, step)))
+	previous := hmac.Equal([]byte(code), []byte(s.rotatingCode(eventID, step-1)))
+	return current || previous
+}
+
+// rotatingCode is the TOTP of RFC 6238 with HMAC-SHA256, keyed per event so one event's codes say nothing about another's
+func (s *Signer) rotatingCode(eventID string, step int64) string {
+	key := hmac.New(sha256.New, s.secret)
+	key.Write([]byte("code:" + eventID))
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// the dynamic truncation of RFC 4226 down to six digits, two of the million codes are accepted at any time
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1_000_000)
+}
+
+func (s *Signer) stepSeconds() int64 {
+	if seconds := int64(s.CodeStep / time.Second); seconds > 0 {
+		return seconds
+	}
+	return 1
+}
+
+func (s *Signer) issue(purpose string, eventID string, ttl time.Duration) *Code {
+	expiresAt := s.now().Add(ttl).Truncate(time.Second)
+	payload := eventID + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return &Code{Token: payload + "." + s.sign(purpose, payload), ExpiresAt: expiresAt.UTC()}
+}
+
+func (s *Signer) verify(purpose string, token string) (string, error) {
+	separator := strings.LastIndexByte(token, '.')
+	if separator < 0 {
+		return "", InvalidToken
+	}
+	payload, signature := token[:separator], token[separator+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.sign(purpose, payload))) {
+		return "", InvalidToken
+	}
+
+	eventID, expiry, ok := strings.Cut(payload, ".")
+	if !ok {
+		return "", InvalidToken
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return "", InvalidToken
+	}
+	if !s.now().Before(time.Unix(expiresAt, 0)) {
+		return "", ExpiredToken
+	}
+	return eventID, nil
+}
+
+// Open reports whether an event from start to end takes check-ins right now
+func (s *Signer) Open(start time.Time, end time.Time) bool {
+	now := s.now()
+	return !now.Before(start.Add(-s.OpensBefore)) && now.Before(end)
+}
+
+// sign covers purpose as well as payload, so a token issued for one purpose never verifies for another
+func (s *Signer) sign(purpose string, payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(purpose + ":" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,204 @@
+package checkin
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+)
+
+func TestSigner_Verify(t *testing.T) {
+	issuedAt := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	signer := NewSigner("check-in-secret-0123456789abcdef", 5*time.Minute, 30*time.Minute)
+	signer.now = func() time.Time { return issuedAt }
+	code := signer.Issue("42")
+	if want := issuedAt.Add(5 * time.Minute); !code.ExpiresAt.Equal(want) {
+		t.Errorf("Issue() expires at %v, want %v", code.ExpiresAt, want)
+	}
+
+	other := NewSigner("another-secret-0123456789abcdef", 5*time.Minute, 0)
+	other.now = signer.now
+	tests := []struct {
+		name    string
+		token   string
+		at      time.Time
+		want    string
+		wantErr error
+	}{
+		{name: "valid", token: code.Token, at: issuedAt, want: "42"},
+		{name: "just before it expires", token: code.Token, at: code.ExpiresAt.Add(-time.Nanosecond), want: "42"},
+		{name: "expired", token: code.Token, at: code.ExpiresAt, wantErr: ExpiredToken},
+		{name: "another secret", token: other.Issue("42").Token, at: issuedAt, wantErr: InvalidToken},
+		{name: "another event", token: strings.Replace(code.Token, "42.", "43.", 1), at: issuedAt, wantErr: InvalidToken},
+		{name: "a later expiry", token: strings.Replace(code.Token, ".1675", ".1676", 1), at: issuedAt, wantErr: InvalidToken},
+		{name: "no signature", token: "42.1675447500", at: issuedAt, wantErr: InvalidToken},
+		{name: "empty", token: "", at: issuedAt, wantErr: InvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer.now = func() time.Time { return tt.at }
+			got, err := signer.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Verify() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSigner_Open(t *testing.T) {
+	start := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	signer := NewSigner("check-in-secret-0123456789abcdef", 5*time.Minute, 30*time.Minute)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "before it opens", at: start.Add(-31 * time.Minute), want: false},
+		{name: "when it opens", at: start.Add(-30 * time.Minute), want: true},
+		{name: "during the event", at: start.Add(time.Hour), want: true},
+		{name: "when the event ends", at: end, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer.now = func() time.Time { return tt.at }
+			if got := signer.Open(start, end); got != tt.want {
+				t.Errorf("Open() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPNG(t *testing.T) {
+	encoded, err := PNG("42.1675447500.signature", 256)
+	if err != nil {
+		t.Fatalf("PNG() error = %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("unable to decode PNG: %v", err)
+	}
+	if width := decoded.Bounds().Dx(); width != 256 {
+		t.Errorf("PNG() is %d pixels wide, want 256", width)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	encoded, err := SVG("42.1675447500.signature")
+	if err != nil {
+		t.Fatalf("SVG() error = %v", err)
+	}
+	var svg struct {
+		XMLName xml.Name `xml:"svg"`
+		ViewBox string   `xml:"viewBox,attr"`
+		Path    struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	if err = xml.Unmarshal(encoded, &svg); err != nil {
+		t.Fatalf("unable to decode SVG %s: %v", encoded, err)
+	}
+	if !strings.HasPrefix(svg.ViewBox, "0 0 ") || !strings.HasPrefix(svg.Path.D, "M") {
+		t.Errorf("SVG() = %s, want a square view box and a path", encoded)
+	}
+}
+
+func TestSigner_VerifyDisplay(t *testing.T) {
+	signer := NewSigner("check-in-secret-0123456789abcdef", 5*time.Minute, 30*time.Minute)
+	display := signer.IssueDisplay("42")
+	if got, err := signer.VerifyDisplay(display.Token); err != nil || got != "42" {
+		t.Errorf("VerifyDisplay() = %q, %v, want 42", got, err)
+	}
+	// a display token must not check anyone in, nor a check-in token open the display
+	if _, err := signer.Verify(display.Token); !errors.Is(err, InvalidToken) {
+		t.Errorf("Verify() of a display token error = %v, want %v", err, InvalidToken)
+	}
+	if _, err := signer.VerifyDisplay(signer.Issue("42").Token); !errors.Is(err, InvalidToken) {
+		t.Errorf("VerifyDisplay() of a check-in token error = %v, want %v", err, InvalidToken)
+	}
+}
+
+func TestSigner_RotatingCode(t *testing.T) {
+	shownAt := time.Date(2023, time.February, 3, 18, 0, 10, 0, time.UTC)
+	signer := NewSigner("check-in-secret-0123456789abcdef", 5*time.Minute, 30*time.Minute)
+	signer.now = func() time.Time { return shownAt }
+	code, rotatesAt := signer.RotatingCode("42")
+	if len(code) != 6 || strings.Trim(code, "0123456789") != "" {
+		t.Errorf("RotatingCode() = %q, want six digits", code)
+	}
+	if want := shownAt.Add(20 * time.Second); !rotatesAt.Equal(want) {
+		t.Errorf("RotatingCode() rotates at %v, want %v", rotatesAt, want)
+	}
+	if other, _ := signer.RotatingCode("43"); other == code {
+		t.Errorf("RotatingCode() of another event = %q, want a different code", other)
+	}
+
+	tests := []struct {
+		name    string
+		eventID string
+		at      time.Time
+		want    bool
+	}{
+		{name: "while it is shown", eventID: "42", at: shownAt, want: true},
+		{name: "one step later", eventID: "42", at: shownAt.Add(30 * time.Second), want: true},
+		{name: "two steps later", eventID: "42", at: shownAt.Add(time.Minute), want: false},
+		{name: "a step early", eventID: "42", at: shownAt.Add(-30 * time.Second), want: false},
+		{name: "another event", eventID: "43", at: shownAt, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer.now = func() time.Time { return tt.at }
+			if got := signer.CheckRotatingCode(tt.eventID, code); got != tt.want {
+				t.Errorf("CheckRotatingCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttempts_Allow(t *testing.T) {
+	ctx := context.Background()
+	attempts := NewAttempts(ratelimit.NewMemoryStore(), ratelimit.Limit{Rate: 1.0 / 60, Burst: 2})
+	at := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	attempts.now = func() time.Time { return at }
+
+	for i := 0; i < 2; i++ {
+		if err := attempts.Allow(ctx, "1"); err != nil {
+			t.Fatalf("attempt %d error = %v, want nil", i+1, err)
+		}
+	}
+	err := attempts.Allow(ctx, "1")
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeRateLimited {
+		t.Errorf("third attempt error = %v, want %s", err, apperrors.CodeRateLimited)
+	}
+	if err = attempts.Allow(ctx, "2"); err != nil {
+		t.Errorf("another user's attempt error = %v, want nil", err)
+	}
+
+	at = at.Add(time.Minute)
+	if err = attempts.Allow(ctx, "1"); err != nil {
+		t.Errorf("attempt a minute later error = %v, want nil", err)
+	}
+}
+
+// brokenStore is a ratelimit.Store whose database is down
+type brokenStore struct{}
+
+func (brokenStore) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func TestAttempts_AllowStoreDown(t *testing.T) {
+	attempts := NewAttempts(brokenStore{}, ratelimit.Limit{Rate: 1.0 / 60, Burst: 2})
+	if err := attempts.Allow(context.Background(), "1"); err == nil || apperrors.CodeOf(err) != apperrors.CodeInternal {
+		t.Errorf("Allow() error = %v, want an %s error instead of letting the attempt through", err, apperrors.CodeInternal)
+	}
+}
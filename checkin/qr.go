@@ -0,0 +1,49 @@
+package checkin
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	// DefaultPNGSize is the width of PNGs when none is asked for, large enough to scan from a projector
+	DefaultPNGSize = 512
+	// MaxPNGSize caps the width of PNGs, rendering is done on every request
+	MaxPNGSize = 2048
+)
+
+// PNG draws token as a QR code size pixels wide
+func PNG(token string, size int) ([]byte, error) {
+	return qrcode.Encode(token, qrcode.Medium, size)
+}
+
+// SVG draws token as a QR code made of a single path, it scales to any size without getting blurry
+func SVG(token string) ([]byte, error) {
+	code, err := qrcode.New(token, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	// the bitmap includes the quiet zone scanners need around the code
+	bitmap := code.Bitmap()
+
+	var svg bytes.Buffer
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, len(bitmap), len(bitmap))
+	svg.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range bitmap {
+		// runs of dark modules become one rectangle each
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&svg, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	svg.WriteString(`"/></svg>`)
+	return svg.Bytes(), nil
+}
@@ -0,0 +1,389 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/logging"
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+)
+
+// Config
+// Everything that can be tuned without a rebuild. Values are read from an optional YAML or TOML file,
+// then environment variables, then flags, each overriding the previous one.
+// Auth keys are still read from the environment by knighthacks_shared.
+type Config struct {
+	Server    Server
+	Database  Database
+	Cache     Cache
+	GraphQL   GraphQL
+	RateLimit RateLimit
+	Logging   Logging
+	Tracing   Tracing
+	Metrics   Metrics
+	Webhooks  Webhooks
+	Outbox    Outbox
+	Reminders Reminders
+	CheckIn   CheckIn
+	Feedback  Feedback
+}
+
+type Server struct {
+	Port              int
+	ReadHeaderTimeout time.Duration
+	// DrainPeriod is how long readiness fails after a signal before the server stops accepting connections
+	DrainPeriod     time.Duration
+	ShutdownTimeout time.Duration
+	Playground      bool
+	CORS            CORS
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For is believed, nothing is by default so
+	// clients can't choose the IP they are rate limited by
+	TrustedProxies []string
+}
+
+// CORS is disabled when AllowedOrigins is empty, "*" allows every origin
+type CORS struct {
+	AllowedOrigins []string
+	MaxAge         time.Duration
+}
+
+type Database struct {
+	URI string
+	// MaxConns and MinConns of 0 keep pgxpool's defaults
+	MaxConns        int
+	MinConns        int
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+	// AutoMigrate applies pending migrations at startup
+	AutoMigrate bool
+}
+
+type Cache struct {
+	Enabled bool
+	TTL     time.Duration
+	Size    int
+}
+
+type GraphQL struct {
+	Introspection        bool
+	QueryCacheSize       int
+	APQCacheSize         int
+	PersistedQueryMaxAge time.Duration
+	// PersistedQueryAllowList is the path to an Apollo manifest, only its operations are accepted when it's set
+	PersistedQueryAllowList string
+	ComplexityLimit         int
+	DepthLimit              int
+}
+
+type RateLimit struct {
+	Enabled bool
+	Rules   string
+	// Store is memory or postgres
+	Store string
+}
+
+type Logging struct {
+	Level string
+}
+
+type Tracing struct {
+	// Exporter is otlp, stdout or none
+	Exporter string
+	Endpoint string
+}
+
+// Metrics are served on a port of their own so /metrics is never reachable through the public listener
+type Metrics struct {
+	Enabled bool
+	Port    int
+}
+
+// Webhooks are always queued, Enabled only decides whether this replica posts them
+type Webhooks struct {
+	Enabled      bool
+	PollInterval time.Duration
+	// Timeout is how long an endpoint gets to respond to one attempt
+	Timeout     time.Duration
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// SecretKey encrypts the signing secrets of endpoints in the database, every replica needs the same one
+	SecretKey string
+}
+
+// Outbox is relayed by every replica, they take turns so messages about one event stay in order
+type Outbox struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts is how many times a message may fail before it is parked, 0 retries it forever
+	MaxAttempts int
+	// Retention is how long published messages are kept
+	Retention time.Duration
+}
+
+// Reminders are sent by whichever replica holds the lock, so every replica can have them enabled
+type Reminders struct {
+	Enabled bool
+	// Window is how long before an event starts its reminders go out
+	Window       time.Duration
+	PollInterval time.Duration
+	// Notifier is log, smtp or webhook
+	Notifier string
+	SMTP     SMTP
+	Webhook  ReminderWebhook
+}
+
+type SMTP struct {
+	// Addr is host:port
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+type ReminderWebhook struct {
+	URL     string
+	Secret  string
+	Timeout time.Duration
+}
+
+// CheckIn tokens are accepted by every replica with the same secret
+type CheckIn struct {
+	// Secret signs tokens, a random one is used when it's empty so only the replica that issued a token accepts it
+	Secret string
+	// TokenTTL is how long a token is accepted after it was issued
+	TokenTTL time.Duration
+	// OpensBefore is how long before an event starts attendees can check in
+	OpensBefore time.Duration
+	// CodeStep is how long each rotating code is shown on the presenter display
+	CodeStep time.Duration
+	// DisplayTTL is how long a link to the presenter display works
+	DisplayTTL time.Duration
+	// CodeAttempts limits how often each user may try a rotating code, written like a rate limit such as 5/m
+	CodeAttempts string
+}
+
+type Feedback struct {
+	// Window is how long after an event ends its attendees can give feedback
+	Window time.Duration
+}
+
+// Default is the configuration used for anything that isn't set explicitly
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:              8080,
+			ReadHeaderTimeout: 10 * time.Second,
+			DrainPeriod:       10 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			Playground:        true,
+			CORS: CORS{
+				MaxAge: 10 * time.Minute,
+			},
+		},
+		Database: Database{
+			ConnectAttempts: 5,
+			ConnectBackoff:  2 * time.Second,
+		},
+		Cache: Cache{
+			Enabled: true,
+			TTL:     30 * time.Second,
+			Size:    1000,
+		},
+		GraphQL: GraphQL{
+			Introspection:        true,
+			QueryCacheSize:       1000,
+			APQCacheSize:         1000,
+			PersistedQueryMaxAge: time.Minute,
+			ComplexityLimit:      1000,
+			DepthLimit:           10,
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Rules:   "*=300/m",
+			Store:   "memory",
+		},
+		Logging: Logging{
+			Level: "info",
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+		Metrics: Metrics{
+			Enabled: true,
+			Port:    9090,
+		},
+		Webhooks: Webhooks{
+			Enabled:      true,
+			PollInterval: 5 * time.Second,
+			Timeout:      10 * time.Second,
+			MaxAttempts:  12,
+			BackoffBase:  time.Minute,
+			BackoffMax:   6 * time.Hour,
+		},
+		Outbox: Outbox{
+			PollInterval: time.Second,
+			BatchSize:    100,
+			MaxAttempts:  10,
+			Retention:    7 * 24 * time.Hour,
+		},
+		Reminders: Reminders{
+			Enabled:      true,
+			Window:       15 * time.Minute,
+			PollInterval: time.Minute,
+			Notifier:     "log",
+			Webhook: ReminderWebhook{
+				Timeout: 10 * time.Second,
+			},
+		},
+		CheckIn: CheckIn{
+			TokenTTL:     5 * time.Minute,
+			OpensBefore:  30 * time.Minute,
+			CodeStep:     30 * time.Second,
+			DisplayTTL:   12 * time.Hour,
+			CodeAttempts: "5/m",
+		},
+		Feedback: Feedback{
+			Window: 72 * time.Hour,
+		},
+	}
+}
+
+// Validate reports every invalid setting at once rather than stopping at the first one
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key string, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port", "%d is not a valid port", c.Server.Port)
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout", "must be positive")
+	check(c.Server.DrainPeriod >= 0, "server.drain_period", "must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout", "must be positive")
+	check(c.Server.CORS.MaxAge >= 0, "server.cors.max_age", "must not be negative")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "server.trusted_proxies", "%q is not an IP address or CIDR", proxy)
+	}
+
+	check(c.Database.URI != "", "database.uri", "is required")
+	check(c.Database.MaxConns >= 0, "database.max_conns", "must not be negative")
+	check(c.Database.MinConns >= 0, "database.min_conns", "must not be negative")
+	check(c.Database.MaxConns == 0 || c.Database.MinConns <= c.Database.MaxConns, "database.min_conns", "must not be greater than database.max_conns")
+	check(c.Database.MaxConnLifetime >= 0, "database.max_conn_lifetime", "must not be negative")
+	check(c.Database.MaxConnIdleTime >= 0, "database.max_conn_idle_time", "must not be negative")
+	check(c.Database.ConnectAttempts > 0, "database.connect_attempts", "must be at least 1")
+	check(c.Database.ConnectBackoff >= 0, "database.connect_backoff", "must not be negative")
+
+	check(c.Cache.TTL >= 0, "cache.ttl", "must not be negative")
+	check(c.Cache.Size >= 0, "cache.size", "must not be negative")
+
+	check(c.GraphQL.QueryCacheSize > 0, "graphql.query_cache_size", "must be positive")
+	check(c.GraphQL.APQCacheSize > 0, "graphql.apq_cache_size", "must be positive")
+	check(c.GraphQL.PersistedQueryMaxAge >= 0, "graphql.persisted_query_max_age", "must not be negative")
+	check(c.GraphQL.ComplexityLimit > 0, "graphql.complexity_limit", "must be positive")
+	check(c.GraphQL.DepthLimit > 0, "graphql.depth_limit", "must be positive")
+
+	if _, err := ratelimit.ParseRules(c.RateLimit.Rules); err != nil {
+		errs = append(errs, fmt.Errorf("rate_limit.rules: %w", err))
+	}
+	check(c.RateLimit.Store == "memory" || c.RateLimit.Store == "postgres", "rate_limit.store", "%q is not memory or postgres", c.RateLimit.Store)
+
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level: %w", err))
+	}
+	switch c.Tracing.Exporter {
+	case "otlp", "stdout", "none":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: %q is not otlp, stdout or none", c.Tracing.Exporter))
+	}
+	if c.Metrics.Enabled {
+		check(c.Metrics.Port > 0 && c.Metrics.Port <= 65535, "metrics.port", "%d is not a valid port", c.Metrics.Port)
+		check(c.Metrics.Port != c.Server.Port, "metrics.port", "must not be the same as server.port")
+	}
+
+	check(c.Webhooks.PollInterval > 0, "webhooks.poll_interval", "must be positive")
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout", "must be positive")
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts", "must be at least 1")
+	check(c.Webhooks.BackoffBase > 0, "webhooks.backoff_base", "must be positive")
+	check(c.Webhooks.BackoffMax >= c.Webhooks.BackoffBase, "webhooks.backoff_max", "must not be less than webhooks.backoff_base")
+	if c.Webhooks.Enabled || c.Webhooks.SecretKey != "" {
+		check(len(c.Webhooks.SecretKey) >= 32, "webhooks.secret_key", "is required while webhooks.enabled is true and must be at least 32 characters")
+	}
+	check(c.Outbox.PollInterval > 0, "outbox.poll_interval", "must be positive")
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size", "must be at least 1")
+	check(c.Outbox.MaxAttempts >= 0, "outbox.max_attempts", "must not be negative")
+	check(c.Outbox.Retention > 0, "outbox.retention", "must be positive")
+
+	check(c.Reminders.Window > 0, "reminders.window", "must be positive")
+	check(c.Reminders.PollInterval > 0 && c.Reminders.PollInterval < c.Reminders.Window, "reminders.poll_interval",
+		"must be positive and shorter than reminders.window or events starting between two polls are missed")
+	switch c.Reminders.Notifier {
+	case "log":
+	case "smtp":
+		check(c.Reminders.SMTP.Addr != "", "reminders.smtp.addr", "is required by the smtp notifier")
+		check(c.Reminders.SMTP.From != "", "reminders.smtp.from", "is required by the smtp notifier")
+	case "webhook":
+		check(c.Reminders.Webhook.URL != "", "reminders.webhook.url", "is required by the webhook notifier")
+		check(c.Reminders.Webhook.Timeout > 0, "reminders.webhook.timeout", "must be positive")
+	default:
+		errs = append(errs, fmt.Errorf("reminders.notifier: %q is not log, smtp or webhook", c.Reminders.Notifier))
+	}
+
+	check(len(c.CheckIn.Secret) >= 32, "check_in.secret", "is required and must be at least 32 characters")
+	check(c.CheckIn.TokenTTL > 0, "check_in.token_ttl", "must be positive")
+	check(c.CheckIn.OpensBefore >= 0, "check_in.opens_before", "must not be negative")
+	check(c.CheckIn.CodeStep >= time.Second && c.CheckIn.CodeStep%time.Second == 0, "check_in.code_step", "must be a whole number of seconds")
+	check(c.CheckIn.DisplayTTL > 0, "check_in.display_ttl", "must be positive")
+	if _, err := ratelimit.ParseLimit(c.CheckIn.CodeAttempts); err != nil {
+		errs = append(errs, fmt.Errorf("check_in.code_attempts: %w", err))
+	}
+	check(c.Feedback.Window > 0, "feedback.window", "must be positive")
+	return errors.Join(errs...)
+}
+
+// LogValue logs every setting by its file key with secrets redacted, so the summary printed at boot can be shared
+func (c *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	for _, o := range c.options() {
+		value := o.value.String()
+		if o.redact != nil {
+			value = o.redact(value)
+		}
+		attrs = append(attrs, slog.String(o.key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// redactSecret only shows whether a secret is set
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "xxxxx"
+}
+
+// dsnPassword also matches quoted passwords, which may contain spaces
+var dsnPassword = regexp.MustCompile(`password\s*=\s*('(\\.|[^'])*'|\S+)`)
+
+// redactURI keeps everything but the password so the summary still shows which database is used,
+// both URLs and keyword/value connection strings are understood. URLs may carry the password in the query too
+func redactURI(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+		if query := u.Query(); query.Has("password") {
+			query.Set("password", "xxxxx")
+			u.RawQuery = query.Encode()
+		}
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(uri, "password=xxxxx")
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		c := Default()
+		c.Database.URI = "postgres://localhost/events"
+		c.Webhooks.SecretKey = strings.Repeat("k", 32)
+		c.CheckIn.Secret = strings.Repeat("s", 32)
+		return c
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatalf("Validate() of the defaults error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{name: "database uri", change: func(c *Config) { c.Database.URI = "" }, want: []string{"database.uri: is required"}},
+		{
+			name:   "every error at once",
+			change: func(c *Config) { c.Server.Port = 70000; c.Cache.TTL = -1 },
+			want:   []string{"server.port: 70000 is not a valid port", "cache.ttl: must not be negative"},
+		},
+		{name: "pool sizes", change: func(c *Config) { c.Database.MaxConns, c.Database.MinConns = 2, 3 }, want: []string{"database.min_conns"}},
+		{name: "trusted proxy", change: func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/8", "proxy"} }, want: []string{`"proxy" is not an IP address`}},
+		{name: "metrics port", change: func(c *Config) { c.Metrics.Port = c.Server.Port }, want: []string{"metrics.port: must not be the same as server.port"}},
+		{name: "metrics disabled", change: func(c *Config) { c.Metrics.Enabled, c.Metrics.Port = false, 0 }},
+		{name: "rate limit rules", change: func(c *Config) { c.RateLimit.Rules = "createEvent=lots" }, want: []string{"rate_limit.rules"}},
+		{name: "log level", change: func(c *Config) { c.Logging.Level = "loud" }, want: []string{"logging.level"}},
+		{name: "tracing exporter", change: func(c *Config) { c.Tracing.Exporter = "jaeger" }, want: []string{`tracing.exporter: "jaeger"`}},
+		{name: "webhook secret key", change: func(c *Config) { c.Webhooks.SecretKey = "" }, want: []string{"webhooks.secret_key: is required"}},
+		{name: "short webhook secret key", change: func(c *Config) { c.Webhooks.Enabled, c.Webhooks.SecretKey = false, "short" }, want: []string{"webhooks.secret_key: is required"}},
+		{name: "webhooks disabled without a secret key", change: func(c *Config) { c.Webhooks.Enabled, c.Webhooks.SecretKey = false, "" }},
+		{name: "webhook backoff", change: func(c *Config) { c.Webhooks.BackoffMax = c.Webhooks.BackoffBase - 1 }, want: []string{"webhooks.backoff_max"}},
+		{name: "reminder poll interval", change: func(c *Config) { c.Reminders.PollInterval = c.Reminders.Window }, want: []string{"reminders.poll_interval"}},
+		{
+			name:   "smtp notifier",
+			change: func(c *Config) { c.Reminders.Notifier = "smtp" },
+			want:   []string{"reminders.smtp.addr: is required", "reminders.smtp.from: is required"},
+		},
+		{name: "check-in secret", change: func(c *Config) { c.CheckIn.Secret = "" }, want: []string{"check_in.secret: is required"}},
+		{name: "short check-in secret", change: func(c *Config) { c.CheckIn.Secret = "short" }, want: []string{"check_in.secret: is required"}},
+		{name: "check-in code step", change: func(c *Config) { c.CheckIn.CodeStep = 1500 * time.Millisecond }, want: []string{"check_in.code_step"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.change(c)
+			err := c.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want %v", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "postgres://events:hunter2@db:5432/events?sslmode=require", want: "postgres://events:xxxxx@db:5432/events?sslmode=require"},
+		{uri: "postgres://events@db/events", want: "postgres://events@db/events"},
+		{uri: "postgres://db/events?password=hunter2&user=events", want: "postgres://db/events?password=xxxxx&user=events"},
+		{uri: "host=db user=events password=hunter2 dbname=events", want: "host=db user=events password=xxxxx dbname=events"},
+		{uri: "host=db password='hunter2 with spaces' dbname=events", want: "host=db password=xxxxx dbname=events"},
+		{uri: "host=db password = hunter2", want: "host=db password=xxxxx"},
+		{uri: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			if got := redactURI(tt.uri); got != tt.want {
+				t.Errorf("redactURI() = %q, want %q", got, tt.want)
+			}
+			if strings.Contains(redactURI(tt.uri), "hunter2") {
+				t.Errorf("redactURI() = %q leaks the password", redactURI(tt.uri))
+			}
+		})
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	c := Default()
+	c.Database.URI = "postgres://events:hunter2@db/events"
+	c.CheckIn.Secret = strings.Repeat("s", 32)
+	c.Webhooks.SecretKey = strings.Repeat("w", 32)
+
+	logged := c.LogValue().String()
+	if strings.Contains(logged, "hunter2") || strings.Contains(logged, c.CheckIn.Secret) || strings.Contains(logged, c.Webhooks.SecretKey) {
+		t.Errorf("LogValue() = %s, leaks a secret", logged)
+	}
+	if !strings.Contains(logged, "postgres://events:xxxxx@db/events") {
+		t.Errorf("LogValue() = %s, want the redacted database uri", logged)
+	}
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv points at the configuration file when -config isn't passed
+const ConfigFileEnv = "CONFIG_FILE"
+
+// option ties a setting to its key in the file, which doubles as the flag name, and its environment variable
+type option struct {
+	key    string
+	env    string
+	usage  string
+	value  flag.Value
+	redact func(string) string
+}
+
+func (c *Config) options() []option {
+	return []option{
+		{key: "server.port", env: "PORT", usage: "port the HTTP server listens on", value: (*intValue)(&c.Server.Port)},
+		{key: "server.read_header_timeout", env: "READ_HEADER_TIMEOUT", usage: "how long clients get to send request headers", value: (*durationValue)(&c.Server.ReadHeaderTimeout)},
+		{key: "server.drain_period", env: "DRAIN_PERIOD", usage: "how long readiness fails after SIGTERM before the server stops accepting connections", value: (*durationValue)(&c.Server.DrainPeriod)},
+		{key: "server.shutdown_timeout", env: "SHUTDOWN_TIMEOUT", usage: "how long in-flight requests and background workers get to finish when shutting down", value: (*durationValue)(&c.Server.ShutdownTimeout)},
+		{key: "server.playground", env: "PLAYGROUND_ENABLED", usage: "serve the GraphQL playground at /", value: (*boolValue)(&c.Server.Playground)},
+		{key: "server.cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS", usage: "comma separated origins allowed to call the API from a browser, * allows any", value: (*listValue)(&c.Server.CORS.AllowedOrigins)},
+		{key: "server.cors.max_age", env: "CORS_MAX_AGE", usage: "how long browsers may cache a preflight response", value: (*durationValue)(&c.Server.CORS.MaxAge)},
+		{key: "server.trusted_proxies", env: "TRUSTED_PROXIES", usage: "comma separated addresses or CIDRs of proxies whose X-Forwarded-For is trusted", value: (*listValue)(&c.Server.TrustedProxies)},
+
+		{key: "database.uri", env: "DATABASE_URI", usage: "Postgres connection string", value: (*stringValue)(&c.Database.URI), redact: redactURI},
+		{key: "database.max_conns", env: "DATABASE_MAX_CONNS", usage: "maximum size of the connection pool, 0 uses pgxpool's default", value: (*intValue)(&c.Database.MaxConns)},
+		{key: "database.min_conns", env: "DATABASE_MIN_CONNS", usage: "connections the pool keeps open when idle", value: (*intValue)(&c.Database.MinConns)},
+		{key: "database.max_conn_lifetime", env: "DATABASE_MAX_CONN_LIFETIME", usage: "connections older than this are closed, 0 uses pgxpool's default", value: (*durationValue)(&c.Database.MaxConnLifetime)},
+		{key: "database.max_conn_idle_time", env: "DATABASE_MAX_CONN_IDLE_TIME", usage: "connections idle for longer than this are closed, 0 uses pgxpool's default", value: (*durationValue)(&c.Database.MaxConnIdleTime)},
+		{key: "database.connect_attempts", env: "DATABASE_CONNECT_ATTEMPTS", usage: "how many times connecting is tried at startup", value: (*intValue)(&c.Database.ConnectAttempts)},
+		{key: "database.connect_backoff", env: "DATABASE_CONNECT_BACKOFF", usage: "pause between connection attempts at startup", value: (*durationValue)(&c.Database.ConnectBackoff)},
+		{key: "database.auto_migrate", env: "DATABASE_AUTO_MIGRATE", usage: "apply pending migrations at startup", value: (*boolValue)(&c.Database.AutoMigrate)},
+
+		{key: "cache.enabled", env: "EVENTS_CACHE_ENABLED", usage: "cache GetEvent and GetEvents results in memory", value: (*boolValue)(&c.Cache.Enabled)},
+		{key: "cache.ttl", env: "EVENTS_CACHE_TTL", usage: "how long events are cached, 0 disables the cache", value: (*durationValue)(&c.Cache.TTL)},
+		{key: "cache.size", env: "EVENTS_CACHE_SIZE", usage: "maximum number of cached events and cached pages", value: (*intValue)(&c.Cache.Size)},
+
+		{key: "graphql.introspection", env: "GRAPHQL_INTROSPECTION_ENABLED", usage: "answer introspection queries", value: (*boolValue)(&c.GraphQL.Introspection)},
+		{key: "graphql.query_cache_size", env: "GRAPHQL_QUERY_CACHE_SIZE", usage: "number of parsed queries kept in memory", value: (*intValue)(&c.GraphQL.QueryCacheSize)},
+		{key: "graphql.apq_cache_size", env: "APQ_CACHE_SIZE", usage: "number of automatic persisted queries kept in memory", value: (*intValue)(&c.GraphQL.APQCacheSize)},
+		{key: "graphql.persisted_query_max_age", env: "PERSISTED_QUERY_MAX_AGE", usage: "Cache-Control max age for queries fetched by hash over GET", value: (*durationValue)(&c.GraphQL.PersistedQueryMaxAge)},
+		{key: "graphql.persisted_query_allow_list", env: "PERSISTED_QUERY_ALLOW_LIST", usage: "path to an Apollo persisted query manifest, only those operations are accepted", value: (*stringValue)(&c.GraphQL.PersistedQueryAllowList)},
+		{key: "graphql.complexity_limit", env: "GRAPHQL_COMPLEXITY_LIMIT", usage: "operations above this complexity are rejected", value: (*intValue)(&c.GraphQL.ComplexityLimit)},
+		{key: "graphql.depth_limit", env: "GRAPHQL_DEPTH_LIMIT", usage: "operations nested deeper than this are rejected", value: (*intValue)(&c.GraphQL.DepthLimit)},
+
+		{key: "rate_limit.enabled", env: "RATE_LIMIT_ENABLED", usage: "rate limit operations per client", value: (*boolValue)(&c.RateLimit.Enabled)},
+		{key: "rate_limit.rules", env: "RATE_LIMITS", usage: "token buckets per operation and role, op[:ROLE]=count/unit[+burst];...", value: (*stringValue)(&c.RateLimit.Rules)},
+		{key: "rate_limit.store", env: "RATE_LIMIT_STORE", usage: "where buckets are kept, memory or postgres", value: (*stringValue)(&c.RateLimit.Store)},
+
+		{key: "logging.level", env: "LOG_LEVEL", usage: "debug, info, warn or error", value: (*stringValue)(&c.Logging.Level)},
+		{key: "tracing.exporter", env: "TRACING_EXPORTER", usage: "where spans are sent, otlp, stdout or none", value: (*stringValue)(&c.Tracing.Exporter)},
+		{key: "tracing.endpoint", env: "TRACING_ENDPOINT", usage: "OTLP/HTTP endpoint URL", value: (*stringValue)(&c.Tracing.Endpoint)},
+		{key: "metrics.enabled", env: "METRICS_ENABLED", usage: "serve Prometheus metrics at /metrics on metrics.port", value: (*boolValue)(&c.Metrics.Enabled)},
+		{key: "metrics.port", env: "METRICS_PORT", usage: "internal port /metrics is served on, separate from the public server.port", value: (*intValue)(&c.Metrics.Port)},
+
+		{key: "webhooks.enabled", env: "WEBHOOKS_ENABLED", usage: "post queued webhook deliveries from this replica", value: (*boolValue)(&c.Webhooks.Enabled)},
+		{key: "webhooks.poll_interval", env: "WEBHOOKS_POLL_INTERVAL", usage: "how often the queue is checked for due deliveries", value: (*durationValue)(&c.Webhooks.PollInterval)},
+		{key: "webhooks.timeout", env: "WEBHOOKS_TIMEOUT", usage: "how long an endpoint gets to respond to a delivery", value: (*durationValue)(&c.Webhooks.Timeout)},
+		{key: "webhooks.max_attempts", env: "WEBHOOKS_MAX_ATTEMPTS", usage: "attempts, including the first, before a delivery fails for good", value: (*intValue)(&c.Webhooks.MaxAttempts)},
+		{key: "webhooks.backoff_base", env: "WEBHOOKS_BACKOFF_BASE", usage: "wait before the first retry, it doubles with every retry", value: (*durationValue)(&c.Webhooks.BackoffBase)},
+		{key: "webhooks.backoff_max", env: "WEBHOOKS_BACKOFF_MAX", usage: "longest wait between retries", value: (*durationValue)(&c.Webhooks.BackoffMax)},
+		{key: "webhooks.secret_key", env: "WEBHOOKS_SECRET_KEY", usage: "key the signing secrets of endpoints are encrypted with in the database, shared by every replica", value: (*stringValue)(&c.Webhooks.SecretKey), redact: redactSecret},
+
+		{key: "outbox.poll_interval", env: "OUTBOX_POLL_INTERVAL", usage: "how often the outbox is checked for messages to publish", value: (*durationValue)(&c.Outbox.PollInterval)},
+		{key: "outbox.batch_size", env: "OUTBOX_BATCH_SIZE", usage: "messages published at once", value: (*intValue)(&c.Outbox.BatchSize)},
+		{key: "outbox.max_attempts", env: "OUTBOX_MAX_ATTEMPTS", usage: "failed attempts before a message is parked, 0 retries it forever", value: (*intValue)(&c.Outbox.MaxAttempts)},
+		{key: "outbox.retention", env: "OUTBOX_RETENTION", usage: "how long published messages are kept", value: (*durationValue)(&c.Outbox.Retention)},
+
+		{key: "reminders.enabled", env: "REMINDERS_ENABLED", usage: "remind users of events they RSVP'd to or bookmarked", value: (*boolValue)(&c.Reminders.Enabled)},
+		{key: "reminders.window", env: "REMINDERS_WINDOW", usage: "how long before an event starts its reminders are sent", value: (*durationValue)(&c.Reminders.Window)},
+		{key: "reminders.poll_interval", env: "REMINDERS_POLL_INTERVAL", usage: "how often due reminders are looked for", value: (*durationValue)(&c.Reminders.PollInterval)},
+		{key: "reminders.notifier", env: "REMINDERS_NOTIFIER", usage: "how reminders are sent, log, smtp or webhook", value: (*stringValue)(&c.Reminders.Notifier)},
+		{key: "reminders.smtp.addr", env: "REMINDERS_SMTP_ADDR", usage: "host:port of the SMTP server", value: (*stringValue)(&c.Reminders.SMTP.Addr)},
+		{key: "reminders.smtp.username", env: "REMINDERS_SMTP_USERNAME", usage: "SMTP username, empty skips authentication", value: (*stringValue)(&c.Reminders.SMTP.Username)},
+		{key: "reminders.smtp.password", env: "REMINDERS_SMTP_PASSWORD", usage: "SMTP password", value: (*stringValue)(&c.Reminders.SMTP.Password), redact: redactSecret},
+		{key: "reminders.smtp.from", env: "REMINDERS_SMTP_FROM", usage: "address reminders are sent from", value: (*stringValue)(&c.Reminders.SMTP.From)},
+		{key: "reminders.webhook.url", env: "REMINDERS_WEBHOOK_URL", usage: "URL every reminder is posted to", value: (*stringValue)(&c.Reminders.Webhook.URL)},
+		{key: "reminders.webhook.secret", env: "REMINDERS_WEBHOOK_SECRET", usage: "key reminder requests are signed with, empty leaves them unsigned", value: (*stringValue)(&c.Reminders.Webhook.Secret), redact: redactSecret},
+		{key: "reminders.webhook.timeout", env: "REMINDERS_WEBHOOK_TIMEOUT", usage: "how long the reminder endpoint gets to respond", value: (*durationValue)(&c.Reminders.Webhook.Timeout)},
+
+		{key: "check_in.secret", env: "CHECK_IN_SECRET", usage: "key check-in tokens are signed with, shared by every replica", value: (*stringValue)(&c.CheckIn.Secret), redact: redactSecret},
+		{key: "check_in.token_ttl", env: "CHECK_IN_TOKEN_TTL", usage: "how long a check-in token is accepted after it was issued", value: (*durationValue)(&c.CheckIn.TokenTTL)},
+		{key: "check_in.opens_before", env: "CHECK_IN_OPENS_BEFORE", usage: "how long before an event starts attendees can check in", value: (*durationValue)(&c.CheckIn.OpensBefore)},
+		{key: "check_in.code_step", env: "CHECK_IN_CODE_STEP", usage: "how long each rotating code is shown on the presenter display", value: (*durationValue)(&c.CheckIn.CodeStep)},
+		{key: "check_in.display_ttl", env: "CHECK_IN_DISPLAY_TTL", usage: "how long a link to the presenter display works", value: (*durationValue)(&c.CheckIn.DisplayTTL)},
+		{key: "check_in.code_attempts", env: "CHECK_IN_CODE_ATTEMPTS", usage: "how often each user may try a rotating code, as count/unit[+burst]", value: (*stringValue)(&c.CheckIn.CodeAttempts)},
+
+		{key: "feedback.window", env: "FEEDBACK_WINDOW", usage: "how long after an event ends its attendees can give feedback", value: (*durationValue)(&c.Feedback.Window)},
+	}
+}
+
+// Load builds the configuration from the defaults, the file named by -config or CONFIG_FILE, the environment
+// and finally the flags in args, then validates it. The arguments left after the flags are returned, they name a
+// subcommand such as migrate.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, []string, error) {
+	c := Default()
+	options := c.options()
+
+	flags := flag.NewFlagSet("knighthacks_events", flag.ContinueOnError)
+	configFile := flags.String("config", "", "path to a YAML or TOML configuration file, also read from "+ConfigFileEnv)
+	for _, o := range options {
+		flags.Var(o.value, o.key, fmt.Sprintf("%s (env %s)", o.usage, o.env))
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// flags have already been applied, remember them so they can be reapplied on top of the file and environment
+	fromFlags := map[string]string{}
+	flags.Visit(func(f *flag.Flag) {
+		fromFlags[f.Name] = f.Value.String()
+	})
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv(ConfigFileEnv)
+	}
+	if *configFile != "" {
+		if err := c.loadFile(*configFile, options); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, o := range options {
+		if value, ok := lookupEnv(o.env); ok && value != "" {
+			if err := o.value.Set(value); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s: %w", o.env, err)
+			}
+		}
+	}
+	for name, value := range fromFlags {
+		if err := flags.Set(name, value); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, flags.Args(), nil
+}
+
+func (c *Config) loadFile(path string, options []option) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	document := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return fmt.Errorf("unsupported configuration file %s, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	byKey := map[string]option{}
+	for _, o := range options {
+		byKey[o.key] = o
+	}
+	values := map[string]string{}
+	flatten("", document, values)
+
+	// sorted so the first unknown key reported is always the same
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		o, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %s", path, key)
+		}
+		if err := o.value.Set(values[key]); err != nil {
+			return fmt.Errorf("%s: invalid %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// flatten turns nested tables into dotted keys, lists become comma separated like they are in the environment
+func flatten(prefix string, document map[string]any, values map[string]string) {
+	for key, value := range document {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(key, v, values)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env is a lookupEnv backed by a map, settings that are required but can't be in a file are added unless it sets them
+type env map[string]string
+
+var required = env{"WEBHOOKS_SECRET_KEY": strings.Repeat("k", 32), "CHECK_IN_SECRET": strings.Repeat("s", 32)}
+
+func (e env) lookup(key string) (string, bool) {
+	if value, ok := e[key]; ok {
+		return value, ok
+	}
+	value, ok := required[key]
+	return value, ok
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const yamlFile = `
+server:
+  port: 8081
+  playground: false
+  cors:
+    allowed_origins:
+      - https://a.example
+      - https://b.example
+database:
+  uri: postgres://file@localhost/events
+cache:
+  ttl: 1m
+`
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", yamlFile)
+
+	tests := []struct {
+		name string
+		args []string
+		env  env
+		want func(c *Config) bool
+	}{
+		{
+			name: "defaults",
+			env:  env{"DATABASE_URI": "postgres://env@localhost/events"},
+			want: func(c *Config) bool {
+				return c.Server.Port == 8080 && c.Server.Playground && c.Cache.TTL == 30*time.Second
+			},
+		},
+		{
+			name: "file over defaults",
+			args: []string{"-config", path},
+			want: func(c *Config) bool {
+				return c.Server.Port == 8081 && !c.Server.Playground && c.Cache.TTL == time.Minute && c.Cache.Size == 1000 &&
+					reflect.DeepEqual(c.Server.CORS.AllowedOrigins, []string{"https://a.example", "https://b.example"})
+			},
+		},
+		{
+			name: "file from the environment",
+			env:  env{ConfigFileEnv: path},
+			want: func(c *Config) bool { return c.Database.URI == "postgres://file@localhost/events" },
+		},
+		{
+			name: "environment over file",
+			args: []string{"-config", path},
+			env:  env{"PORT": "8082", "CORS_ALLOWED_ORIGINS": "https://c.example", "CACHE_TTL": ""},
+			want: func(c *Config) bool {
+				return c.Server.Port == 8082 && c.Cache.TTL == time.Minute &&
+					reflect.DeepEqual(c.Server.CORS.AllowedOrigins, []string{"https://c.example"})
+			},
+		},
+		{
+			name: "flags over environment and file",
+			args: []string{"-server.port", "8083", "-config", path, "-server.playground", "-server.cors.allowed_origins", "https://d.example"},
+			env:  env{"PORT": "8082", "CORS_ALLOWED_ORIGINS": "https://c.example"},
+			want: func(c *Config) bool {
+				return c.Server.Port == 8083 && c.Server.Playground &&
+					reflect.DeepEqual(c.Server.CORS.AllowedOrigins, []string{"https://d.example"})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, err := Load(tt.args, tt.env.lookup)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !tt.want(c) {
+				t.Errorf("Load() = %+v", c)
+			}
+		})
+	}
+}
+
+func TestLoad_Subcommand(t *testing.T) {
+	_, rest, err := Load([]string{"-database.uri", "postgres://localhost/events", "migrate", "up"}, env{}.lookup)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(rest, []string{"migrate", "up"}) {
+		t.Errorf("Load() args = %v, want [migrate up]", rest)
+	}
+}
+
+func TestLoad_TOML(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+[server]
+port = 8084
+trusted_proxies = ["10.0.0.0/8", "192.168.0.1"]
+
+[database]
+uri = "postgres://localhost/events"
+`)
+	c, _, err := Load([]string{"-config", path}, env{}.lookup)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.Server.Port != 8084 || !reflect.DeepEqual(c.Server.TrustedProxies, []string{"10.0.0.0/8", "192.168.0.1"}) {
+		t.Errorf("Load() = %+v", c.Server)
+	}
+}
+
+func TestLoad_Example(t *testing.T) {
+	if _, _, err := Load([]string{"-config", "../config.example.yaml"}, env{}.lookup); err != nil {
+		t.Errorf("Load() of config.example.yaml error = %v", err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     env
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown flag", args: []string{"-nope"}, wantErr: "not defined"},
+		{name: "invalid flag", args: []string{"-server.port", "http"}, wantErr: "invalid value"},
+		{name: "invalid environment", env: env{"PORT": "http"}, wantErr: "invalid PORT"},
+		{name: "unknown setting", file: "config.yaml", content: "server:\n  prot: 1\n", wantErr: "unknown setting server.prot"},
+		{name: "invalid setting", file: "config.yaml", content: "server:\n  drain_period: soon\n", wantErr: "invalid server.drain_period"},
+		{name: "malformed file", file: "config.yaml", content: "server: [", wantErr: "unable to parse"},
+		{name: "unsupported extension", file: "config.json", content: "{}", wantErr: "unsupported configuration file"},
+		{name: "missing file", args: []string{"-config", "does-not-exist.yaml"}, wantErr: "does-not-exist.yaml"},
+		{name: "validated", env: env{"DATABASE_URI": "postgres://localhost/events", "PORT": "0"}, wantErr: "server.port: 0 is not a valid port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append(args, "-config", writeFile(t, tt.file, tt.content))
+			}
+			_, _, err := Load(args, tt.env.lookup)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	values := map[string]string{}
+	flatten("", map[string]any{
+		"port": 8080,
+		"server": map[string]any{
+			"cors": map[string]any{"allowed_origins": []any{"a", "b"}, "max_age": "10m"},
+		},
+		"enabled": true,
+		"empty":   []any{},
+	}, values)
+
+	want := map[string]string{
+		"port":                        "8080",
+		"server.cors.allowed_origins": "a,b",
+		"server.cors.max_age":         "10m",
+		"enabled":                     "true",
+		"empty":                       "",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("flatten() = %v, want %v", values, want)
+	}
+}
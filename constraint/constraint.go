@@ -0,0 +1,219 @@
+// Package constraint implements the @constraint directive. Unlike a directive that fails straight away, every broken
+// rule of every input field is reported, each as a VALIDATION_FAILED error naming the input it is about.
+package constraint
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// DirectiveName is how the directive is spelled in schema.graphqls
+const DirectiveName = "constraint"
+
+// patterns caches compiled patterns, the schema only has a handful so it never needs evicting
+var patterns sync.Map
+
+// Rules
+// The arguments of a @constraint, rules that weren't given are nil
+type Rules struct {
+	MinLength *int
+	MaxLength *int
+	Pattern   *string
+	Min       *float64
+	Max       *float64
+}
+
+// RulesOf reads the @constraint of a field from the schema, ok is false when it has none
+func RulesOf(field *ast.FieldDefinition) (rules Rules, ok bool, err error) {
+	directive := field.Directives.ForName(DirectiveName)
+	if directive == nil {
+		return rules, false, nil
+	}
+	for _, argument := range directive.Arguments {
+		value, err := argument.Value.Value(nil)
+		if err != nil {
+			return rules, false, err
+		}
+		// the schema has already been validated, so every argument has the type the directive declares
+		switch argument.Name {
+		case "minLength":
+			minLength := int(value.(int64))
+			rules.MinLength = &minLength
+		case "maxLength":
+			maxLength := int(value.(int64))
+			rules.MaxLength = &maxLength
+		case "pattern":
+			pattern := value.(string)
+			rules.Pattern = &pattern
+		case "min":
+			rules.Min = toFloat(value)
+		case "max":
+			rules.Max = toFloat(value)
+		}
+	}
+	return rules, true, nil
+}
+
+// Directive checks the value of an input field against the rules it was given. Violations are added to the response
+// on the field the input belongs to and Extension keeps that field's resolver from running, so nothing stops early.
+func Directive(ctx context.Context, obj interface{}, next graphql.Resolver, minLength *int, maxLength *int, pattern *string, min *float64, max *float64) (interface{}, error) {
+	value, err := next(ctx)
+	if err != nil {
+		return value, err
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil {
+		// arguments are also decoded outside of any field to compute the complexity, they're checked again once the field runs
+		return value, nil
+	}
+
+	violations, err := Rules{MinLength: minLength, MaxLength: maxLength, Pattern: pattern, Min: min, Max: max}.check(value)
+	if err != nil {
+		return nil, err
+	}
+	field := inputPath(graphql.GetPath(ctx), fc.Path())
+	for _, violation := range violations {
+		graphql.AddError(ctx, gqlerror.WrapPath(fc.Path(), apperrors.Invalid(field, field+" "+violation)))
+	}
+	return value, nil
+}
+
+// CheckInput applies the rules of an input object to a decoded JSON object for callers that don't go through GraphQL.
+// Like GraphQL it also rejects unknown fields and missing non-null fields, every violation is returned at once.
+func CheckInput(definition *ast.Definition, input map[string]interface{}) ([]*apperrors.Error, error) {
+	var violations []*apperrors.Error
+	names := make([]string, 0, len(input))
+	for name := range input {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if definition.Fields.ForName(name) == nil {
+			violations = append(violations, apperrors.Invalid(name, fmt.Sprintf("%s is not a field of %s", name, definition.Name)))
+		}
+	}
+
+	for _, field := range definition.Fields {
+		value, ok := input[field.Name]
+		if !ok || value == nil {
+			if field.Type.NonNull {
+				violations = append(violations, apperrors.Invalid(field.Name, field.Name+" is required"))
+			}
+			continue
+		}
+		switch value.(type) {
+		case string, float64:
+		default:
+			// JSON of the wrong type is left for the decoder to reject
+			continue
+		}
+
+		rules, ok, err := RulesOf(field)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		messages, err := rules.check(value)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			violations = append(violations, apperrors.Invalid(field.Name, field.Name+" "+message))
+		}
+	}
+	return violations, nil
+}
+
+// check returns what's wrong with value, nil values are never checked as leaving out an optional field is always fine
+func (r Rules) check(value interface{}) ([]string, error) {
+	var violations []string
+	switch v := value.(type) {
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		return r.check(*v)
+	case *int:
+		if v == nil {
+			return nil, nil
+		}
+		return r.check(*v)
+	case *float64:
+		if v == nil {
+			return nil, nil
+		}
+		return r.check(*v)
+	case int:
+		return r.check(float64(v))
+	case float64:
+		if r.Min != nil && v < *r.Min {
+			violations = append(violations, fmt.Sprintf("must be at least %g", *r.Min))
+		}
+		if r.Max != nil && v > *r.Max {
+			violations = append(violations, fmt.Sprintf("must be at most %g", *r.Max))
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if r.MinLength != nil && length < *r.MinLength {
+			violations = append(violations, fmt.Sprintf("must be at least %d characters long", *r.MinLength))
+		}
+		if r.MaxLength != nil && length > *r.MaxLength {
+			violations = append(violations, fmt.Sprintf("must be at most %d characters long", *r.MaxLength))
+		}
+		if r.Pattern != nil {
+			re, err := compile(*r.Pattern)
+			if err != nil {
+				return nil, err
+			}
+			if !re.MatchString(v) {
+				violations = append(violations, fmt.Sprintf("must match %s", *r.Pattern))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("@%s can't check values of type %T", DirectiveName, value)
+	}
+	return violations, nil
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid @%s pattern %q: %w", DirectiveName, pattern, err)
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// toFloat converts a Float argument, which the schema also accepts as an integer literal
+func toFloat(value interface{}) *float64 {
+	var f float64
+	switch v := value.(type) {
+	case int64:
+		f = float64(v)
+	case float64:
+		f = v
+	}
+	return &f
+}
+
+// inputPath is the path of the input relative to the field it was passed to, such as input.name
+func inputPath(path ast.Path, fieldPath ast.Path) string {
+	if len(path) > len(fieldPath) {
+		path = path[len(fieldPath):]
+	}
+	return path.String()
+}
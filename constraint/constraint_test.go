@@ -0,0 +1,50 @@
+package constraint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRules_check(t *testing.T) {
+	one, three := 1, 3
+	zero, ten := 0.0, 10.0
+	digits := "^[0-9]+$"
+	text := "abcd"
+	type args struct {
+		value     interface{}
+		minLength *int
+		maxLength *int
+		pattern   *string
+		min       *float64
+		max       *float64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string
+		wantErr bool
+	}{
+		{name: "valid string", args: args{value: "12", minLength: &one, maxLength: &three, pattern: &digits}, want: nil},
+		{
+			name: "every rule broken",
+			args: args{value: &text, minLength: &one, maxLength: &three, pattern: &digits},
+			want: []string{"must be at most 3 characters long", "must match ^[0-9]+$"},
+		},
+		{name: "null is never checked", args: args{value: (*string)(nil), minLength: &one}, want: nil},
+		{name: "below min", args: args{value: -1, min: &zero, max: &ten}, want: []string{"must be at least 0"}},
+		{name: "above max", args: args{value: 10.5, min: &zero, max: &ten}, want: []string{"must be at most 10"}},
+		{name: "unsupported type", args: args{value: true, min: &zero}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := Rules{MinLength: tt.args.minLength, MaxLength: tt.args.maxLength, Pattern: tt.args.pattern, Min: tt.args.min, Max: tt.args.max}
+			got, err := rules.check(tt.args.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("check() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
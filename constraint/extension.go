@@ -0,0 +1,46 @@
+package constraint
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Extension
+// Skips the resolver of every field whose arguments broke a constraint, the violations already explain why it is null.
+// Validate compiles every pattern in the schema so a typo fails at startup rather than on the first request.
+type Extension struct{}
+
+var _ interface {
+	graphql.FieldInterceptor
+	graphql.HandlerExtension
+} = Extension{}
+
+func (e Extension) ExtensionName() string {
+	return "Constraint"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	for _, definition := range schema.Schema().Types {
+		for _, field := range definition.Fields {
+			rules, ok, err := RulesOf(field)
+			if err != nil {
+				return err
+			}
+			if ok && rules.Pattern != nil {
+				if _, err = compile(*rules.Pattern); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc != nil && len(fc.Args) > 0 && graphql.HasFieldError(ctx, fc) {
+		return nil, nil
+	}
+	return next(ctx)
+}
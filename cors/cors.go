@@ -0,0 +1,50 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware lets browsers on allowedOrigins call the API, "*" allows any origin.
+// Preflight requests are answered here so they never reach auth or rate limiting.
+func Middleware(allowedOrigins []string, maxAge time.Duration) gin.HandlerFunc {
+	allowed := map[string]bool{}
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+	maxAgeSeconds := strconv.Itoa(int(maxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		// the response depends on the origin even when it isn't allowed, caches must not mix them up
+		c.Writer.Header().Add("Vary", "Origin")
+		if !allowed["*"] && !allowed[origin] {
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		if c.Request.Method != http.MethodOptions || c.GetHeader("Access-Control-Request-Method") == "" {
+			c.Header("Access-Control-Expose-Headers", "Retry-After, X-Request-ID")
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		c.Header("Access-Control-Allow-Methods", strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodOptions}, ", "))
+		if requestHeaders := c.GetHeader("Access-Control-Request-Headers"); requestHeaders != "" {
+			c.Header("Access-Control-Allow-Headers", requestHeaders)
+		}
+		c.Header("Access-Control-Max-Age", maxAgeSeconds)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
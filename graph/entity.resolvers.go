@@ -8,10 +8,14 @@ import (
 
 	"github.com/KnightHacks/knighthacks_events/graph/generated"
 	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/loaders"
 )
 
 // FindEventByID is the resolver for the findEventByID field.
 func (r *entityResolver) FindEventByID(ctx context.Context, id string) (*model.Event, error) {
+	if l := loaders.For(ctx); l != nil {
+		return l.EventByID.Load(ctx, id)
+	}
 	return r.Repository.GetEvent(ctx, id)
 }
 
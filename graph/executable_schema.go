@@ -0,0 +1,26 @@
+package graph
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KnightHacks/knighthacks_events/constraint"
+	"github.com/KnightHacks/knighthacks_events/graph/generated"
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/KnightHacks/knighthacks_events/limits"
+	"github.com/KnightHacks/knighthacks_shared/pagination"
+)
+
+// NewExecutableSchema builds the schema served by the API around resolver, hasRole implements @hasRole so tests
+// can swap knighthacks_shared's JWT based directive for one that trusts whatever claims are on the context.
+// @constraint only reports its violations when the server also uses constraint.Extension.
+func NewExecutableSchema(resolver *Resolver, hasRole identity.HasRoleFunc) graphql.ExecutableSchema {
+	schemaConfig := generated.Config{
+		Resolvers: resolver,
+		Directives: generated.DirectiveRoot{
+			HasRole:    hasRole,
+			Pagination: pagination.Pagination,
+			Constraint: constraint.Directive,
+		},
+	}
+	limits.SetComplexity(&schemaConfig.Complexity)
+	return limits.WithEntitiesComplexity(generated.NewExecutableSchema(schemaConfig))
+}
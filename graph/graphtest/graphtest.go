@@ -0,0 +1,165 @@
+// Package graphtest runs GraphQL documents against the real executable schema without HTTP middleware, JWTs or a
+// database, so resolvers can be tested as any caller against any repository.Repository
+package graphtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/KnightHacks/knighthacks_events/constraint"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/KnightHacks/knighthacks_events/loaders"
+	"github.com/KnightHacks/knighthacks_events/outbox"
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+	"github.com/KnightHacks/knighthacks_events/repository"
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+	"github.com/KnightHacks/knighthacks_events/webhooks/webhookstest"
+	"github.com/KnightHacks/knighthacks_shared/auth"
+	"github.com/KnightHacks/knighthacks_shared/models"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Callers to pass as Request.As, nil is an anonymous caller
+var (
+	Admin   = &auth.UserClaims{UserID: "1", Role: models.RoleAdmin}
+	Sponsor = &auth.UserClaims{UserID: "2", Role: models.RoleSponsor}
+	Normal  = &auth.UserClaims{UserID: "3", Role: models.RoleNormal}
+)
+
+// HasRole stands in for knighthacks_shared's @hasRole, it trusts the claims on the context instead of a JWT.
+// Admins may do anything, everybody else needs exactly the required role.
+func HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, role models.Role) (interface{}, error) {
+	claims, ok := identity.FromContext(ctx)
+	if !ok {
+		return nil, apperrors.New(apperrors.CodeUnauthenticated, "you must be logged in")
+	}
+	if claims.Role != models.RoleAdmin && claims.Role != role {
+		return nil, apperrors.New(apperrors.CodeForbidden, "you must be "+role.String())
+	}
+	return next(ctx)
+}
+
+// Server
+// Serves the executable schema over POST with the same error handling as main, minus the middleware that needs infrastructure
+type Server struct {
+	Repository repository.Repository
+	// Webhooks starts out empty, deliveries queued by mutations show up in it once they are relayed
+	Webhooks *webhookstest.MemoryStore
+	// CheckIn signs with Secret, tokens are accepted for a minute from half an hour before an event starts
+	CheckIn *checkin.Signer
+	handler *handler.Server
+}
+
+// CodeAttempts is how many rotating codes each user of a Server may try, they don't come back during a test
+var CodeAttempts = ratelimit.Limit{Rate: 1e-9, Burst: 3}
+
+// FeedbackWindow is how long after an event ends attendees can give feedback on every Server
+const FeedbackWindow = 72 * time.Hour
+
+// Secret is the key the CheckIn of every Server signs with
+const Secret = "graphtest-check-in-secret-0123456789"
+
+// NewServer builds a Server around repo, a nil repo gets an empty repository.MemoryRepository
+func NewServer(repo repository.Repository) *Server {
+	if repo == nil {
+		repo = repository.NewMemoryRepository()
+	}
+	webhookStore := webhookstest.NewMemoryStore()
+	signer := checkin.NewSigner(Secret, time.Minute, 30*time.Minute)
+	resolver := &graph.Resolver{
+		Repository:      repo,
+		Webhooks:        webhookStore,
+		CheckIn:         signer,
+		CheckInAttempts: checkin.NewAttempts(ratelimit.NewMemoryStore(), CodeAttempts),
+		FeedbackWindow:  FeedbackWindow,
+	}
+	srv := handler.New(graph.NewExecutableSchema(resolver, HasRole))
+	srv.AddTransport(transport.POST{})
+	srv.Use(apperrors.Extension{})
+	srv.Use(constraint.Extension{})
+	srv.SetErrorPresenter(apperrors.Present)
+	return &Server{Repository: repo, Webhooks: webhookStore, CheckIn: signer, handler: srv}
+}
+
+// Relay publishes the outbox of a repository.MemoryRepository to Webhooks, like the relay worker started by main
+func (s *Server) Relay(t testing.TB) {
+	t.Helper()
+	repo, ok := s.Repository.(*repository.MemoryRepository)
+	if !ok {
+		t.Fatalf("Relay needs a *repository.MemoryRepository, the server has a %T", s.Repository)
+	}
+	relay := outbox.NewRelay(repo.Outbox, webhooks.NewPublisher(s.Webhooks))
+	for {
+		relayed, err := relay.RelayPending(context.Background())
+		if err != nil {
+			t.Fatalf("RelayPending() error = %v", err)
+		}
+		if relayed == 0 {
+			return
+		}
+	}
+}
+
+// Request
+// A GraphQL document along with who sends it
+type Request struct {
+	Query     string
+	Variables map[string]interface{}
+	// As is the caller, nil sends the request anonymously
+	As *auth.UserClaims
+}
+
+// Response
+// The decoded GraphQL response, Data is left raw so tests can unmarshal it into whatever shape they queried
+type Response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors gqlerror.List   `json:"errors"`
+}
+
+// Codes returns the code extension of every error in order, errors without one are reported by their message
+func (r Response) Codes() []string {
+	var codes []string
+	for _, err := range r.Errors {
+		if code, ok := err.Extensions["code"].(string); ok {
+			codes = append(codes, code)
+		} else {
+			codes = append(codes, err.Message)
+		}
+	}
+	return codes
+}
+
+// Do executes request, failing t if the response can't be decoded. GraphQL errors are part of the Response.
+func (s *Server) Do(t testing.TB, request Request) Response {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"query": request.Query, "variables": request.Variables})
+	if err != nil {
+		t.Fatalf("unable to encode request: %v", err)
+	}
+
+	httpRequest := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+	httpRequest.Header.Set("Content-Type", "application/json")
+	ctx := loaders.WithLoaders(httpRequest.Context(), loaders.NewLoaders(s.Repository))
+	if request.As != nil {
+		ctx = identity.WithClaims(ctx, request.As)
+	}
+	recorder := httptest.NewRecorder()
+	s.handler.ServeHTTP(recorder, httpRequest.WithContext(ctx))
+
+	var response Response
+	if err = json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unable to decode response %q: %v", recorder.Body.String(), err)
+	}
+	return response
+}
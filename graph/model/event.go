@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Event
+// Bound with @goModel instead of generated, so fields only resolvers fill in such as feedbackSummary stay out of the
+// JSON events are cached, published and served as
+type Event struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	Points      int       `json:"points"`
+}
+
+func (Event) IsEntity() {}
@@ -5,6 +5,7 @@ package model
 import (
 	"time"
 
+	"github.com/KnightHacks/knighthacks_events/webhooks"
 	"github.com/KnightHacks/knighthacks_shared/models"
 )
 
@@ -12,16 +13,33 @@ type Connection interface {
 	IsConnection()
 }
 
-type Event struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	StartDate   time.Time `json:"start_date"`
-	EndDate     time.Time `json:"end_date"`
-	Description string    `json:"description"`
-	Location    string    `json:"location"`
+type Attendance struct {
+	EventID string    `json:"eventId"`
+	UserID  string    `json:"userId"`
+	Time    time.Time `json:"time"`
+}
+
+type AttendanceBucket struct {
+	Start    time.Time `json:"start"`
+	CheckIns int       `json:"checkIns"`
+}
+
+type AttendanceFrequency struct {
+	Events    int `json:"events"`
+	Attendees int `json:"attendees"`
 }
 
-func (Event) IsEntity() {}
+type EventAttendanceStats struct {
+	EventID            string              `json:"eventId"`
+	Name               string              `json:"name"`
+	StartDate          time.Time           `json:"start_date"`
+	CheckIns           int                 `json:"checkIns"`
+	Rsvps              int                 `json:"rsvps"`
+	RsvpsAttended      int                 `json:"rsvpsAttended"`
+	ConversionRate     float64             `json:"conversionRate"`
+	ReturningAttendees int                 `json:"returningAttendees"`
+	Histogram          []*AttendanceBucket `json:"histogram"`
+}
 
 type EventsConnection struct {
 	TotalCount int              `json:"totalCount"`
@@ -31,6 +49,48 @@ type EventsConnection struct {
 
 func (EventsConnection) IsConnection() {}
 
+type FeedbackResponse struct {
+	UserID    *string    `json:"userId"`
+	Rating    int        `json:"rating"`
+	Comment   string     `json:"comment"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+type FeedbackSummary struct {
+	Count         int                 `json:"count"`
+	AverageRating *float64            `json:"averageRating"`
+	Ratings       []*RatingCount      `json:"ratings"`
+	Responses     []*FeedbackResponse `json:"responses"`
+}
+
+type HackathonAttendanceStats struct {
+	HackathonID     string                  `json:"hackathonId"`
+	CheckIns        int                     `json:"checkIns"`
+	UniqueAttendees int                     `json:"uniqueAttendees"`
+	Rsvps           int                     `json:"rsvps"`
+	RsvpsAttended   int                     `json:"rsvpsAttended"`
+	ConversionRate  float64                 `json:"conversionRate"`
+	RepeatAttendees int                     `json:"repeatAttendees"`
+	Frequency       []*AttendanceFrequency  `json:"frequency"`
+	Histogram       []*AttendanceBucket     `json:"histogram"`
+	Events          []*EventAttendanceStats `json:"events"`
+}
+
+type LeaderboardConnection struct {
+	TotalCount int                 `json:"totalCount"`
+	PageInfo   *models.PageInfo    `json:"pageInfo"`
+	Entries    []*LeaderboardEntry `json:"entries"`
+}
+
+func (LeaderboardConnection) IsConnection() {}
+
+type LeaderboardEntry struct {
+	Rank        int       `json:"rank"`
+	UserID      string    `json:"userId"`
+	Points      int       `json:"points"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
 type NewEvent struct {
 	Name        string    `json:"name"`
 	StartDate   time.Time `json:"start_date"`
@@ -38,6 +98,41 @@ type NewEvent struct {
 	Description string    `json:"description"`
 	Location    string    `json:"location"`
 	HackathonID string    `json:"hackathonId"`
+	Points      *int      `json:"points"`
+}
+
+type NewFeedback struct {
+	EventID string `json:"eventId"`
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+type NewWebhook struct {
+	URL    string           `json:"url"`
+	Topics []webhooks.Topic `json:"topics"`
+}
+
+type PointsAdjustment struct {
+	HackathonID string `json:"hackathonId"`
+	UserID      string `json:"userId"`
+	Points      int    `json:"points"`
+	Reason      string `json:"reason"`
+}
+
+type PointsAward struct {
+	ID          string    `json:"id"`
+	HackathonID string    `json:"hackathonId"`
+	UserID      string    `json:"userId"`
+	EventID     *string   `json:"eventId"`
+	Points      int       `json:"points"`
+	Reason      string    `json:"reason"`
+	AdjustedBy  *string   `json:"adjustedBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type RatingCount struct {
+	Rating    int `json:"rating"`
+	Responses int `json:"responses"`
 }
 
 type UpdatedEvent struct {
@@ -46,4 +141,18 @@ type UpdatedEvent struct {
 	EndDate     *time.Time `json:"end_date"`
 	Description *string    `json:"description"`
 	Location    *string    `json:"location"`
+	Points      *int       `json:"points"`
+}
+
+type WebhookDeliveriesConnection struct {
+	TotalCount int                  `json:"totalCount"`
+	PageInfo   *models.PageInfo     `json:"pageInfo"`
+	Deliveries []*webhooks.Delivery `json:"deliveries"`
+}
+
+func (WebhookDeliveriesConnection) IsConnection() {}
+
+type WebhookRegistration struct {
+	Webhook *webhooks.Endpoint `json:"webhook"`
+	Secret  string             `json:"secret"`
 }
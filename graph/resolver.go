@@ -1,8 +1,20 @@
 package graph
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/KnightHacks/knighthacks_events/loaders"
 	"github.com/KnightHacks/knighthacks_events/repository"
+	"github.com/KnightHacks/knighthacks_events/webhooks"
 	"github.com/KnightHacks/knighthacks_shared/auth"
+	"github.com/KnightHacks/knighthacks_shared/models"
 )
 
 // This file will not be regenerated automatically.
@@ -11,5 +23,103 @@ import (
 
 type Resolver struct {
 	Repository repository.Repository
+	Webhooks   webhooks.Store
 	Auth       *auth.Auth
+	CheckIn    *checkin.Signer
+	// CheckInAttempts limits how often each user may try a rotating code
+	CheckInAttempts *checkin.Attempts
+	// FeedbackWindow is how long after an event ends its attendees can give feedback
+	FeedbackWindow time.Duration
+}
+
+// FeedbackClosed is returned for feedback before the event ended or once its window has passed
+var FeedbackClosed = apperrors.New(apperrors.CodeForbidden, "feedback for this event is not open")
+
+// MaxPageSize is the most a paginated query returns at once, it keeps a page about as expensive as REST allows
+const MaxPageSize = 100
+
+// pageSize checks the first argument of paginated queries
+func pageSize(first int) error {
+	if first < 1 || first > MaxPageSize {
+		return apperrors.Invalid("first", fmt.Sprintf("first must be between 1 and %d", MaxPageSize))
+	}
+	return nil
+}
+
+// MaxBucketMinutes is the widest histogram bucket of the attendance stats, a day
+const MaxBucketMinutes = 24 * 60
+
+// bucketWidth checks the bucketMinutes argument of the attendance stats
+func bucketWidth(minutes int) (time.Duration, error) {
+	if minutes < 1 || minutes > MaxBucketMinutes {
+		return 0, apperrors.Invalid("bucketMinutes", fmt.Sprintf("bucketMinutes must be between 1 and %d", MaxBucketMinutes))
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+// callerID is the user id of the authenticated caller, fields that need one are guarded by @hasRole already
+func callerID(ctx context.Context) (string, error) {
+	claims, ok := identity.FromContext(ctx)
+	if !ok {
+		return "", apperrors.New(apperrors.CodeUnauthenticated, "you must be logged in")
+	}
+	return claims.UserID, nil
+}
+
+// canSeeFeedback lets admins and hosts of the event see its feedback, admin tells whether the caller is one
+func (r *Resolver) canSeeFeedback(ctx context.Context, eventID string) (admin bool, err error) {
+	claims, ok := identity.FromContext(ctx)
+	if !ok {
+		return false, apperrors.New(apperrors.CodeUnauthenticated, "you must be logged in")
+	}
+	if claims.Role == models.RoleAdmin {
+		return true, nil
+	}
+	host, err := r.isHost(ctx, eventID, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+	if !host {
+		return false, apperrors.New(apperrors.CodeForbidden, "only hosts of the event and admins can see its feedback")
+	}
+	return false, nil
+}
+
+// isHost goes through the request's loader when there is one, so a list of events asks once for all of them
+func (r *Resolver) isHost(ctx context.Context, eventID string, userID string) (bool, error) {
+	if l := loaders.For(ctx); l != nil {
+		return l.IsHost.Load(ctx, loaders.Host{EventID: eventID, UserID: userID})
+	}
+	hosted, err := r.Repository.HostedEvents(ctx, userID, []string{eventID})
+	return len(hosted) > 0, err
+}
+
+// feedbackSummary is isHost for the summary of the event
+func (r *Resolver) feedbackSummary(ctx context.Context, eventID string) (*model.FeedbackSummary, error) {
+	if l := loaders.For(ctx); l != nil {
+		return l.FeedbackSummary.Load(ctx, eventID)
+	}
+	summaries, err := r.Repository.GetFeedbackSummaries(ctx, []string{eventID})
+	if err != nil {
+		return nil, err
+	}
+	return summaries[eventID], nil
+}
+
+// anonymizeFeedback leaves hosts the ratings and comments only, ordered so neither says who answered or when. The
+// summary may be memoized by the request's loader, so it returns a copy.
+func anonymizeFeedback(summary *model.FeedbackSummary) *model.FeedbackSummary {
+	anonymized := *summary
+	anonymized.Responses = make([]*model.FeedbackResponse, 0, len(summary.Responses))
+	for _, response := range summary.Responses {
+		anonymized.Responses = append(anonymized.Responses, &model.FeedbackResponse{Rating: response.Rating, Comment: response.Comment})
+	}
+	sort.SliceStable(anonymized.Responses, func(i, j int) bool {
+		a, b := anonymized.Responses[i], anonymized.Responses[j]
+		if a.Rating != b.Rating {
+			return a.Rating > b.Rating
+		}
+		return a.Comment < b.Comment
+	})
+	return &anonymized
 }
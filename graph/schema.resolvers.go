@@ -5,12 +5,55 @@ package graph
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/KnightHacks/knighthacks_events/webhooks"
 	"github.com/KnightHacks/knighthacks_shared/pagination"
 
 	"github.com/KnightHacks/knighthacks_events/graph/generated"
 	"github.com/KnightHacks/knighthacks_events/graph/model"
 )
 
+// FeedbackSummary is the resolver for the feedbackSummary field.
+func (r *eventResolver) FeedbackSummary(ctx context.Context, obj *model.Event) (*model.FeedbackSummary, error) {
+	admin, err := r.canSeeFeedback(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := r.feedbackSummary(ctx, obj.ID)
+	if err != nil || admin {
+		return summary, err
+	}
+	return anonymizeFeedback(summary), nil
+}
+
+// SVG is the resolver for the svg field.
+func (r *checkInCodeResolver) SVG(ctx context.Context, obj *checkin.Code) (string, error) {
+	svg, err := checkin.SVG(obj.Token)
+	if err != nil {
+		return "", err
+	}
+	return string(svg), nil
+}
+
+// Png is the resolver for the png field.
+func (r *checkInCodeResolver) Png(ctx context.Context, obj *checkin.Code, size int) (string, error) {
+	if size < 1 || size > checkin.MaxPNGSize {
+		return "", apperrors.Invalid("size", fmt.Sprintf("size must be between 1 and %d", checkin.MaxPNGSize))
+	}
+	png, err := checkin.PNG(obj.Token, size)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(png), nil
+}
+
 // CreateEvent is the resolver for the createEvent field.
 func (r *mutationResolver) CreateEvent(ctx context.Context, input model.NewEvent) (*model.Event, error) {
 	return r.Repository.CreateEvent(ctx, &input)
@@ -26,29 +69,307 @@ func (r *mutationResolver) DeleteEvent(ctx context.Context, id string) (bool, er
 	return r.Repository.DeleteEvent(ctx, id)
 }
 
+// CreateWebhook is the resolver for the createWebhook field.
+func (r *mutationResolver) CreateWebhook(ctx context.Context, input model.NewWebhook) (*model.WebhookRegistration, error) {
+	if len(input.Topics) == 0 {
+		return nil, apperrors.Invalid("input.topics", "input.topics must not be empty")
+	}
+	// the same topic listed twice would still only be delivered once
+	var topics []webhooks.Topic
+	for _, topic := range input.Topics {
+		duplicate := false
+		for _, t := range topics {
+			duplicate = duplicate || t == topic
+		}
+		if !duplicate {
+			topics = append(topics, topic)
+		}
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := r.Webhooks.CreateEndpoint(ctx, input.URL, topics, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &model.WebhookRegistration{Webhook: endpoint, Secret: secret}, nil
+}
+
+// DeleteWebhook is the resolver for the deleteWebhook field.
+func (r *mutationResolver) DeleteWebhook(ctx context.Context, id string) (bool, error) {
+	return r.Webhooks.DeleteEndpoint(ctx, id)
+}
+
+// RedeliverWebhook is the resolver for the redeliverWebhook field.
+func (r *mutationResolver) RedeliverWebhook(ctx context.Context, deliveryID string) (*webhooks.Delivery, error) {
+	return r.Webhooks.Redeliver(ctx, deliveryID, time.Now())
+}
+
+// RsvpEvent is the resolver for the rsvpEvent field.
+func (r *mutationResolver) RsvpEvent(ctx context.Context, id string, going bool) (bool, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = r.Repository.SetRSVP(ctx, id, userID, going); err != nil {
+		return false, err
+	}
+	return going, nil
+}
+
+// BookmarkEvent is the resolver for the bookmarkEvent field.
+func (r *mutationResolver) BookmarkEvent(ctx context.Context, id string, saved bool) (bool, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err = r.Repository.SetBookmark(ctx, id, userID, saved); err != nil {
+		return false, err
+	}
+	return saved, nil
+}
+
+// CheckInWithToken is the resolver for the checkInWithToken field.
+func (r *mutationResolver) CheckInWithToken(ctx context.Context, token string) (*model.Attendance, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	eventID, err := r.CheckIn.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	// the token may have been issued before the event was moved or deleted
+	event, err := r.Repository.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !r.CheckIn.Open(event.StartDate, event.EndDate) {
+		return nil, checkin.CheckInClosed
+	}
+	return r.Repository.CheckIn(ctx, eventID, userID, time.Now())
+}
+
+// CheckInWithCode is the resolver for the checkInWithCode field.
+func (r *mutationResolver) CheckInWithCode(ctx context.Context, eventID string, code string) (*model.Attendance, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// spent before the code is looked at, otherwise guesses would still be checked once the user is limited
+	if err = r.CheckInAttempts.Allow(ctx, userID); err != nil {
+		if apperrors.CodeOf(err) == apperrors.CodeRateLimited {
+			slog.WarnContext(ctx, "Check-in attempts used up", "event_id", eventID, "user_id", userID)
+		}
+		return nil, err
+	}
+	event, err := r.Repository.GetEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !r.CheckIn.CheckRotatingCode(eventID, code) {
+		slog.WarnContext(ctx, "Wrong check-in code", "event_id", eventID, "user_id", userID)
+		return nil, checkin.WrongCode
+	}
+	if !r.CheckIn.Open(event.StartDate, event.EndDate) {
+		return nil, checkin.CheckInClosed
+	}
+	return r.Repository.CheckIn(ctx, eventID, userID, time.Now())
+}
+
+// AdjustPoints is the resolver for the adjustPoints field.
+func (r *mutationResolver) AdjustPoints(ctx context.Context, input model.PointsAdjustment) (*model.PointsAward, error) {
+	adminID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	award, err := r.Repository.AdjustPoints(ctx, &input, adminID)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Points adjusted", "award", award.ID, "hackathonId", award.HackathonID, "userId", award.UserID, "points", award.Points, "reason", award.Reason)
+	return award, nil
+}
+
+// SetEventHost is the resolver for the setEventHost field.
+func (r *mutationResolver) SetEventHost(ctx context.Context, id string, userID string, host bool) (bool, error) {
+	if err := r.Repository.SetHost(ctx, id, userID, host); err != nil {
+		return false, err
+	}
+	return host, nil
+}
+
+// SubmitFeedback is the resolver for the submitFeedback field.
+func (r *mutationResolver) SubmitFeedback(ctx context.Context, input model.NewFeedback) (*model.FeedbackResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := r.Repository.GetEvent(ctx, input.EventID)
+	if err != nil {
+		return nil, err
+	}
+	if now := time.Now(); now.Before(event.EndDate) || now.After(event.EndDate.Add(r.FeedbackWindow)) {
+		return nil, FeedbackClosed
+	}
+	return r.Repository.SubmitFeedback(ctx, input.EventID, userID, input.Rating, input.Comment)
+}
+
 // Events is the resolver for the events field.
 func (r *queryResolver) Events(ctx context.Context, first int, after *string) (*model.EventsConnection, error) {
+	if err := pageSize(first); err != nil {
+		return nil, err
+	}
 	a, err := pagination.DecodeCursor(after)
 	if err != nil {
-		return nil, err
+		return nil, apperrors.Invalid("after", "after is not a valid cursor")
 	}
 	events, total, err := r.Repository.GetEvents(ctx, first, a)
 	if err != nil {
 		return nil, err
 	}
 
+	// an empty page starts and ends where it was asked for, so clients can keep polling from the same cursor
+	startID, endID := a, a
+	if len(events) > 0 {
+		startID, endID = events[0].ID, events[len(events)-1].ID
+	}
 	return &model.EventsConnection{
 		TotalCount: total,
-		PageInfo:   pagination.GetPageInfo(events[0].ID, events[len(events)-1].ID),
+		PageInfo:   pagination.GetPageInfo(startID, endID),
 		Events:     events,
 	}, nil
 }
 
+// Webhooks is the resolver for the webhooks field.
+func (r *queryResolver) Webhooks(ctx context.Context) ([]*webhooks.Endpoint, error) {
+	return r.Resolver.Webhooks.GetEndpoints(ctx)
+}
+
+// CheckInCode is the resolver for the checkInCode field.
+func (r *queryResolver) CheckInCode(ctx context.Context, eventID string) (*checkin.Code, error) {
+	if _, err := r.Repository.GetEvent(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return r.CheckIn.Issue(eventID), nil
+}
+
+// CheckInDisplay is the resolver for the checkInDisplay field.
+func (r *queryResolver) CheckInDisplay(ctx context.Context, eventID string) (*checkin.Code, error) {
+	if _, err := r.Repository.GetEvent(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return r.CheckIn.IssueDisplay(eventID), nil
+}
+
+// EventAttendance is the resolver for the eventAttendance field.
+func (r *queryResolver) EventAttendance(ctx context.Context, eventID string, bucketMinutes int) (*model.EventAttendanceStats, error) {
+	bucket, err := bucketWidth(bucketMinutes)
+	if err != nil {
+		return nil, err
+	}
+	return r.Repository.EventAttendance(ctx, eventID, bucket)
+}
+
+// HackathonAttendance is the resolver for the hackathonAttendance field.
+func (r *queryResolver) HackathonAttendance(ctx context.Context, hackathonID string, bucketMinutes int) (*model.HackathonAttendanceStats, error) {
+	bucket, err := bucketWidth(bucketMinutes)
+	if err != nil {
+		return nil, err
+	}
+	return r.Repository.HackathonAttendance(ctx, hackathonID, bucket)
+}
+
+// Leaderboard is the resolver for the leaderboard field.
+func (r *queryResolver) Leaderboard(ctx context.Context, hackathonID string, first int, after *string) (*model.LeaderboardConnection, error) {
+	if err := pageSize(first); err != nil {
+		return nil, err
+	}
+	// the cursor of an entry is its rank
+	a, err := pagination.DecodeCursor(after)
+	if err != nil {
+		return nil, apperrors.Invalid("after", "after is not a valid cursor")
+	}
+	afterRank, err := strconv.Atoi(a)
+	if err != nil || afterRank < 0 {
+		return nil, apperrors.Invalid("after", "after is not a valid cursor")
+	}
+	entries, total, err := r.Repository.GetLeaderboard(ctx, hackathonID, first, afterRank)
+	if err != nil {
+		return nil, err
+	}
+
+	startRank, endRank := a, a
+	if len(entries) > 0 {
+		startRank, endRank = strconv.Itoa(entries[0].Rank), strconv.Itoa(entries[len(entries)-1].Rank)
+	}
+	return &model.LeaderboardConnection{
+		TotalCount: total,
+		PageInfo:   pagination.GetPageInfo(startRank, endRank),
+		Entries:    entries,
+	}, nil
+}
+
+// PointsHistory is the resolver for the pointsHistory field.
+func (r *queryResolver) PointsHistory(ctx context.Context, hackathonID string, userID string) ([]*model.PointsAward, error) {
+	return r.Repository.GetPointsAwards(ctx, hackathonID, userID)
+}
+
+// Deliveries is the resolver for the deliveries field.
+func (r *webhookResolver) Deliveries(ctx context.Context, obj *webhooks.Endpoint, first int, after *string) (*model.WebhookDeliveriesConnection, error) {
+	if err := pageSize(first); err != nil {
+		return nil, err
+	}
+	a, err := pagination.DecodeCursor(after)
+	if err != nil {
+		return nil, apperrors.Invalid("after", "after is not a valid cursor")
+	}
+	deliveries, total, err := r.Resolver.Webhooks.GetDeliveries(ctx, obj.ID, first, a)
+	if err != nil {
+		return nil, err
+	}
+
+	startID, endID := a, a
+	if len(deliveries) > 0 {
+		startID, endID = deliveries[0].ID, deliveries[len(deliveries)-1].ID
+	}
+	return &model.WebhookDeliveriesConnection{
+		TotalCount: total,
+		PageInfo:   pagination.GetPageInfo(startID, endID),
+		Deliveries: deliveries,
+	}, nil
+}
+
+// Log is the resolver for the log field.
+func (r *webhookDeliveryResolver) Log(ctx context.Context, obj *webhooks.Delivery) ([]*webhooks.Attempt, error) {
+	return r.Resolver.Webhooks.GetAttempts(ctx, obj.ID)
+}
+
+// CheckInCode returns generated.CheckInCodeResolver implementation.
+func (r *Resolver) CheckInCode() generated.CheckInCodeResolver { return &checkInCodeResolver{r} }
+
+// Event returns generated.EventResolver implementation.
+func (r *Resolver) Event() generated.EventResolver { return &eventResolver{r} }
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// Webhook returns generated.WebhookResolver implementation.
+func (r *Resolver) Webhook() generated.WebhookResolver { return &webhookResolver{r} }
+
+// WebhookDelivery returns generated.WebhookDeliveryResolver implementation.
+func (r *Resolver) WebhookDelivery() generated.WebhookDeliveryResolver {
+	return &webhookDeliveryResolver{r}
+}
+
+type checkInCodeResolver struct{ *Resolver }
+type eventResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type webhookResolver struct{ *Resolver }
+type webhookDeliveryResolver struct{ *Resolver }
@@ -0,0 +1,1097 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/KnightHacks/knighthacks_events/graph/graphtest"
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+	"github.com/KnightHacks/knighthacks_shared/auth"
+	"github.com/KnightHacks/knighthacks_shared/pagination"
+)
+
+type test struct {
+	name string
+	// repo defaults to an empty repository.MemoryRepository
+	repo      repository.Repository
+	as        *auth.UserClaims
+	query     string
+	variables map[string]interface{}
+	// seed is how many events exist beforehand, they get the ids 1 through seed
+	seed      int
+	want      string
+	wantCodes []string
+}
+
+func TestEvents(t *testing.T) {
+	const query = `query ($first: Int!, $after: ID) {
+		events(first: $first, after: $after) { totalCount pageInfo { startCursor endCursor } events { id name } }
+	}`
+	page := func(start, end string) string {
+		pageInfo := pagination.GetPageInfo(start, end)
+		return `"pageInfo": {"startCursor": "` + pageInfo.StartCursor + `", "endCursor": "` + pageInfo.EndCursor + `"}`
+	}
+
+	run(t, []test{
+		{
+			name:      "first page anonymously",
+			query:     query,
+			variables: map[string]interface{}{"first": 2},
+			seed:      3,
+			want:      `{"events": {"totalCount": 3, ` + page("1", "2") + `, "events": [{"id": "1", "name": "Event 1"}, {"id": "2", "name": "Event 2"}]}}`,
+		},
+		{
+			name:      "page after a cursor",
+			as:        graphtest.Normal,
+			query:     query,
+			variables: map[string]interface{}{"first": 2, "after": pagination.GetPageInfo("2", "2").EndCursor},
+			seed:      3,
+			want:      `{"events": {"totalCount": 3, ` + page("3", "3") + `, "events": [{"id": "3", "name": "Event 3"}]}}`,
+		},
+		{
+			name:      "past the last event",
+			as:        graphtest.Admin,
+			query:     query,
+			variables: map[string]interface{}{"first": 2, "after": pagination.GetPageInfo("3", "3").EndCursor},
+			seed:      3,
+			want:      `{"events": {"totalCount": 3, ` + page("3", "3") + `, "events": []}}`,
+		},
+		{
+			name:  "without any events",
+			query: `{ events(first: 10) { totalCount events { id } } }`,
+			want:  `{"events": {"totalCount": 0, "events": []}}`,
+		},
+		{
+			name:      "invalid cursor",
+			query:     query,
+			variables: map[string]interface{}{"first": 2, "after": "not a cursor"},
+			seed:      1,
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "page too large",
+			query:     query,
+			variables: map[string]interface{}{"first": graph.MaxPageSize + 1},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "negative page size",
+			query:     query,
+			variables: map[string]interface{}{"first": -1},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+	})
+}
+
+func TestCreateEvent(t *testing.T) {
+	const query = `mutation ($input: NewEvent!) { createEvent(input: $input) { id name start_date end_date description location } }`
+	variables := map[string]interface{}{"input": map[string]interface{}{
+		"name":        "Opening Ceremony",
+		"start_date":  "2023-02-03T18:00:00Z",
+		"end_date":    "2023-02-03T19:00:00Z",
+		"description": "Kick off",
+		"location":    "UCF",
+		"hackathonId": "1",
+	}}
+
+	run(t, []test{
+		{
+			name:      "as an admin",
+			as:        graphtest.Admin,
+			query:     query,
+			variables: variables,
+			seed:      1,
+			want: `{"createEvent": {"id": "2", "name": "Opening Ceremony", "start_date": "2023-02-03T18:00:00Z",
+				"end_date": "2023-02-03T19:00:00Z", "description": "Kick off", "location": "UCF"}}`,
+		},
+		{name: "as a sponsor", as: graphtest.Sponsor, query: query, variables: variables, want: `null`, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "as a hacker", as: graphtest.Normal, query: query, variables: variables, want: `null`, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "anonymously", query: query, variables: variables, want: `null`, wantCodes: []string{apperrors.CodeUnauthenticated}},
+	})
+}
+
+func TestUpdateEvent(t *testing.T) {
+	const query = `mutation ($id: ID!, $input: UpdatedEvent!) { updateEvent(id: $id, input: $input) { id name location } }`
+	rename := map[string]interface{}{"name": "Closing Ceremony"}
+
+	run(t, []test{
+		{
+			name:      "as an admin",
+			as:        graphtest.Admin,
+			query:     query,
+			variables: map[string]interface{}{"id": "1", "input": rename},
+			seed:      1,
+			want:      `{"updateEvent": {"id": "1", "name": "Closing Ceremony", "location": "UCF"}}`,
+		},
+		{
+			name:      "without any fields",
+			as:        graphtest.Admin,
+			query:     query,
+			variables: map[string]interface{}{"id": "1", "input": map[string]interface{}{}},
+			seed:      1,
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "a missing event",
+			as:        graphtest.Admin,
+			query:     query,
+			variables: map[string]interface{}{"id": "2", "input": rename},
+			seed:      1,
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeNotFound},
+		},
+		{
+			name:      "as a hacker",
+			as:        graphtest.Normal,
+			query:     query,
+			variables: map[string]interface{}{"id": "1", "input": rename},
+			seed:      1,
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeForbidden},
+		},
+		{
+			name:      "anonymously",
+			query:     query,
+			variables: map[string]interface{}{"id": "1", "input": rename},
+			seed:      1,
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeUnauthenticated},
+		},
+	})
+}
+
+func TestDeleteEvent(t *testing.T) {
+	const query = `mutation ($id: ID!) { deleteEvent(id: $id) }`
+
+	run(t, []test{
+		{name: "as an admin", as: graphtest.Admin, query: query, variables: map[string]interface{}{"id": "1"}, seed: 1, want: `{"deleteEvent": true}`},
+		{
+			name:      "a missing event",
+			as:        graphtest.Admin,
+			query:     query,
+			variables: map[string]interface{}{"id": "not-an-id"},
+			seed:      1,
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeNotFound},
+		},
+		{name: "as a sponsor", as: graphtest.Sponsor, query: query, variables: map[string]interface{}{"id": "1"}, seed: 1, want: `null`, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "anonymously", query: query, variables: map[string]interface{}{"id": "1"}, seed: 1, want: `null`, wantCodes: []string{apperrors.CodeUnauthenticated}},
+	})
+}
+
+func TestRsvpEventAndBookmarkEvent(t *testing.T) {
+	run(t, []test{
+		{name: "rsvp", as: graphtest.Normal, query: `mutation { rsvpEvent(id: "1", going: true) }`, seed: 1, want: `{"rsvpEvent": true}`},
+		{name: "cancel an rsvp", as: graphtest.Normal, query: `mutation { rsvpEvent(id: "1", going: false) }`, seed: 1, want: `{"rsvpEvent": false}`},
+		{name: "bookmark", as: graphtest.Normal, query: `mutation { bookmarkEvent(id: "1", saved: true) }`, seed: 1, want: `{"bookmarkEvent": true}`},
+		{name: "rsvp to a missing event", as: graphtest.Normal, query: `mutation { rsvpEvent(id: "2", going: true) }`, seed: 1, want: `null`, wantCodes: []string{apperrors.CodeNotFound}},
+		{name: "bookmark anonymously", query: `mutation { bookmarkEvent(id: "1", saved: true) }`, seed: 1, want: `null`, wantCodes: []string{apperrors.CodeUnauthenticated}},
+	})
+
+	// both count for reminders, a user who did both is only listed once
+	repo := repository.NewMemoryRepository()
+	if _, err := repo.CreateEvent(context.Background(), &model.NewEvent{HackathonID: "1", Name: "Event 1"}); err != nil {
+		t.Fatalf("unable to seed events: %v", err)
+	}
+	server := graphtest.NewServer(repo)
+	server.Do(t, graphtest.Request{Query: `mutation { rsvpEvent(id: "1", going: true) }`, As: graphtest.Normal})
+	server.Do(t, graphtest.Request{Query: `mutation { bookmarkEvent(id: "1", saved: true) }`, As: graphtest.Normal})
+	server.Do(t, graphtest.Request{Query: `mutation { bookmarkEvent(id: "1", saved: true) }`, As: graphtest.Admin})
+	if got, want := repo.Interested("1"), []string{graphtest.Admin.UserID, graphtest.Normal.UserID}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Interested() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckIn(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := repository.NewMemoryRepository()
+	seed := func(name string, start time.Time, end time.Time) string {
+		t.Helper()
+		event, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: name, StartDate: start, EndDate: end})
+		if err != nil {
+			t.Fatalf("unable to seed events: %v", err)
+		}
+		return event.ID
+	}
+	live := seed("Live", now.Add(-time.Hour), now.Add(time.Hour))
+	soon := seed("Soon", now.Add(10*time.Minute), now.Add(time.Hour))
+	later := seed("Later", now.Add(2*time.Hour), now.Add(3*time.Hour))
+	over := seed("Over", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	deleted := seed("Deleted", now.Add(-time.Hour), now.Add(time.Hour))
+	server := graphtest.NewServer(repo)
+	endpoint, err := server.Webhooks.CreateEndpoint(ctx, "https://discord.example.com/hook", []webhooks.Topic{webhooks.TopicAttendanceCheckedIn}, "secret")
+	if err != nil {
+		t.Fatalf("CreateEndpoint() error = %v", err)
+	}
+
+	const codeQuery = `query ($id: ID!, $size: Int!) { checkInCode(eventId: $id) { token expiresAt svg png(size: $size) } }`
+	code := func(eventID string) string {
+		t.Helper()
+		response := server.Do(t, graphtest.Request{Query: codeQuery, Variables: map[string]interface{}{"id": eventID, "size": 64}, As: graphtest.Admin})
+		var data struct {
+			CheckInCode struct {
+				Token     string
+				ExpiresAt time.Time
+				SVG       string
+				PNG       string
+			}
+		}
+		if err := json.Unmarshal(response.Data, &data); err != nil || len(response.Errors) != 0 {
+			t.Fatalf("checkInCode got %s and errors %v", response.Data, response.Errors)
+		}
+		if !strings.HasPrefix(data.CheckInCode.SVG, "<svg") || data.CheckInCode.PNG == "" || !data.CheckInCode.ExpiresAt.After(now) {
+			t.Errorf("checkInCode = %+v, want an SVG, a PNG and an expiry in the future", data.CheckInCode)
+		}
+		return data.CheckInCode.Token
+	}
+	deletedToken := code(deleted)
+	if _, err = repo.DeleteEvent(ctx, deleted); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		as        *auth.UserClaims
+		variables map[string]interface{}
+		wantCodes []string
+	}{
+		{name: "as a hacker", as: graphtest.Normal, variables: map[string]interface{}{"id": live, "size": 64}, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "a missing event", as: graphtest.Admin, variables: map[string]interface{}{"id": "42", "size": 64}, wantCodes: []string{apperrors.CodeNotFound}},
+		{name: "a PNG too large", as: graphtest.Admin, variables: map[string]interface{}{"id": live, "size": 4096}, wantCodes: []string{apperrors.CodeValidationFailed}},
+	} {
+		t.Run("checkInCode "+tt.name, func(t *testing.T) {
+			response := server.Do(t, graphtest.Request{Query: codeQuery, Variables: tt.variables, As: tt.as})
+			if codes := response.Codes(); !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("error codes = %v, want %v", codes, tt.wantCodes)
+			}
+		})
+	}
+
+	other := checkin.NewSigner("another-check-in-secret-0123456789", time.Minute, 0)
+	expired := checkin.NewSigner(graphtest.Secret, -time.Second, 0)
+	liveToken := code(live)
+	for _, tt := range []struct {
+		name      string
+		token     string
+		as        *auth.UserClaims
+		wantCodes []string
+	}{
+		{name: "during the event", token: liveToken, as: graphtest.Normal},
+		{name: "twice", token: liveToken, as: graphtest.Normal, wantCodes: []string{apperrors.CodeConflict}},
+		{name: "as another user", token: liveToken, as: graphtest.Admin},
+		{name: "shortly before the event", token: code(soon), as: graphtest.Normal},
+		{name: "long before the event", token: code(later), as: graphtest.Normal, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "after the event", token: code(over), as: graphtest.Normal, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "a deleted event", token: deletedToken, as: graphtest.Normal, wantCodes: []string{apperrors.CodeNotFound}},
+		{name: "an expired token", token: expired.Issue(live).Token, as: graphtest.Admin, wantCodes: []string{apperrors.CodeValidationFailed}},
+		{name: "another secret", token: other.Issue(live).Token, as: graphtest.Admin, wantCodes: []string{apperrors.CodeValidationFailed}},
+		{name: "a token for another event", token: strings.Replace(liveToken, live+".", soon+".", 1), as: graphtest.Admin, wantCodes: []string{apperrors.CodeValidationFailed}},
+		{name: "anonymously", token: liveToken, wantCodes: []string{apperrors.CodeUnauthenticated}},
+	} {
+		t.Run("checkInWithToken "+tt.name, func(t *testing.T) {
+			response := server.Do(t, graphtest.Request{
+				Query: `mutation ($token: String!) { checkInWithToken(token: $token) { eventId userId } }`, Variables: map[string]interface{}{"token": tt.token},
+				As: tt.as,
+			})
+			if codes := response.Codes(); !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("error codes = %v, want %v", codes, tt.wantCodes)
+			}
+			if tt.wantCodes == nil {
+				eventID, _, _ := strings.Cut(tt.token, ".")
+				assertData(t, response, `{"checkInWithToken": {"eventId": "`+eventID+`", "userId": "`+tt.as.UserID+`"}}`)
+			}
+		})
+	}
+
+	// every check-in is announced
+	server.Relay(t)
+	if _, total, err := server.Webhooks.GetDeliveries(ctx, endpoint.ID, 10, "0"); err != nil || total != 3 {
+		t.Errorf("GetDeliveries() = %d deliveries, %v, want one for every check-in", total, err)
+	}
+}
+
+func TestCheckInWithCode(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := repository.NewMemoryRepository()
+	seed := func(name string, start time.Time, end time.Time) string {
+		t.Helper()
+		event, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: name, StartDate: start, EndDate: end})
+		if err != nil {
+			t.Fatalf("unable to seed events: %v", err)
+		}
+		return event.ID
+	}
+	live := seed("Live", now.Add(-time.Hour), now.Add(time.Hour))
+	also := seed("Also live", now.Add(-time.Hour), now.Add(time.Hour))
+	later := seed("Later", now.Add(2*time.Hour), now.Add(3*time.Hour))
+	server := graphtest.NewServer(repo)
+
+	const displayQuery = `query ($id: ID!) { checkInDisplay(eventId: $id) { token expiresAt } }`
+	response := server.Do(t, graphtest.Request{Query: displayQuery, Variables: map[string]interface{}{"id": live}, As: graphtest.Admin})
+	var data struct {
+		CheckInDisplay checkin.Code
+	}
+	if err := json.Unmarshal(response.Data, &data); err != nil || len(response.Errors) != 0 {
+		t.Fatalf("checkInDisplay got %s and errors %v", response.Data, response.Errors)
+	}
+	if eventID, err := server.CheckIn.VerifyDisplay(data.CheckInDisplay.Token); err != nil || eventID != live {
+		t.Errorf("VerifyDisplay() = %q, %v, want %q", eventID, err, live)
+	}
+	response = server.Do(t, graphtest.Request{Query: displayQuery, Variables: map[string]interface{}{"id": live}, As: graphtest.Normal})
+	if codes, want := response.Codes(), []string{apperrors.CodeForbidden}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("checkInDisplay as a hacker error codes = %v, want %v", codes, want)
+	}
+
+	code := func(eventID string) string {
+		code, _ := server.CheckIn.RotatingCode(eventID)
+		return code
+	}
+	// off by one in the last digit, so never the code on screen
+	wrong := func(eventID string) string {
+		code := []byte(code(eventID))
+		code[5] = '0' + (code[5]-'0'+1)%10
+		return string(code)
+	}
+	for _, tt := range []struct {
+		name      string
+		eventID   string
+		code      string
+		as        *auth.UserClaims
+		wantCodes []string
+	}{
+		{name: "the code on screen", eventID: live, code: code(live), as: graphtest.Normal},
+		{name: "a wrong code", eventID: also, code: wrong(also), as: graphtest.Normal, wantCodes: []string{apperrors.CodeValidationFailed}},
+		{name: "another event's code", eventID: also, code: code(live), as: graphtest.Normal, wantCodes: []string{apperrors.CodeValidationFailed}},
+		// every user gets graphtest.CodeAttempts tries
+		{name: "once the attempts are used up", eventID: also, code: code(also), as: graphtest.Normal, wantCodes: []string{apperrors.CodeRateLimited}},
+		{name: "as another user", eventID: also, code: code(also), as: graphtest.Admin},
+		{name: "long before the event", eventID: later, code: code(later), as: graphtest.Admin, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "a missing event", eventID: "42", code: code("42"), as: graphtest.Admin, wantCodes: []string{apperrors.CodeNotFound}},
+		{name: "anonymously", eventID: live, code: code(live), wantCodes: []string{apperrors.CodeUnauthenticated}},
+	} {
+		t.Run("checkInWithCode "+tt.name, func(t *testing.T) {
+			response := server.Do(t, graphtest.Request{
+				Query:     `mutation ($id: ID!, $code: String!) { checkInWithCode(eventId: $id, code: $code) { eventId userId } }`,
+				Variables: map[string]interface{}{"id": tt.eventID, "code": tt.code},
+				As:        tt.as,
+			})
+			if codes := response.Codes(); !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("error codes = %v, want %v", codes, tt.wantCodes)
+			}
+			if tt.wantCodes == nil {
+				assertData(t, response, `{"checkInWithCode": {"eventId": "`+tt.eventID+`", "userId": "`+tt.as.UserID+`"}}`)
+			}
+		})
+	}
+}
+
+func TestAttendanceStats(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	repo := repository.NewMemoryRepository()
+	for _, name := range []string{"Workshop", "Ceremony"} {
+		if _, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: name, StartDate: start, EndDate: start.Add(time.Hour)}); err != nil {
+			t.Fatalf("unable to seed events: %v", err)
+		}
+	}
+	if err := repo.SetRSVP(ctx, "1", "1", true); err != nil {
+		t.Fatalf("SetRSVP() error = %v", err)
+	}
+	for _, checkIn := range []struct {
+		eventID, userID string
+		at              time.Time
+	}{{"1", "1", start}, {"1", "2", start.Add(20 * time.Minute)}, {"2", "1", start.Add(time.Hour)}} {
+		if _, err := repo.CheckIn(ctx, checkIn.eventID, checkIn.userID, checkIn.at); err != nil {
+			t.Fatalf("CheckIn() error = %v", err)
+		}
+	}
+
+	const eventQuery = `query ($id: ID!, $minutes: Int!) {
+		eventAttendance(eventId: $id, bucketMinutes: $minutes) { checkIns rsvpsAttended conversionRate histogram { start checkIns } }
+	}`
+	const hackathonQuery = `query ($id: ID!) {
+		hackathonAttendance(hackathonId: $id) { checkIns uniqueAttendees repeatAttendees frequency { events attendees } events { eventId returningAttendees } }
+	}`
+	run(t, []test{
+		{
+			name:      "of an event",
+			repo:      repo,
+			as:        graphtest.Admin,
+			query:     eventQuery,
+			variables: map[string]interface{}{"id": "1", "minutes": 15},
+			want: `{"eventAttendance": {"checkIns": 2, "rsvpsAttended": 1, "conversionRate": 1, "histogram": [
+				{"start": "2023-02-03T18:00:00Z", "checkIns": 1}, {"start": "2023-02-03T18:15:00Z", "checkIns": 1}
+			]}}`,
+		},
+		{
+			name:      "of a hackathon",
+			repo:      repo,
+			as:        graphtest.Admin,
+			query:     hackathonQuery,
+			variables: map[string]interface{}{"id": "1"},
+			want: `{"hackathonAttendance": {"checkIns": 3, "uniqueAttendees": 2, "repeatAttendees": 1,
+				"frequency": [{"events": 1, "attendees": 1}, {"events": 2, "attendees": 1}],
+				"events": [{"eventId": "1", "returningAttendees": 0}, {"eventId": "2", "returningAttendees": 1}]}}`,
+		},
+		{
+			name:      "of a missing event",
+			as:        graphtest.Admin,
+			query:     eventQuery,
+			variables: map[string]interface{}{"id": "42", "minutes": 15},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeNotFound},
+		},
+		{
+			name:      "with buckets wider than a day",
+			repo:      repo,
+			as:        graphtest.Admin,
+			query:     eventQuery,
+			variables: map[string]interface{}{"id": "1", "minutes": 24*60 + 1},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "as a sponsor",
+			repo:      repo,
+			as:        graphtest.Sponsor,
+			query:     hackathonQuery,
+			variables: map[string]interface{}{"id": "1"},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeForbidden},
+		},
+	})
+}
+
+func TestPoints(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	repo := repository.NewMemoryRepository()
+	points := 10
+	if _, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: "Workshop", StartDate: start, EndDate: start.Add(time.Hour), Points: &points}); err != nil {
+		t.Fatalf("unable to seed events: %v", err)
+	}
+	for i, userID := range []string{graphtest.Normal.UserID, graphtest.Sponsor.UserID} {
+		if _, err := repo.CheckIn(ctx, "1", userID, start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("CheckIn() error = %v", err)
+		}
+	}
+
+	const adjust = `mutation ($input: PointsAdjustment!) { adjustPoints(input: $input) { userId points reason adjustedBy } }`
+	const leaderboard = `query ($first: Int!, $after: ID) {
+		leaderboard(hackathonId: "1", first: $first, after: $after) { totalCount pageInfo { endCursor } entries { rank userId points } }
+	}`
+	adjustment := func(points int) map[string]interface{} {
+		return map[string]interface{}{"input": map[string]interface{}{"hackathonId": "1", "userId": graphtest.Sponsor.UserID, "points": points, "reason": "Won the raffle"}}
+	}
+	run(t, []test{
+		{
+			name:  "events have points",
+			repo:  repo,
+			query: `{ events(first: 1) { events { points } } }`,
+			want:  `{"events": {"events": [{"points": 10}]}}`,
+		},
+		{
+			name:      "the leaderboard anonymously",
+			repo:      repo,
+			query:     leaderboard,
+			variables: map[string]interface{}{"first": 1},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeUnauthenticated},
+		},
+		{
+			name:      "the leaderboard breaks ties by who got there first",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     leaderboard,
+			variables: map[string]interface{}{"first": 1},
+			want: `{"leaderboard": {"totalCount": 2, "pageInfo": {"endCursor": "` + pagination.EncodeCursor("1") + `"},
+				"entries": [{"rank": 1, "userId": "` + graphtest.Normal.UserID + `", "points": 10}]}}`,
+		},
+		{
+			name:      "the next page of the leaderboard",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     leaderboard,
+			variables: map[string]interface{}{"first": 1, "after": pagination.EncodeCursor("1")},
+			want: `{"leaderboard": {"totalCount": 2, "pageInfo": {"endCursor": "` + pagination.EncodeCursor("2") + `"},
+				"entries": [{"rank": 2, "userId": "` + graphtest.Sponsor.UserID + `", "points": 10}]}}`,
+		},
+		{
+			name:      "a leaderboard cursor that isn't a rank",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     leaderboard,
+			variables: map[string]interface{}{"first": 1, "after": pagination.EncodeCursor("first")},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "a negative leaderboard page size",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     leaderboard,
+			variables: map[string]interface{}{"first": -1},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "adjusting points as an admin",
+			repo:      repo,
+			as:        graphtest.Admin,
+			query:     adjust,
+			variables: adjustment(5),
+			want:      `{"adjustPoints": {"userId": "` + graphtest.Sponsor.UserID + `", "points": 5, "reason": "Won the raffle", "adjustedBy": "` + graphtest.Admin.UserID + `"}}`,
+		},
+		{
+			name:      "the adjustment moves the user up",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     leaderboard,
+			variables: map[string]interface{}{"first": 1},
+			want: `{"leaderboard": {"totalCount": 2, "pageInfo": {"endCursor": "` + pagination.EncodeCursor("1") + `"},
+				"entries": [{"rank": 1, "userId": "` + graphtest.Sponsor.UserID + `", "points": 15}]}}`,
+		},
+		{
+			name:  "every award is kept",
+			repo:  repo,
+			as:    graphtest.Admin,
+			query: `{ pointsHistory(hackathonId: "1", userId: "` + graphtest.Sponsor.UserID + `") { eventId points reason } }`,
+			want:  `{"pointsHistory": [{"eventId": "1", "points": 10, "reason": "attendance"}, {"eventId": null, "points": 5, "reason": "Won the raffle"}]}`,
+		},
+		{
+			name:      "adjusting by 0 points",
+			repo:      repo,
+			as:        graphtest.Admin,
+			query:     adjust,
+			variables: adjustment(0),
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "adjusting without a reason",
+			repo:      repo,
+			as:        graphtest.Admin,
+			query:     adjust,
+			variables: map[string]interface{}{"input": map[string]interface{}{"hackathonId": "1", "userId": "2", "points": 5, "reason": ""}},
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "adjusting points as a hacker",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     adjust,
+			variables: adjustment(100),
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeForbidden},
+		},
+	})
+}
+
+func TestFeedback(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := repository.NewMemoryRepository()
+	for _, event := range []struct {
+		name string
+		end  time.Time
+	}{
+		{"Ended", now.Add(-time.Hour)},
+		{"Running", now.Add(time.Hour)},
+		{"Long ago", now.Add(-graphtest.FeedbackWindow - time.Hour)},
+		{"Skipped", now.Add(-time.Hour)},
+	} {
+		if _, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: event.name, StartDate: event.end.Add(-time.Hour), EndDate: event.end}); err != nil {
+			t.Fatalf("unable to seed events: %v", err)
+		}
+	}
+	for _, eventID := range []string{"1", "2", "3"} {
+		if _, err := repo.CheckIn(ctx, eventID, graphtest.Normal.UserID, now); err != nil {
+			t.Fatalf("CheckIn() error = %v", err)
+		}
+	}
+	if _, err := repo.CheckIn(ctx, "1", "4", now); err != nil {
+		t.Fatalf("CheckIn() error = %v", err)
+	}
+	if _, err := repo.SubmitFeedback(ctx, "1", "4", 5, "Great"); err != nil {
+		t.Fatalf("SubmitFeedback() error = %v", err)
+	}
+
+	const submit = `mutation ($input: NewFeedback!) { submitFeedback(input: $input) { userId rating comment } }`
+	const summary = `query ($representations: [_Any!]!) {
+		_entities(representations: $representations) { ... on Event { feedbackSummary { count averageRating ratings { rating responses } responses { userId rating comment } } } }
+	}`
+	feedback := func(eventID string, rating int) map[string]interface{} {
+		return map[string]interface{}{"input": map[string]interface{}{"eventId": eventID, "rating": rating, "comment": "Amazing"}}
+	}
+	event := map[string]interface{}{"representations": []interface{}{map[string]interface{}{"__typename": "Event", "id": "1"}}}
+	ratings := func(counts ...int) string {
+		var ratings []string
+		for i, count := range counts {
+			ratings = append(ratings, fmt.Sprintf(`{"rating": %d, "responses": %d}`, i+1, count))
+		}
+		return "[" + strings.Join(ratings, ", ") + "]"
+	}
+	run(t, []test{
+		{
+			name:      "admins see who answered",
+			repo:      repo,
+			as:        graphtest.Admin,
+			query:     summary,
+			variables: event,
+			want: `{"_entities": [{"feedbackSummary": {"count": 1, "averageRating": 5, "ratings": ` + ratings(0, 0, 0, 0, 1) + `,
+				"responses": [{"userId": "4", "rating": 5, "comment": "Great"}]}}]}`,
+		},
+		{
+			name:  "making a sponsor a host",
+			repo:  repo,
+			as:    graphtest.Admin,
+			query: `mutation { setEventHost(id: "1", userId: "` + graphtest.Sponsor.UserID + `", host: true) }`,
+			want:  `{"setEventHost": true}`,
+		},
+		{
+			name:      "giving feedback after checking in",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     submit,
+			variables: feedback("1", 5),
+			want:      `{"submitFeedback": {"userId": "` + graphtest.Normal.UserID + `", "rating": 5, "comment": "Amazing"}}`,
+		},
+		{
+			name:      "giving feedback twice",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     submit,
+			variables: feedback("1", 1),
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeConflict},
+		},
+		{
+			name:      "giving feedback before the event ends",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     submit,
+			variables: feedback("2", 4),
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeForbidden},
+		},
+		{
+			name:      "giving feedback after the window",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     submit,
+			variables: feedback("3", 4),
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeForbidden},
+		},
+		{
+			name:      "giving feedback without checking in",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     submit,
+			variables: feedback("4", 4),
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeForbidden},
+		},
+		{
+			name:      "a rating above 5",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     submit,
+			variables: feedback("1", 6),
+			want:      `null`,
+			wantCodes: []string{apperrors.CodeValidationFailed},
+		},
+		{
+			name:      "hosts don't see who answered",
+			repo:      repo,
+			as:        graphtest.Sponsor,
+			query:     summary,
+			variables: event,
+			want: `{"_entities": [{"feedbackSummary": {"count": 2, "averageRating": 5, "ratings": ` + ratings(0, 0, 0, 0, 2) + `,
+				"responses": [{"userId": null, "rating": 5, "comment": "Amazing"}, {"userId": null, "rating": 5, "comment": "Great"}]}}]}`,
+		},
+		{
+			name:      "attendees can't see the feedback",
+			repo:      repo,
+			as:        graphtest.Normal,
+			query:     summary,
+			variables: event,
+			want:      `{"_entities": [{"feedbackSummary": null}]}`,
+			wantCodes: []string{apperrors.CodeForbidden},
+		},
+		{
+			name:      "the feedback anonymously",
+			repo:      repo,
+			query:     summary,
+			variables: event,
+			want:      `{"_entities": [{"feedbackSummary": null}]}`,
+			wantCodes: []string{apperrors.CodeUnauthenticated},
+		},
+	})
+}
+
+// feedbackCalls is a Repository that counts the round trips feedbackSummary makes
+type feedbackCalls struct {
+	repository.Repository
+	mu        sync.Mutex
+	hosts     int
+	summaries int
+}
+
+func (r *feedbackCalls) HostedEvents(ctx context.Context, userID string, eventIDs []string) ([]string, error) {
+	r.mu.Lock()
+	r.hosts++
+	r.mu.Unlock()
+	return r.Repository.HostedEvents(ctx, userID, eventIDs)
+}
+
+func (r *feedbackCalls) GetFeedbackSummaries(ctx context.Context, eventIDs []string) (map[string]*model.FeedbackSummary, error) {
+	r.mu.Lock()
+	r.summaries++
+	r.mu.Unlock()
+	return r.Repository.GetFeedbackSummaries(ctx, eventIDs)
+}
+
+func TestFeedback_Batched(t *testing.T) {
+	ctx := context.Background()
+	repo := &feedbackCalls{Repository: repository.NewMemoryRepository()}
+	start := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		event, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: fmt.Sprintf("Event %d", i), StartDate: start, EndDate: start.Add(time.Hour)})
+		if err != nil {
+			t.Fatalf("unable to seed events: %v", err)
+		}
+		if err = repo.SetHost(ctx, event.ID, graphtest.Sponsor.UserID, true); err != nil {
+			t.Fatalf("SetHost() error = %v", err)
+		}
+	}
+
+	run(t, []test{
+		{
+			name:  "every summary of a page",
+			repo:  repo,
+			as:    graphtest.Sponsor,
+			query: `{ events(first: 3) { events { feedbackSummary { count } } } }`,
+			want:  `{"events": {"events": [{"feedbackSummary": {"count": 0}}, {"feedbackSummary": {"count": 0}}, {"feedbackSummary": {"count": 0}}]}}`,
+		},
+	})
+	if repo.hosts != 1 || repo.summaries != 1 {
+		t.Errorf("HostedEvents() was called %d times and GetFeedbackSummaries() %d times, want once each", repo.hosts, repo.summaries)
+	}
+}
+
+func TestEntities(t *testing.T) {
+	const query = `query ($representations: [_Any!]!) { _entities(representations: $representations) { ... on Event { id name } } }`
+
+	run(t, []test{
+		{
+			name:  "resolves events by id",
+			query: query,
+			variables: map[string]interface{}{"representations": []interface{}{
+				map[string]interface{}{"__typename": "Event", "id": "2"},
+				map[string]interface{}{"__typename": "Event", "id": "1"},
+			}},
+			seed: 2,
+			want: `{"_entities": [{"id": "2", "name": "Event 2"}, {"id": "1", "name": "Event 1"}]}`,
+		},
+		{
+			name:      "a missing event",
+			query:     query,
+			variables: map[string]interface{}{"representations": []interface{}{map[string]interface{}{"__typename": "Event", "id": "3"}}},
+			seed:      2,
+			want:      `{"_entities": [null]}`,
+			wantCodes: []string{apperrors.CodeNotFound},
+		},
+	})
+}
+
+func TestConstraints(t *testing.T) {
+	type args struct {
+		query     string
+		variables map[string]interface{}
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantFields []string
+	}{
+		{
+			name: "every violation of a new event",
+			args: args{
+				query: `mutation ($input: NewEvent!) { createEvent(input: $input) { id } }`,
+				variables: map[string]interface{}{"input": map[string]interface{}{
+					"name":        "",
+					"start_date":  "2023-02-03T18:00:00Z",
+					"end_date":    "2023-02-03T19:00:00Z",
+					"description": strings.Repeat("a", 2001),
+					"location":    "UCF",
+					"hackathonId": "spring",
+				}},
+			},
+			wantFields: []string{"input.name", "input.description", "input.hackathonId"},
+		},
+		{
+			name: "an update",
+			args: args{
+				query:     `mutation { updateEvent(id: "1", input: {name: "", location: ""}) { id } }`,
+				variables: nil,
+			},
+			wantFields: []string{"input.name", "input.location"},
+		},
+		{
+			name: "characters rather than bytes",
+			args: args{
+				query:     `mutation ($name: String) { updateEvent(id: "1", input: {name: $name}) { name } }`,
+				variables: map[string]interface{}{"name": strings.Repeat("é", 100)},
+			},
+			wantFields: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := graphtest.NewServer(nil)
+			event, err := server.Repository.CreateEvent(context.Background(), &model.NewEvent{HackathonID: "1", Name: "Event 1", Location: "UCF"})
+			if err != nil {
+				t.Fatalf("unable to seed events: %v", err)
+			}
+
+			response := server.Do(t, graphtest.Request{Query: tt.args.query, Variables: tt.args.variables, As: graphtest.Admin})
+			var fields []string
+			for _, err := range response.Errors {
+				if code := err.Extensions["code"]; code != apperrors.CodeValidationFailed {
+					t.Errorf("error %v has code %v, want %v", err, code, apperrors.CodeValidationFailed)
+				}
+				field, _ := err.Extensions["field"].(string)
+				fields = append(fields, field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("violations of %v, want %v", fields, tt.wantFields)
+			}
+
+			_, total, _ := server.Repository.GetEvents(context.Background(), 10, "0")
+			unchanged, _ := server.Repository.GetEvent(context.Background(), event.ID)
+			if tt.wantFields != nil && (total != 1 || !reflect.DeepEqual(unchanged, event)) {
+				t.Errorf("the resolver ran, got %d events and %v", total, unchanged)
+			}
+		})
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	server := graphtest.NewServer(nil)
+
+	const register = `mutation ($input: NewWebhook!) { createWebhook(input: $input) { secret webhook { id url topics } } }`
+	input := map[string]interface{}{"url": "https://discord.example.com/hook", "topics": []string{"EVENT_CREATED", "EVENT_DELETED", "EVENT_CREATED"}}
+	response := server.Do(t, graphtest.Request{Query: register, Variables: map[string]interface{}{"input": input}, As: graphtest.Normal})
+	if codes := response.Codes(); !reflect.DeepEqual(codes, []string{apperrors.CodeForbidden}) {
+		t.Errorf("registering as a hacker got error codes %v, want %v", codes, []string{apperrors.CodeForbidden})
+	}
+	invalid := map[string]interface{}{"url": "ftp://discord.example.com/hook", "topics": []string{}}
+	response = server.Do(t, graphtest.Request{Query: register, Variables: map[string]interface{}{"input": invalid}, As: graphtest.Admin})
+	if codes := response.Codes(); !reflect.DeepEqual(codes, []string{apperrors.CodeValidationFailed}) {
+		t.Errorf("registering an ftp URL got error codes %v, want %v", codes, []string{apperrors.CodeValidationFailed})
+	}
+
+	response = server.Do(t, graphtest.Request{Query: register, Variables: map[string]interface{}{"input": input}, As: graphtest.Admin})
+	if len(response.Errors) != 0 {
+		t.Fatalf("registering got errors %v", response.Errors)
+	}
+	var registered struct {
+		CreateWebhook struct {
+			Secret  string
+			Webhook struct {
+				ID     string
+				URL    string
+				Topics []string
+			}
+		}
+	}
+	if err := json.Unmarshal(response.Data, &registered); err != nil {
+		t.Fatalf("unable to decode data %s: %v", response.Data, err)
+	}
+	webhook := registered.CreateWebhook.Webhook
+	if registered.CreateWebhook.Secret == "" || !reflect.DeepEqual(webhook.Topics, []string{"EVENT_CREATED", "EVENT_DELETED"}) {
+		t.Errorf("createWebhook = %s, want a secret and each topic once", response.Data)
+	}
+
+	// only subscribed topics are queued
+	response = server.Do(t, graphtest.Request{
+		Query: `mutation ($input: NewEvent!) { createEvent(input: $input) { id } }`,
+		Variables: map[string]interface{}{"input": map[string]interface{}{
+			"name": "Opening Ceremony", "start_date": "2023-02-03T18:00:00Z", "end_date": "2023-02-03T19:00:00Z",
+			"description": "Kick off", "location": "UCF", "hackathonId": "1",
+		}},
+		As: graphtest.Admin,
+	})
+	if len(response.Errors) != 0 {
+		t.Fatalf("createEvent got errors %v", response.Errors)
+	}
+	server.Do(t, graphtest.Request{Query: `mutation { updateEvent(id: "1", input: {name: "Closing Ceremony"}) { id } }`, As: graphtest.Admin})
+	server.Do(t, graphtest.Request{Query: `mutation { deleteEvent(id: "1") }`, As: graphtest.Admin})
+	server.Relay(t)
+
+	deliveries, total, err := server.Webhooks.GetDeliveries(ctx, webhook.ID, 10, "0")
+	if err != nil {
+		t.Fatalf("GetDeliveries() error = %v", err)
+	}
+	if total != 2 || deliveries[0].Topic != webhooks.TopicEventDeleted || deliveries[1].Topic != webhooks.TopicEventCreated {
+		t.Fatalf("GetDeliveries() = %v, want event.deleted and event.created", deliveries)
+	}
+	var message webhooks.Message
+	if err = json.Unmarshal([]byte(deliveries[1].Payload), &message); err != nil || message.ID == "" || message.Data.(map[string]interface{})["name"] != "Opening Ceremony" {
+		t.Errorf("payload = %s, want the created event", deliveries[1].Payload)
+	}
+
+	// a delivery that failed for good is sent again
+	failure := "connection refused"
+	if err = server.Webhooks.Record(ctx, deliveries[0].ID, webhooks.Attempt{AttemptedAt: time.Now(), Error: &failure}, webhooks.StatusFailed, nil); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	const deliveriesQuery = `{ webhooks { deliveries(first: 1) { totalCount deliveries { id topic status attempts lastError log { error } } } } }`
+	response = server.Do(t, graphtest.Request{Query: deliveriesQuery, As: graphtest.Admin})
+	want := `{"webhooks": [{"deliveries": {"totalCount": 2, "deliveries": [{"id": "` + deliveries[0].ID + `", "topic": "EVENT_DELETED",
+		"status": "FAILED", "attempts": 1, "lastError": "connection refused", "log": [{"error": "connection refused"}]}]}}]}`
+	assertData(t, response, want)
+	for _, first := range []int{0, -1, graph.MaxPageSize + 1} {
+		response = server.Do(t, graphtest.Request{
+			Query: `query ($first: Int!) { webhooks { deliveries(first: $first) { totalCount } } }`, Variables: map[string]interface{}{"first": first},
+			As: graphtest.Admin,
+		})
+		if codes := response.Codes(); !reflect.DeepEqual(codes, []string{apperrors.CodeValidationFailed}) {
+			t.Errorf("deliveries(first: %d) got error codes %v, want %v", first, codes, []string{apperrors.CodeValidationFailed})
+		}
+	}
+
+	response = server.Do(t, graphtest.Request{
+		Query: `mutation ($id: ID!) { redeliverWebhook(deliveryId: $id) { status attempts } }`, Variables: map[string]interface{}{"id": deliveries[0].ID},
+		As: graphtest.Admin,
+	})
+	assertData(t, response, `{"redeliverWebhook": {"status": "PENDING", "attempts": 0}}`)
+
+	response = server.Do(t, graphtest.Request{Query: `mutation { redeliverWebhook(deliveryId: "42") { id } }`, As: graphtest.Admin})
+	if codes := response.Codes(); !reflect.DeepEqual(codes, []string{apperrors.CodeNotFound}) {
+		t.Errorf("redelivering a missing delivery got error codes %v, want %v", codes, []string{apperrors.CodeNotFound})
+	}
+
+	response = server.Do(t, graphtest.Request{Query: `mutation ($id: ID!) { deleteWebhook(id: $id) }`, Variables: map[string]interface{}{"id": webhook.ID}, As: graphtest.Admin})
+	assertData(t, response, `{"deleteWebhook": true}`)
+	response = server.Do(t, graphtest.Request{Query: `{ webhooks { id } }`, As: graphtest.Admin})
+	assertData(t, response, `{"webhooks": []}`)
+}
+
+// brokenRepository fails the way a lost database connection would
+type brokenRepository struct {
+	*repository.MemoryRepository
+}
+
+func (r brokenRepository) GetEvents(ctx context.Context, first int, after string) ([]*model.Event, int, error) {
+	return nil, 0, errors.New("failed to connect to `host=db user=postgres database=events`: dial error")
+}
+
+func (r brokenRepository) DeleteEvent(ctx context.Context, id string) (bool, error) {
+	return false, errors.New("failed to connect to `host=db user=postgres database=events`: dial error")
+}
+
+func TestErrors(t *testing.T) {
+	server := graphtest.NewServer(brokenRepository{repository.NewMemoryRepository()})
+
+	response := server.Do(t, graphtest.Request{Query: `{ events(first: 1) { totalCount } }`})
+	if codes := response.Codes(); !reflect.DeepEqual(codes, []string{apperrors.CodeInternal}) {
+		t.Fatalf("error codes = %v, want %v", codes, []string{apperrors.CodeInternal})
+	}
+	masked := response.Errors[0]
+	if strings.Contains(masked.Message, "postgres") {
+		t.Errorf("message = %q, the database error leaked", masked.Message)
+	}
+	if id, _ := masked.Extensions["correlationId"].(string); id == "" {
+		t.Errorf("extensions = %v, want a correlationId", masked.Extensions)
+	}
+	if masked.Path.String() != "events" {
+		t.Errorf("path = %v, want events", masked.Path)
+	}
+
+	response = server.Do(t, graphtest.Request{Query: `mutation { deleteEvent(id: "1") }`, As: graphtest.Normal})
+	if codes := response.Codes(); !reflect.DeepEqual(codes, []string{apperrors.CodeForbidden}) {
+		t.Errorf("a forbidden mutation got error codes %v, want %v", codes, []string{apperrors.CodeForbidden})
+	}
+
+	response = server.Do(t, graphtest.Request{
+		Query: `mutation { updateEvent(id: "1", input: {}) { id } }`,
+		As:    graphtest.Admin,
+	})
+	if len(response.Errors) != 1 || response.Errors[0].Extensions["field"] != "input" {
+		t.Errorf("an empty update got errors %v, want one on the input field", response.Errors)
+	}
+
+	// arguments gqlgen can't coerce are the client's fault and keep their message
+	response = server.Do(t, graphtest.Request{
+		Query: `mutation { updateEvent(id: "1", input: {start_date: "tomorrow"}) { id } }`,
+		As:    graphtest.Admin,
+	})
+	if len(response.Errors) != 1 || response.Errors[0].Extensions["code"] == apperrors.CodeInternal {
+		t.Errorf("an invalid argument got errors %v, want it reported as is", response.Errors)
+	}
+}
+
+func run(t *testing.T, tests []test) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := graphtest.NewServer(tt.repo)
+			for i := 1; i <= tt.seed; i++ {
+				if _, err := server.Repository.CreateEvent(context.Background(), &model.NewEvent{
+					HackathonID: "1",
+					Name:        "Event " + strconv.Itoa(i),
+					StartDate:   time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC),
+					EndDate:     time.Date(2023, time.February, 3, 20, 0, 0, 0, time.UTC),
+					Description: "Event " + strconv.Itoa(i) + " Description",
+					Location:    "UCF",
+				}); err != nil {
+					t.Fatalf("unable to seed events: %v", err)
+				}
+			}
+
+			response := server.Do(t, graphtest.Request{Query: tt.query, Variables: tt.variables, As: tt.as})
+			if codes := response.Codes(); !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("error codes = %v, want %v", codes, tt.wantCodes)
+			}
+			assertData(t, response, tt.want)
+		})
+	}
+}
+
+// assertData compares the data of response to want as JSON
+func assertData(t *testing.T, response graphtest.Response, want string) {
+	t.Helper()
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(response.Data, &gotValue); err != nil {
+		t.Fatalf("unable to decode data %s: %v", response.Data, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("unable to decode want %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("data = %s, want %s (errors %v)", response.Data, want, response.Errors)
+	}
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const defaultPingTimeout = 2 * time.Second
+
+// Checker
+// Answers the orchestrator's liveness and readiness probes
+type Checker struct {
+	DatabasePool *pgxpool.Pool
+	PingTimeout  time.Duration
+
+	draining atomic.Bool
+}
+
+func NewChecker(databasePool *pgxpool.Pool) *Checker {
+	return &Checker{
+		DatabasePool: databasePool,
+		PingTimeout:  defaultPingTimeout,
+	}
+}
+
+// SetDraining makes readiness fail so no new traffic is routed here while in-flight requests finish
+func (h *Checker) SetDraining() {
+	h.draining.Store(true)
+}
+
+type Check struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type PoolStats struct {
+	AcquiredConns        int32   `json:"acquiredConns"`
+	IdleConns            int32   `json:"idleConns"`
+	ConstructingConns    int32   `json:"constructingConns"`
+	TotalConns           int32   `json:"totalConns"`
+	MaxConns             int32   `json:"maxConns"`
+	AcquireCount         int64   `json:"acquireCount"`
+	EmptyAcquireCount    int64   `json:"emptyAcquireCount"`
+	CanceledAcquireCount int64   `json:"canceledAcquireCount"`
+	AcquireDuration      float64 `json:"acquireDurationSeconds"`
+}
+
+type Readiness struct {
+	Ready    bool             `json:"ready"`
+	Draining bool             `json:"draining"`
+	Checks   map[string]Check `json:"checks"`
+	Pool     PoolStats        `json:"pool"`
+}
+
+// Liveness only proves the process can still serve HTTP, it must never depend on the database
+// or the orchestrator would restart every pod during a database outage
+func (h *Checker) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *Checker) Readiness(c *gin.Context) {
+	readiness := h.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, readiness)
+}
+
+// Check runs every readiness check, the service is ready when all of them pass and it isn't draining
+func (h *Checker) Check(ctx context.Context) Readiness {
+	readiness := Readiness{
+		Draining: h.draining.Load(),
+		Checks: map[string]Check{
+			"database": h.checkDatabase(ctx),
+		},
+		Pool: h.poolStats(),
+	}
+
+	readiness.Ready = !readiness.Draining
+	for _, check := range readiness.Checks {
+		readiness.Ready = readiness.Ready && check.Ok
+	}
+	return readiness
+}
+
+func (h *Checker) checkDatabase(ctx context.Context) Check {
+	ctx, cancel := context.WithTimeout(ctx, h.PingTimeout)
+	defer cancel()
+
+	if err := h.DatabasePool.Ping(ctx); err != nil {
+		return Check{Error: err.Error()}
+	}
+	return Check{Ok: true}
+}
+
+func (h *Checker) poolStats() PoolStats {
+	stat := h.DatabasePool.Stat()
+	return PoolStats{
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		ConstructingConns:    stat.ConstructingConns(),
+		TotalConns:           stat.TotalConns(),
+		MaxConns:             stat.MaxConns(),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquireDuration:      stat.AcquireDuration().Seconds(),
+	}
+}
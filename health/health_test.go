@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// unreachablePool connects lazily, so it can be created without a database and every ping fails
+func unreachablePool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), "postgres://events@127.0.0.1:1/events?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("pgxpool.New() error = %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func serve(handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", handler)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	return recorder
+}
+
+func TestChecker_Liveness(t *testing.T) {
+	checker := NewChecker(unreachablePool(t))
+
+	if recorder := serve(checker.Liveness); recorder.Code != http.StatusOK {
+		t.Errorf("Liveness() status = %d, want %d without a database", recorder.Code, http.StatusOK)
+	}
+}
+
+func TestChecker_Readiness(t *testing.T) {
+	checker := NewChecker(unreachablePool(t))
+	checker.PingTimeout = time.Second
+
+	recorder := serve(checker.Readiness)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Readiness() status = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+	var readiness Readiness
+	if err := json.Unmarshal(recorder.Body.Bytes(), &readiness); err != nil {
+		t.Fatalf("Readiness() body = %s, %v", recorder.Body, err)
+	}
+	if readiness.Ready || readiness.Draining {
+		t.Errorf("Readiness() ready = %v, draining = %v, want false, false", readiness.Ready, readiness.Draining)
+	}
+	if check := readiness.Checks["database"]; check.Ok || check.Error == "" {
+		t.Errorf("Readiness() database check = %+v, want the ping error", check)
+	}
+	if len(readiness.Checks) != 1 {
+		t.Errorf("Readiness() checks = %v, want only the database", readiness.Checks)
+	}
+}
@@ -0,0 +1,23 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/KnightHacks/knighthacks_shared/auth"
+)
+
+// claimsKey is where auth.AuthContextMiddleware stores the caller's *auth.UserClaims, knighthacks_shared doesn't
+// export it so identity_test.go checks it against auth.HasRoleDirective which reads the same key
+const claimsKey = "AuthorizationUserClaims"
+
+// FromContext returns the claims of the authenticated caller, ok is false for anonymous requests
+func FromContext(ctx context.Context) (claims *auth.UserClaims, ok bool) {
+	claims, ok = ctx.Value(claimsKey).(*auth.UserClaims)
+	return claims, ok && claims != nil
+}
+
+// WithClaims attaches claims the same way auth.AuthContextMiddleware does, mostly useful for tests
+func WithClaims(ctx context.Context, claims *auth.UserClaims) context.Context {
+	//lint:ignore SA1029 the key is defined by knighthacks_shared
+	return context.WithValue(ctx, claimsKey, claims)
+}
@@ -0,0 +1,57 @@
+package identity_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/KnightHacks/knighthacks_shared/auth"
+	"github.com/KnightHacks/knighthacks_shared/models"
+	"github.com/KnightHacks/knighthacks_shared/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TestWithClaims_SharedDirective fails when knighthacks_shared stores the claims under a different key than identity
+// reads them from, the directive is the only thing in the shared module that reads them back
+func TestWithClaims_SharedDirective(t *testing.T) {
+	directive := auth.HasRoleDirective{GetUserId: auth.DefaultGetUserId}
+	next := func(ctx context.Context) (interface{}, error) { return "ok", nil }
+
+	claims := &auth.UserClaims{UserID: "1", Role: models.RoleAdmin}
+	ctx := identity.WithClaims(context.Background(), claims)
+	if _, err := directive.Direct(ctx, nil, next, models.RoleAdmin); err != nil {
+		t.Fatalf("Direct() error = %v, want the directive to find the claims", err)
+	}
+	if got, ok := identity.FromContext(ctx); !ok || got != claims {
+		t.Errorf("FromContext() = %v, %v, want %v", got, ok, claims)
+	}
+
+	if _, err := directive.Direct(context.Background(), nil, next, models.RoleAdmin); err == nil {
+		t.Fatal("Direct() error = nil, want anonymous callers to be rejected")
+	}
+	if _, ok := identity.FromContext(context.Background()); ok {
+		t.Error("FromContext() ok = true for an anonymous caller")
+	}
+}
+
+func TestFromContext_Middleware(t *testing.T) {
+	a, err := auth.NewAuthWithEnvironment()
+	if err != nil {
+		t.Skipf("auth is not configured: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth.AuthContextMiddleware(a), utils.GinContextMiddleware())
+
+	var ok bool
+	router.GET("/", func(c *gin.Context) {
+		_, ok = identity.FromContext(c.Request.Context())
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if ok {
+		t.Error("FromContext() ok = true for a request without an Authorization header")
+	}
+}
@@ -0,0 +1,32 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_shared/auth"
+	"github.com/KnightHacks/knighthacks_shared/models"
+)
+
+// HasRoleFunc is the shape of the @hasRole directive, REST handlers call it with a nil obj to run the same checks
+type HasRoleFunc func(ctx context.Context, obj interface{}, next graphql.Resolver, role models.Role) (interface{}, error)
+
+// HasRole reports the requests the shared directive rejects as UNAUTHENTICATED or FORBIDDEN, the directive itself
+// returns errors without a code which would otherwise be masked as INTERNAL
+func HasRole(directive auth.HasRoleDirective) HasRoleFunc {
+	return func(ctx context.Context, obj interface{}, next graphql.Resolver, role models.Role) (interface{}, error) {
+		allowed := false
+		res, err := directive.Direct(ctx, obj, func(ctx context.Context) (interface{}, error) {
+			allowed = true
+			return next(ctx)
+		}, role)
+		if err == nil || allowed {
+			return res, err
+		}
+		if _, ok := FromContext(ctx); !ok {
+			return nil, apperrors.Wrap(apperrors.CodeUnauthenticated, err)
+		}
+		return nil, apperrors.Wrap(apperrors.CodeForbidden, err)
+	}
+}
@@ -0,0 +1,56 @@
+package integration_tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/outbox"
+	"github.com/KnightHacks/knighthacks_events/repository/repositorytest"
+)
+
+func TestDatabaseRepository_Conformance(t *testing.T) {
+	hackathonID := createHackathon(t)
+	repositorytest.Run(t, databaseRepository, hackathonID)
+	t.Run("Outbox", func(t *testing.T) {
+		repositorytest.RunOutbox(t, databaseRepository, hackathonID, outbox.NewPostgresStore(databaseRepository.DatabasePool))
+	})
+	userID := createUser(t, "conformance@knighthacks.org")
+	repositorytest.RunInterests(t, databaseRepository, hackathonID, userID)
+	repositorytest.RunAttendance(t, databaseRepository, hackathonID, userID)
+	userIDs := [3]string{userID, createUser(t, "conformance2@knighthacks.org"), createUser(t, "conformance3@knighthacks.org")}
+	repositorytest.RunAttendanceStats(t, databaseRepository, createHackathon(t), userIDs)
+	repositorytest.RunPoints(t, databaseRepository, createHackathon(t), userIDs)
+	repositorytest.RunFeedback(t, databaseRepository, hackathonID, userIDs)
+}
+
+// createHackathon inserts a hackathon, which belongs to another service along with its term, and returns its id.
+// Every hackathon needs a term of its own.
+func createHackathon(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	pool := databaseRepository.DatabasePool
+
+	var termID, hackathonID int
+	if err := pool.QueryRow(ctx, "INSERT INTO terms (year, semester) VALUES (2023, 'SPRING') RETURNING id").Scan(&termID); err != nil {
+		t.Fatalf("unable to create term: %v", err)
+	}
+	err := pool.QueryRow(ctx, "INSERT INTO hackathons (term_id, start_date, end_date) VALUES ($1, '2023-02-03', '2023-02-05') RETURNING id", termID).
+		Scan(&hackathonID)
+	if err != nil {
+		t.Fatalf("unable to create hackathon: %v", err)
+	}
+	return strconv.Itoa(hackathonID)
+}
+
+// createUser inserts a user, which belongs to another service, with only the required columns and returns its id
+func createUser(t *testing.T, email string) string {
+	t.Helper()
+	var id int
+	err := databaseRepository.DatabasePool.QueryRow(context.Background(), `INSERT INTO users (email, last_name, first_name, role, oauth_uid, oauth_provider, shirt_size)
+		VALUES ($1, 'Knight', 'Kenny', 'NORMAL', $1, 'GITHUB', 'M') RETURNING id`, email).Scan(&id)
+	if err != nil {
+		t.Fatalf("unable to create user: %v", err)
+	}
+	return strconv.Itoa(id)
+}
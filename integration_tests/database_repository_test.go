@@ -2,15 +2,18 @@ package integration_tests
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/migrations"
 	"github.com/KnightHacks/knighthacks_events/repository"
 	"github.com/KnightHacks/knighthacks_shared/database"
 	shared_db_utils "github.com/KnightHacks/knighthacks_shared/database"
@@ -45,6 +48,15 @@ func TestMain(t *testing.M) {
 		log.Fatalf("unable to connect to database err=%v\n", err)
 	}
 
+	// the schema of this service comes from the same migrations production runs, init.sql only has the other services'
+	migrator, err := migrations.NewMigrator(pool)
+	if err != nil {
+		log.Fatalf("unable to load migrations err=%v\n", err)
+	}
+	if _, err = migrator.Up(context.Background()); err != nil {
+		log.Fatalf("unable to migrate database err=%v\n", err)
+	}
+
 	databaseRepository = repository.NewDatabaseRepository(pool)
 	os.Exit(t.Run())
 }
@@ -77,6 +89,22 @@ func TestDatabaseRepository_CreateEvent(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "create for a missing hackathon",
+			args: args{
+				ctx: context.Background(),
+				input: &model.NewEvent{
+					Name:        "Orphan",
+					StartDate:   time.Date(2000, time.January, 1, 1, 1, 1, 1, time.UTC),
+					EndDate:     time.Date(2000, time.February, 1, 1, 1, 1, 1, time.UTC),
+					Description: "Orphan Description",
+					Location:    "UCF",
+					HackathonID: "2147483647",
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		// TODO: review
 	}
 	for _, tt := range tests {
@@ -86,6 +114,9 @@ func TestDatabaseRepository_CreateEvent(t *testing.T) {
 				t.Errorf("CreateEvent() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErr && !errors.Is(err, repository.HackathonNotFound) {
+				t.Errorf("CreateEvent() error = %v, want %v", err, repository.HackathonNotFound)
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("CreateEvent() got = %v, want %v", got, tt.want)
 			}
@@ -170,6 +201,56 @@ func TestDatabaseRepository_GetEvent(t *testing.T) {
 	}
 }
 
+func TestDatabaseRepository_GetEventsByIDs(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		ids []string
+	}
+	tests := []Test[args, []string]{
+		{
+			name: "get events 1 and 2",
+			args: args{
+				ctx: context.Background(),
+				ids: []string{"1", "2"},
+			},
+			want: []string{"1", "2"},
+		},
+		{
+			name: "skip missing and malformed ids",
+			args: args{
+				ctx: context.Background(),
+				ids: []string{"1", "13579111315", "not an id"},
+			},
+			want: []string{"1"},
+		},
+		{
+			name: "no ids",
+			args: args{
+				ctx: context.Background(),
+				ids: []string{},
+			},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := databaseRepository.GetEventsByIDs(tt.args.ctx, tt.args.ids)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetEventsByIDs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			got := make([]string, 0, len(events))
+			for _, event := range events {
+				got = append(got, event.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetEventsByIDs() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestDatabaseRepository_GetEvents(t *testing.T) {
 	type args struct {
 		ctx   context.Context
@@ -253,6 +334,30 @@ func TestDatabaseRepository_GetEvents(t *testing.T) {
 	}
 }
 
+func TestDatabaseRepository_AdjustPoints(t *testing.T) {
+	hackathonID := createHackathon(t)
+	userID := createUser(t, "points@knighthacks.org")
+
+	type args struct {
+		hackathonID string
+		userID      string
+	}
+	tests := []Test[args, error]{
+		{name: "an existing user", args: args{hackathonID: hackathonID, userID: userID}},
+		{name: "a missing user", args: args{hackathonID: hackathonID, userID: "999999"}, want: repository.UserNotFound, wantErr: true},
+		{name: "a missing hackathon", args: args{hackathonID: "999999", userID: userID}, want: repository.HackathonNotFound, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := &model.PointsAdjustment{HackathonID: tt.args.hackathonID, UserID: tt.args.userID, Points: 3, Reason: "Found a bug"}
+			_, err := databaseRepository.AdjustPoints(context.Background(), input, userID)
+			if (err != nil) != tt.wantErr || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Errorf("AdjustPoints() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
 func TestDatabaseRepository_UpdateDescription(t *testing.T) {
 	type args struct {
 		ctx         context.Context
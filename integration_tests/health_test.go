@@ -0,0 +1,25 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/health"
+)
+
+func TestChecker_Check(t *testing.T) {
+	checker := health.NewChecker(databaseRepository.DatabasePool)
+
+	readiness := checker.Check(context.Background())
+	if !readiness.Ready || !readiness.Checks["database"].Ok {
+		t.Errorf("Check() = %+v, want ready", readiness)
+	}
+	if readiness.Pool.MaxConns == 0 {
+		t.Errorf("Check() pool = %+v, want the pool statistics", readiness.Pool)
+	}
+
+	checker.SetDraining()
+	if readiness = checker.Check(context.Background()); readiness.Ready || !readiness.Draining {
+		t.Errorf("Check() while draining = %+v, want not ready", readiness)
+	}
+}
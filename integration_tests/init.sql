@@ -1,4 +1,5 @@
 -- SCHEMA START
+-- tables owned by the other services, the tables of this service are created by the migrations package
 create type semester as enum ('FALL', 'SPRING', 'SUMMER');
 
 create type subscription_tier as enum ('BRONZE', 'SILVER', 'GOLD', 'PLATINUM');
@@ -105,21 +106,6 @@ create table hackathon_sponsors
             references sponsors (id)
 );
 
-create table events
-(
-    id           serial
-        constraint events_pk
-            primary key,
-    hackathon_id integer   not null
-        constraint events_hackathons_id_fk
-            references hackathons,
-    location     varchar   not null,
-    start_date   timestamp not null,
-    end_date     timestamp not null,
-    name         varchar   not null,
-    description  varchar   not null
-);
-
 create table hackathon_applications
 (
     id                        serial
@@ -178,19 +164,6 @@ create table education_info
     level           varchar
 );
 
-create table event_attendance
-(
-    event_id integer                 not null
-        constraint event_attendance_events_id_fk
-            references events,
-    user_id  integer                 not null
-        constraint event_attendance_users_id_fk
-            references users,
-    time     timestamp default now() not null,
-    constraint event_attendance_pk
-        primary key (event_id, user_id)
-);
-
 create table meals
 (
     hackathon_id integer             not null
@@ -0,0 +1,118 @@
+package integration_tests
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestMigrator_Status(t *testing.T) {
+	migrator, err := migrations.NewMigrator(databaseRepository.DatabasePool)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(statuses) != len(migrator.Migrations) {
+		t.Fatalf("Status() got %d migrations, want %d", len(statuses), len(migrator.Migrations))
+	}
+	// TestMain already applied everything
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("Status() migration %d_%s is pending", status.Version, status.Name)
+		}
+	}
+}
+
+// isolatedMigrator migrates a schema of its own, reverting migrations in the shared schema would pull it out from
+// under every other test. The tables of the other services are still found in public through the search path
+func isolatedMigrator(t *testing.T) *migrations.Migrator {
+	t.Helper()
+	ctx := context.Background()
+	const schema = "migrations_test"
+
+	pool := databaseRepository.DatabasePool
+	for _, statement := range []string{"DROP SCHEMA IF EXISTS " + schema + " CASCADE", "CREATE SCHEMA " + schema} {
+		if _, err := pool.Exec(ctx, statement); err != nil {
+			t.Fatalf("unable to create schema: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		if _, err := pool.Exec(context.Background(), "DROP SCHEMA IF EXISTS "+schema+" CASCADE"); err != nil {
+			t.Errorf("unable to drop schema: %v", err)
+		}
+	})
+
+	config, err := pgxpool.ParseConfig(*databaseUri)
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema + ",public"
+	isolated, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("NewWithConfig() error = %v", err)
+	}
+	t.Cleanup(isolated.Close)
+
+	migrator, err := migrations.NewMigrator(isolated)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	return migrator
+}
+
+func TestMigrator_DownUp(t *testing.T) {
+	migrator := isolatedMigrator(t)
+	all := make([]int, len(migrator.Migrations))
+	for i, migration := range migrator.Migrations {
+		all[i] = migration.Version
+	}
+	latest := all[len(all)-1]
+
+	type args struct {
+		up    bool
+		steps int
+	}
+	tests := []Test[args, []int]{
+		{name: "up applies everything to an empty schema", args: args{up: true}, want: all},
+		{name: "down reverts the latest migration", args: args{steps: 1}, want: []int{latest}},
+		{name: "up applies it again", args: args{up: true}, want: []int{latest}},
+		{name: "up again is a no-op", args: args{up: true}, want: nil},
+		{name: "down reverts everything but the first migration", args: args{steps: len(all) - 1}, want: all[1:]},
+		{name: "down refuses to drop the adopted tables", args: args{steps: 1}, want: nil, wantErr: true},
+		{name: "up applies the rest again", args: args{up: true}, want: all[1:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []migrations.Migration
+			var err error
+			if tt.args.up {
+				got, err = migrator.Up(context.Background())
+			} else {
+				got, err = migrator.Down(context.Background(), tt.args.steps)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d migrations, want %v", len(got), tt.want)
+			}
+			// down returns the newest first
+			if !tt.args.up {
+				sort.Slice(got, func(i, j int) bool { return got[i].Version < got[j].Version })
+			}
+			for i := range got {
+				if got[i].Version != tt.want[i] {
+					t.Errorf("got version %d, want %d", got[i].Version, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/outbox"
+	"github.com/KnightHacks/knighthacks_events/outbox/outboxtest"
+)
+
+func TestOutboxPostgresStore_Conformance(t *testing.T) {
+	pool := databaseRepository.DatabasePool
+	outboxtest.Run(t, outbox.NewPostgresStore(pool), func(t *testing.T, aggregateID string, topic string) {
+		if err := outbox.Insert(context.Background(), pool, aggregateID, topic, map[string]string{"id": aggregateID}); err != nil {
+			t.Fatalf("Insert() error = %v", err)
+		}
+	})
+}
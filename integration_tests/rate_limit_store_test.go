@@ -0,0 +1,37 @@
+package integration_tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+)
+
+func TestPostgresStore_Take(t *testing.T) {
+	store := ratelimit.NewPostgresStore(databaseRepository.DatabasePool)
+	limit := ratelimit.Limit{Rate: 1, Burst: 2}
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	type args struct {
+		now time.Time
+	}
+	tests := []Test[args, bool]{
+		{name: "first token of the burst", args: args{now: start}, want: true},
+		{name: "second token of the burst", args: args{now: start}, want: true},
+		{name: "burst used up", args: args{now: start}, want: false},
+		{name: "refilled after a second", args: args{now: start.Add(time.Second)}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := store.Take(context.Background(), "integration-test", limit, tt.args.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Take() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Take() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package integration_tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/reminders"
+	"github.com/KnightHacks/knighthacks_events/reminders/reminderstest"
+)
+
+func TestReminderPostgresStore_Conformance(t *testing.T) {
+	ctx := context.Background()
+	hackathonID := createHackathon(t)
+	users := 0
+	reminderstest.Run(t, reminders.NewPostgresStore(databaseRepository.DatabasePool), func(t *testing.T, startDate time.Time, rsvp bool, bookmark bool) reminders.Reminder {
+		event, err := databaseRepository.CreateEvent(ctx, &model.NewEvent{
+			HackathonID: hackathonID,
+			Name:        "Reminder Conformance",
+			StartDate:   startDate,
+			EndDate:     startDate.Add(time.Hour),
+			Description: "Reminder Conformance Description",
+			Location:    "UCF",
+		})
+		if err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+		users++
+		email := "reminders-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.Itoa(users) + "@knighthacks.org"
+		userID := createUser(t, email)
+		if rsvp {
+			if err = databaseRepository.SetRSVP(ctx, event.ID, userID, true); err != nil {
+				t.Fatalf("SetRSVP() error = %v", err)
+			}
+		}
+		if bookmark {
+			if err = databaseRepository.SetBookmark(ctx, event.ID, userID, true); err != nil {
+				t.Fatalf("SetBookmark() error = %v", err)
+			}
+		}
+		return reminders.Reminder{EventID: event.ID, EventName: event.Name, Location: event.Location, StartDate: startDate, UserID: userID, Email: email}
+	})
+}
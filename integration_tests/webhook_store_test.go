@@ -0,0 +1,51 @@
+package integration_tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+	"github.com/KnightHacks/knighthacks_events/webhooks/webhookstest"
+)
+
+func newWebhookStore(t *testing.T) *webhooks.PostgresStore {
+	t.Helper()
+	secrets, err := webhooks.NewSecretBox("integration-test-webhook-secret-key")
+	if err != nil {
+		t.Fatalf("NewSecretBox() error = %v", err)
+	}
+	return webhooks.NewPostgresStore(databaseRepository.DatabasePool, secrets)
+}
+
+func TestPostgresStore_Conformance(t *testing.T) {
+	webhookstest.Run(t, newWebhookStore(t))
+}
+
+func TestPostgresStore_SecretEncrypted(t *testing.T) {
+	ctx := context.Background()
+	store := newWebhookStore(t)
+	endpoint, err := store.CreateEndpoint(ctx, "https://example.com/encrypted", []webhooks.Topic{webhooks.TopicEventCreated}, "plaintext-secret")
+	if err != nil {
+		t.Fatalf("CreateEndpoint() error = %v", err)
+	}
+	t.Cleanup(func() { store.DeleteEndpoint(ctx, endpoint.ID) })
+
+	var stored string
+	if err = store.DatabasePool.QueryRow(ctx, "SELECT secret FROM webhooks WHERE id = $1", endpoint.ID).Scan(&stored); err != nil {
+		t.Fatalf("unable to read the secret: %v", err)
+	}
+	if strings.Contains(stored, "plaintext-secret") {
+		t.Errorf("stored secret = %q, want it encrypted", stored)
+	}
+
+	endpoints, err := store.GetEndpoints(ctx)
+	if err != nil {
+		t.Fatalf("GetEndpoints() error = %v", err)
+	}
+	for _, got := range endpoints {
+		if got.ID == endpoint.ID && got.Secret != "plaintext-secret" {
+			t.Errorf("GetEndpoints() secret = %q, want it decrypted", got.Secret)
+		}
+	}
+}
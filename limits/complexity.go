@@ -0,0 +1,54 @@
+package limits
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/KnightHacks/knighthacks_events/graph/generated"
+)
+
+// ErrComplexityLimitCode is the code extension.ComplexityLimit puts on rejected operations
+const ErrComplexityLimitCode = "COMPLEXITY_LIMIT_EXCEEDED"
+
+func init() {
+	// gqlgen treats unknown codes as user errors, rejected operations are never executed so they deserve a 422
+	errcode.RegisterErrorType(ErrComplexityLimitCode, errcode.KindProtocol)
+}
+
+// FeedbackSummaryCost is what the feedbackSummary of an event costs on top of its selection, it reads every response
+const FeedbackSummaryCost = 10
+
+// SetComplexity weights every list returning field by how many elements it can return,
+// anything not set here keeps gqlgen's default of 1 + childComplexity. A negative first counts as none rather than
+// taking the cost of its siblings away.
+func SetComplexity(complexity *generated.ComplexityRoot) {
+	complexity.Event.FeedbackSummary = func(childComplexity int) int {
+		return FeedbackSummaryCost + childComplexity
+	}
+	complexity.Query.Events = func(childComplexity int, first int, after *string) int {
+		return max(first, 0) * childComplexity
+	}
+	complexity.Query.Leaderboard = func(childComplexity int, hackathonID string, first int, after *string) int {
+		return max(first, 0) * childComplexity
+	}
+	complexity.Webhook.Deliveries = func(childComplexity int, first int, after *string) int {
+		return max(first, 0) * childComplexity
+	}
+}
+
+// WithEntitiesComplexity weights _entities by the number of representations,
+// the generated ComplexityRoot keeps that field unexported so it has to be done on the schema itself
+func WithEntitiesComplexity(schema graphql.ExecutableSchema) graphql.ExecutableSchema {
+	return entitiesComplexity{schema}
+}
+
+type entitiesComplexity struct {
+	graphql.ExecutableSchema
+}
+
+func (e entitiesComplexity) Complexity(typeName, field string, childComplexity int, rawArgs map[string]interface{}) (int, bool) {
+	if typeName == "Query" && field == "_entities" {
+		representations, _ := rawArgs["representations"].([]interface{})
+		return len(representations) * childComplexity, true
+	}
+	return e.ExecutableSchema.Complexity(typeName, field, childComplexity, rawArgs)
+}
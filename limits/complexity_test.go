@@ -0,0 +1,64 @@
+package limits_test
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/KnightHacks/knighthacks_events/graph/generated"
+	"github.com/KnightHacks/knighthacks_events/graph/graphtest"
+	"github.com/KnightHacks/knighthacks_events/limits"
+	"github.com/vektah/gqlparser/v2"
+)
+
+func TestComplexity(t *testing.T) {
+	schema := graph.NewExecutableSchema(&graph.Resolver{}, graphtest.HasRole)
+	entities := `_entities(representations: [{__typename: "Event", id: "1"}, {__typename: "Event", id: "2"}]) { ... on Event { id } }`
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{name: "events by first", query: `{ events(first: 10) { events { id name } } }`, want: 10 * 3},
+		{name: "leaderboard by first", query: `{ leaderboard(hackathonId: "1", first: 5) { entries { userId } } }`, want: 5 * 2},
+		{name: "deliveries by first", query: `{ webhooks { deliveries(first: 4) { deliveries { id } } } }`, want: 1 + 4*2},
+		{name: "feedback summaries per event", query: `{ events(first: 10) { events { feedbackSummary { count } } } }`, want: 10 * (1 + limits.FeedbackSummaryCost + 1)},
+		{name: "_entities by representations", query: "{ " + entities + " }", want: 2 * 1},
+		// costs what its fields do, as a negative cost it would cancel out _entities
+		{name: "a negative first", query: `{ events(first: -100) { events { id } } }`, want: 1 + 2},
+		{name: "a negative first next to _entities", query: `{ events(first: -100) { events { id } } ` + entities + ` }`, want: 1 + 2 + 2},
+		{name: "a negative leaderboard next to _entities", query: `{ leaderboard(hackathonId: "1", first: -100) { entries { userId } } ` + entities + ` }`, want: 1 + 2 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := gqlparser.LoadQuery(schema.Schema(), tt.query)
+			if err != nil {
+				t.Fatalf("unable to parse query: %v", err)
+			}
+			if got := complexity.Calculate(schema, document.Operations.ForName(""), map[string]interface{}{}); got != tt.want {
+				t.Errorf("complexity = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetComplexity(t *testing.T) {
+	var root generated.ComplexityRoot
+	limits.SetComplexity(&root)
+
+	if got := root.Event.FeedbackSummary(3); got != limits.FeedbackSummaryCost+3 {
+		t.Errorf("feedbackSummary = %d, want %d", got, limits.FeedbackSummaryCost+3)
+	}
+	for first, want := range map[int]int{10: 30, 0: 0, -100: 0} {
+		if got := root.Query.Events(3, first, nil); got != want {
+			t.Errorf("events(first: %d) = %d, want %d", first, got, want)
+		}
+		if got := root.Query.Leaderboard(3, "1", first, nil); got != want {
+			t.Errorf("leaderboard(first: %d) = %d, want %d", first, got, want)
+		}
+		if got := root.Webhook.Deliveries(3, first, nil); got != want {
+			t.Errorf("deliveries(first: %d) = %d, want %d", first, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package limits
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const ErrDepthLimitCode = "DEPTH_LIMIT_EXCEEDED"
+
+func init() {
+	errcode.RegisterErrorType(ErrDepthLimitCode, errcode.KindProtocol)
+}
+
+// DepthLimit
+// Rejects operations that nest fields deeper than Max, introspection fields are not counted
+// so the playground and the federation gateway can still load the schema
+type DepthLimit struct {
+	Max int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = DepthLimit{}
+
+func (d DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	if d.Max < 1 {
+		return fmt.Errorf("DepthLimit.Max must be at least 1")
+	}
+	return nil
+}
+
+func (d DepthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	operation := rc.Doc.Operations.ForName(rc.OperationName)
+	if operation == nil {
+		return nil
+	}
+
+	if depth := selectionDepth(operation.SelectionSet); depth > d.Max {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.Max)
+		errcode.Set(err, ErrDepthLimitCode)
+		return err
+	}
+	return nil
+}
+
+// selectionDepth only counts fields, fragments are flattened into the selection they are spread in.
+// Fragment cycles are rejected by validation before this ever runs
+func selectionDepth(selectionSet ast.SelectionSet) int {
+	deepest := 0
+	for _, selection := range selectionSet {
+		var depth int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			depth = 1 + selectionDepth(selection.SelectionSet)
+		case *ast.InlineFragment:
+			depth = selectionDepth(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				depth = selectionDepth(selection.Definition.SelectionSet)
+			}
+		}
+		if depth > deepest {
+			deepest = depth
+		}
+	}
+	return deepest
+}
@@ -0,0 +1,64 @@
+package limits_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/KnightHacks/knighthacks_events/graph/graphtest"
+	"github.com/KnightHacks/knighthacks_events/limits"
+	"github.com/vektah/gqlparser/v2"
+)
+
+func TestDepthLimit(t *testing.T) {
+	schema := graph.NewExecutableSchema(&graph.Resolver{}, graphtest.HasRole)
+	limit := limits.DepthLimit{Max: 4}
+
+	tests := []struct {
+		name     string
+		query    string
+		rejected bool
+	}{
+		{name: "at the limit", query: `{ events(first: 1) { events { feedbackSummary { count } } } }`},
+		{name: "over the limit", query: `{ events(first: 1) { events { feedbackSummary { ratings { rating } } } } }`, rejected: true},
+		{
+			name:     "through a fragment spread",
+			query:    `{ events(first: 1) { ...Page } } fragment Page on EventsConnection { events { feedbackSummary { ratings { rating } } } }`,
+			rejected: true,
+		},
+		{
+			name:     "through an inline fragment",
+			query:    `{ events(first: 1) { ... on EventsConnection { events { feedbackSummary { ratings { rating } } } } } }`,
+			rejected: true,
+		},
+		{name: "fragments don't count themselves", query: `{ events(first: 1) { ... on EventsConnection { ...Page } } } fragment Page on EventsConnection { events { feedbackSummary { count } } }`},
+		{name: "__type", query: `{ __type(name: "Event") { fields { type { ofType { ofType { name } } } } } }`},
+		{name: "__schema", query: `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`},
+		{name: "__typename", query: `{ events(first: 1) { events { feedbackSummary { count __typename } } } }`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := gqlparser.LoadQuery(schema.Schema(), tt.query)
+			if err != nil {
+				t.Fatalf("unable to parse query: %v", err)
+			}
+			gqlErr := limit.MutateOperationContext(context.Background(), &graphql.OperationContext{Doc: document})
+			if !tt.rejected {
+				if gqlErr != nil {
+					t.Errorf("MutateOperationContext() = %v, want the operation accepted", gqlErr)
+				}
+				return
+			}
+			if gqlErr == nil {
+				t.Fatal("MutateOperationContext() = nil, want the operation rejected")
+			}
+			if code := gqlErr.Extensions["code"]; code != limits.ErrDepthLimitCode {
+				t.Errorf("code extension = %v, want %s", code, limits.ErrDepthLimitCode)
+			}
+			if want := "operation has depth 5, which exceeds the limit of 4"; gqlErr.Message != want {
+				t.Errorf("message = %q, want %q", gqlErr.Message, want)
+			}
+		})
+	}
+}
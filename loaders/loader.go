@@ -0,0 +1,143 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchFunc loads every key in a single round trip, the returned values and errors must line up with keys
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) ([]V, []error)
+
+// Loader
+// Collects the keys requested within wait of each other and resolves them with one call to fetch,
+// results are memoized for the lifetime of the loader so it should be created once per request.
+// A batch is fetched without the cancellation of the caller that started it, so callers that give up don't fail the
+// others waiting on the same batch.
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*result[V]
+	batch *batch[K, V]
+}
+
+type result[V any] struct {
+	value V
+	err   error
+	done  chan struct{}
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+	closed  bool
+}
+
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V], wait time.Duration, maxBatch int) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		cache:    map[K]*result[V]{},
+	}
+}
+
+// Load queues key into the current batch and blocks until the batch has been fetched
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	if res, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return res.wait(ctx)
+	}
+
+	res := &result[V]{done: make(chan struct{})}
+	l.cache[key] = res
+
+	// keeps the values of ctx, such as the caller's claims and trace, but not its deadline or cancellation
+	fetchCtx := context.WithoutCancel(ctx)
+	if l.batch == nil {
+		l.batch = &batch[K, V]{}
+		go l.dispatchAfterWait(fetchCtx, l.batch)
+	}
+	b := l.batch
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, res)
+	if l.maxBatch > 0 && len(b.keys) >= l.maxBatch {
+		l.batch = nil
+		b.closed = true
+		go l.dispatch(fetchCtx, b)
+	}
+	l.mu.Unlock()
+
+	return res.wait(ctx)
+}
+
+func (l *Loader[K, V]) dispatchAfterWait(ctx context.Context, b *batch[K, V]) {
+	time.Sleep(l.wait)
+
+	l.mu.Lock()
+	if b.closed {
+		// already dispatched because it filled up
+		l.mu.Unlock()
+		return
+	}
+	b.closed = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	l.dispatch(ctx, b)
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	values, errs := l.safeFetch(ctx, b.keys)
+	for i, res := range b.results {
+		if i < len(values) {
+			res.value = values[i]
+		}
+		if i < len(errs) {
+			res.err = errs[i]
+		}
+		// a timeout says nothing about the key, the next Load fetches it again
+		if errors.Is(res.err, context.Canceled) || errors.Is(res.err, context.DeadlineExceeded) {
+			l.mu.Lock()
+			if l.cache[b.keys[i]] == res {
+				delete(l.cache, b.keys[i])
+			}
+			l.mu.Unlock()
+		}
+		close(res.done)
+	}
+}
+
+// safeFetch turns a panic into an error for every key, fetch runs on its own goroutine
+// where a panic would otherwise take down the whole server
+func (l *Loader[K, V]) safeFetch(ctx context.Context, keys []K) (values []V, errs []error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("loader panicked: %v", r)
+			values = nil
+			errs = make([]error, len(keys))
+			for i := range errs {
+				errs[i] = err
+			}
+		}
+	}()
+	return l.fetch(ctx, keys)
+}
+
+func (r *result[V]) wait(ctx context.Context) (V, error) {
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
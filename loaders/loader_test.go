@@ -0,0 +1,140 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a BatchFunc that doubles its keys and keeps the batches it was called with
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	// err is returned for every key while it is set
+	err error
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) ([]int, []error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	batch := append([]int(nil), keys...)
+	sort.Ints(batch)
+	r.batches = append(r.batches, batch)
+
+	values := make([]int, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		values[i], errs[i] = key*2, r.err
+	}
+	return values, errs
+}
+
+// loadAll loads every key from its own goroutine, the way resolvers of sibling fields do
+func loadAll(ctx context.Context, loader *Loader[int, int], keys ...int) ([]int, []error) {
+	values := make([]int, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key int) {
+			defer wg.Done()
+			values[i], errs[i] = loader.Load(ctx, key)
+		}(i, key)
+	}
+	wg.Wait()
+	return values, errs
+}
+
+func TestLoader_Load(t *testing.T) {
+	recorder := &recorder{}
+	loader := NewLoader(recorder.fetch, 10*time.Millisecond, 3)
+
+	values, errs := loadAll(context.Background(), loader, 1, 2, 3, 4, 1)
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if want := []int{1, 2, 3, 4, 1}[i] * 2; values[i] != want {
+			t.Errorf("Load() = %d, want %d", values[i], want)
+		}
+	}
+	// a repeated key is fetched once and a full batch goes out without waiting for the rest
+	if len(recorder.batches) != 2 || len(recorder.batches[0])+len(recorder.batches[1]) != 4 {
+		t.Errorf("batches = %v, want the 4 keys in a batch of 3 and one of 1", recorder.batches)
+	}
+
+	if _, err := loader.Load(context.Background(), 4); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(recorder.batches) != 2 {
+		t.Errorf("batches = %v, want a loaded key to be memoized", recorder.batches)
+	}
+}
+
+func TestLoader_LoadCancelled(t *testing.T) {
+	recorder := &recorder{}
+	loader := NewLoader(recorder.fetch, 20*time.Millisecond, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := loader.Load(ctx, 1)
+		cancelled <- err
+	}()
+	// joins the batch the cancelled caller started
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	values, errs := loadAll(context.Background(), loader, 2)
+
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("Load() of the cancelled caller error = %v, want %v", err, context.Canceled)
+	}
+	if errs[0] != nil || values[0] != 4 {
+		t.Errorf("Load() = %d, %v, want 4 although the caller that started the batch gave up", values[0], errs[0])
+	}
+	if !reflect.DeepEqual(recorder.batches, [][]int{{1, 2}}) {
+		t.Errorf("batches = %v, want [[1 2]]", recorder.batches)
+	}
+}
+
+func TestLoader_LoadTimeoutNotCached(t *testing.T) {
+	recorder := &recorder{err: context.DeadlineExceeded}
+	loader := NewLoader(recorder.fetch, time.Millisecond, 0)
+
+	if _, err := loader.Load(context.Background(), 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Load() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	recorder.mu.Lock()
+	recorder.err = nil
+	recorder.mu.Unlock()
+	if value, err := loader.Load(context.Background(), 1); err != nil || value != 2 {
+		t.Errorf("Load() after a timeout = %d, %v, want the key to be fetched again", value, err)
+	}
+
+	other := errors.New("broken")
+	recorder.err = other
+	if _, err := loader.Load(context.Background(), 2); !errors.Is(err, other) {
+		t.Fatalf("Load() error = %v, want %v", err, other)
+	}
+	recorder.err = nil
+	if _, err := loader.Load(context.Background(), 2); !errors.Is(err, other) {
+		t.Errorf("Load() after an error = %v, want it to be memoized", err)
+	}
+}
+
+func TestLoader_LoadPanic(t *testing.T) {
+	loader := NewLoader(func(ctx context.Context, keys []int) ([]int, []error) {
+		panic("boom")
+	}, time.Millisecond, 0)
+
+	_, errs := loadAll(context.Background(), loader, 1, 2)
+	for _, err := range errs {
+		if err == nil {
+			t.Error("Load() error = nil, want the panic as an error")
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package loaders
+
+import (
+	"context"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+type contextKey struct{}
+
+const (
+	batchWait    = 2 * time.Millisecond
+	maxBatchSize = 100
+)
+
+// Loaders holds every request scoped loader, a fresh instance must be attached to each request
+type Loaders struct {
+	EventByID       *Loader[string, *model.Event]
+	IsHost          *Loader[Host, bool]
+	FeedbackSummary *Loader[string, *model.FeedbackSummary]
+}
+
+// Host is the key of IsHost, whether UserID hosts the event of EventID
+type Host struct {
+	EventID string
+	UserID  string
+}
+
+func NewLoaders(repo repository.Repository) *Loaders {
+	return &Loaders{
+		EventByID:       NewLoader(eventsByIDs(repo), batchWait, maxBatchSize),
+		IsHost:          NewLoader(isHost(repo), batchWait, maxBatchSize),
+		FeedbackSummary: NewLoader(feedbackSummaries(repo), batchWait, maxBatchSize),
+	}
+}
+
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, contextKey{}, loaders)
+}
+
+// For returns the loaders attached to ctx, or nil when the request was not wrapped by WithLoaders
+func For(ctx context.Context) *Loaders {
+	loaders, _ := ctx.Value(contextKey{}).(*Loaders)
+	return loaders
+}
+
+// eventsByIDs fetches all the keys with one query and puts them back in key order,
+// any id that was not returned gets its own repository.EventNotFound
+func eventsByIDs(repo repository.Repository) BatchFunc[string, *model.Event] {
+	return func(ctx context.Context, ids []string) ([]*model.Event, []error) {
+		events := make([]*model.Event, len(ids))
+		errs := make([]error, len(ids))
+
+		found, err := repo.GetEventsByIDs(ctx, ids)
+		if err != nil {
+			for i := range errs {
+				errs[i] = err
+			}
+			return events, errs
+		}
+
+		byID := make(map[string]*model.Event, len(found))
+		for _, event := range found {
+			byID[event.ID] = event
+		}
+		for i, id := range ids {
+			event, ok := byID[id]
+			if !ok {
+				errs[i] = repository.EventNotFound
+				continue
+			}
+			events[i] = event
+		}
+		return events, errs
+	}
+}
+
+// isHost fetches the hosted events of every user in the batch with one query each, within a request that is the caller
+func isHost(repo repository.Repository) BatchFunc[Host, bool] {
+	return func(ctx context.Context, keys []Host) ([]bool, []error) {
+		hosts := make([]bool, len(keys))
+		errs := make([]error, len(keys))
+
+		eventIDs := map[string][]string{}
+		for _, key := range keys {
+			eventIDs[key.UserID] = append(eventIDs[key.UserID], key.EventID)
+		}
+		hosted := map[Host]bool{}
+		failed := map[string]error{}
+		for userID, ids := range eventIDs {
+			found, err := repo.HostedEvents(ctx, userID, ids)
+			if err != nil {
+				failed[userID] = err
+				continue
+			}
+			for _, id := range found {
+				hosted[Host{EventID: id, UserID: userID}] = true
+			}
+		}
+		for i, key := range keys {
+			hosts[i], errs[i] = hosted[key], failed[key.UserID]
+		}
+		return hosts, errs
+	}
+}
+
+// feedbackSummaries fetches the summaries of all the keys with one query
+func feedbackSummaries(repo repository.Repository) BatchFunc[string, *model.FeedbackSummary] {
+	return func(ctx context.Context, ids []string) ([]*model.FeedbackSummary, []error) {
+		summaries := make([]*model.FeedbackSummary, len(ids))
+		errs := make([]error, len(ids))
+
+		found, err := repo.GetFeedbackSummaries(ctx, ids)
+		for i, id := range ids {
+			summaries[i], errs[i] = found[id], err
+		}
+		return summaries, errs
+	}
+}
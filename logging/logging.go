@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KnightHacks/knighthacks_events/identity"
+)
+
+// ParseLevel accepts debug, info, warn or error in any case
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return l, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", level)
+	}
+	return l, nil
+}
+
+// Setup makes JSON written to w the default for both slog and the standard log package, so libraries that still
+// use log.Printf end up in the same stream
+func Setup(w io.Writer, level slog.Level) *slog.Logger {
+	logger := slog.New(NewHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	return logger
+}
+
+// Handler
+// Adds the request id, the caller's user id and the GraphQL operation name from the context to every record
+type Handler struct {
+	slog.Handler
+}
+
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{Handler: next}
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		if requestID, ok := RequestIDFromContext(ctx); ok {
+			record.AddAttrs(slog.String("request_id", requestID))
+		}
+		if claims, ok := identity.FromContext(ctx); ok {
+			record.AddAttrs(slog.String("user_id", claims.UserID))
+		}
+		if graphql.HasOperationContext(ctx) {
+			record.AddAttrs(slog.String("operation", operationName(graphql.GetOperationContext(ctx))))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewHandler(h.Handler.WithAttrs(attrs))
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return NewHandler(h.Handler.WithGroup(name))
+}
+
+func operationName(rc *graphql.OperationContext) string {
+	if rc.Operation != nil && rc.Operation.Name != "" {
+		return rc.Operation.Name
+	}
+	if rc.OperationName != "" {
+		return rc.OperationName
+	}
+	return "anonymous"
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader is accepted from the gateway when it already assigned an id and always echoed back
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength stops clients from stuffing arbitrary amounts of data into every log entry
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID attaches a request id, every entry logged with the returned context carries it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok
+}
+
+// Middleware assigns the request id and writes one access log entry per request once it completes
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		// the request has been replaced by the auth middleware by now, so its context knows who the caller was
+		slog.Log(c.Request.Context(), level, "request completed",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
+
+// Recovery replaces gin.Recovery so panics outside of GraphQL are logged as one entry with their stack
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		LogPanic(c.Request.Context(), recovered)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// LogPanic logs a recovered value along with the stack of the goroutine that panicked, it has to be called
+// from the deferred function that recovered
+func LogPanic(ctx context.Context, recovered any) {
+	slog.ErrorContext(ctx, "recovered from panic", "panic", recovered, "stack", string(debug.Stack()))
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	// crypto/rand never fails on the platforms we deploy to
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryTracer
+// Logs every query at debug level and failed queries at warn level, the request id reaches the repository
+// through the context its queries are run with
+type QueryTracer struct{}
+
+var _ pgx.QueryTracer = QueryTracer{}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql  string
+	time time.Time
+}
+
+func (t QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, time: time.Now()})
+}
+
+func (t QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	// arguments are left out on purpose, they contain user data
+	attrs := []any{"sql", start.sql, "duration", time.Since(start.time)}
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		slog.WarnContext(ctx, "query failed", append(attrs, "error", data.Err)...)
+		return
+	}
+	slog.DebugContext(ctx, "query", append(attrs, "rows_affected", data.CommandTag.RowsAffected())...)
+}
+
+// QueryTracers
+// Lets more than one pgx.QueryTracer see every query since pgx only accepts one
+type QueryTracers []pgx.QueryTracer
+
+func (t QueryTracers) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	for _, tracer := range t {
+		ctx = tracer.TraceQueryStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+func (t QueryTracers) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	for i := len(t) - 1; i >= 0; i-- {
+		t[i].TraceQueryEnd(ctx, conn, data)
+	}
+}
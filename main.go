@@ -2,83 +2,352 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
-	"runtime/debug"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/KnightHacks/knighthacks_events/config"
+	"github.com/KnightHacks/knighthacks_events/constraint"
+	"github.com/KnightHacks/knighthacks_events/cors"
 	"github.com/KnightHacks/knighthacks_events/graph"
-	"github.com/KnightHacks/knighthacks_events/graph/generated"
+	"github.com/KnightHacks/knighthacks_events/health"
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/KnightHacks/knighthacks_events/limits"
+	"github.com/KnightHacks/knighthacks_events/loaders"
+	"github.com/KnightHacks/knighthacks_events/logging"
+	"github.com/KnightHacks/knighthacks_events/metrics"
+	"github.com/KnightHacks/knighthacks_events/migrations"
+	"github.com/KnightHacks/knighthacks_events/outbox"
+	"github.com/KnightHacks/knighthacks_events/persisted"
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+	"github.com/KnightHacks/knighthacks_events/reminders"
 	"github.com/KnightHacks/knighthacks_events/repository"
+	"github.com/KnightHacks/knighthacks_events/rest"
+	"github.com/KnightHacks/knighthacks_events/tracing"
+	"github.com/KnightHacks/knighthacks_events/webhooks"
 	"github.com/KnightHacks/knighthacks_shared/auth"
-	"github.com/KnightHacks/knighthacks_shared/database"
-	"github.com/KnightHacks/knighthacks_shared/pagination"
 	"github.com/KnightHacks/knighthacks_shared/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
-const defaultPort = "8080"
+// rateLimitBucketRetention has to be longer than the slowest bucket takes to refill
+const rateLimitBucketRetention = 24 * time.Hour
 
 func main() {
-	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Llongfile)
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	logging.Setup(os.Stderr, slog.LevelInfo)
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		fatal("Invalid configuration", err)
 	}
+	logLevel, _ := logging.ParseLevel(cfg.Logging.Level)
+	logging.Setup(os.Stderr, logLevel)
+	slog.Info("Loaded configuration", "config", cfg)
 
-	pool, err := database.ConnectWithRetries(utils.GetEnvOrDie("DATABASE_URI"))
+	pool, err := connectDatabase(cfg.Database)
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v\n", err)
+		fatal("Unable to connect to database", err)
+	}
+
+	if len(args) > 0 {
+		if args[0] != "migrate" {
+			fatal("Unknown command", fmt.Errorf("%q, the only command is migrate", args[0]))
+		}
+		err = runMigrate(context.Background(), pool, args[1:], os.Stdout)
+		pool.Close()
+		if err != nil {
+			fatal("Unable to migrate", err)
+		}
+		return
+	}
+	if cfg.Database.AutoMigrate {
+		migrator, err := migrations.NewMigrator(pool)
+		if err != nil {
+			fatal("Unable to load migrations", err)
+		}
+		if _, err = migrator.Up(context.Background()); err != nil {
+			fatal("Unable to migrate", err)
+		}
+	}
+
+	// background workers stop when workersCtx is cancelled, which only happens once the server has stopped serving
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.Endpoint)
+	if err != nil {
+		fatal("Unable to set up tracing", err)
 	}
 
 	newAuth, err := auth.NewAuthWithEnvironment()
 	if err != nil {
-		log.Fatalf("An error occured when trying to create an instance of Auth: %s\n", err)
+		fatal("An error occured when trying to create an instance of Auth", err)
 	}
 
-	ginRouter := gin.Default()
+	if cfg.Metrics.Enabled {
+		metrics.RegisterDatabase(pool)
+	}
+
+	var repo repository.Repository = metrics.NewRepository(repository.NewDatabaseRepository(pool))
+	if cfg.Cache.Enabled && cfg.Cache.TTL > 0 && cfg.Cache.Size > 0 {
+		cachedRepository := repository.NewCachedRepository(repo, pool, cfg.Cache.TTL, cfg.Cache.Size)
+		startWorker(workersCtx, &workers, cachedRepository.Listen)
+		repo = cachedRepository
+	}
+
+	var allowList map[string]string
+	if manifestPath := cfg.GraphQL.PersistedQueryAllowList; manifestPath != "" {
+		allowList, err = persisted.LoadManifest(manifestPath)
+		if err != nil {
+			fatal("Unable to load persisted query allow list", err)
+		}
+		slog.Info("Only accepting persisted operations from the allow list", "operations", len(allowList), "path", manifestPath)
+	}
+
+	// attempts at rotating check-in codes are always shared, with a store per replica users could guess once per replica
+	attemptStore := ratelimit.NewPostgresStore(pool)
+	startWorker(workersCtx, &workers, func(ctx context.Context) {
+		deleteIdleRateLimitBuckets(ctx, attemptStore)
+	})
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == "postgres" {
+		rateLimitStore = attemptStore
+	}
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		// the rules were already checked by config.Validate
+		rateLimitRules, _ := ratelimit.ParseRules(cfg.RateLimit.Rules)
+		limiter = ratelimit.NewLimiter(rateLimitStore, rateLimitRules)
+	}
+
+	checker := health.NewChecker(pool)
+
+	ginRouter := gin.New()
+	// validated by config.Validate, without it gin believes every X-Forwarded-For
+	if err = ginRouter.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal("Unable to set trusted proxies", err)
+	}
+	ginRouter.Use(logging.Middleware(), logging.Recovery())
+	if len(cfg.Server.CORS.AllowedOrigins) > 0 {
+		ginRouter.Use(cors.Middleware(cfg.Server.CORS.AllowedOrigins, cfg.Server.CORS.MaxAge))
+	}
+	// probes are registered before the middleware so they never need a token and are never rate limited
+	ginRouter.GET("/healthz", checker.Liveness)
+	ginRouter.GET("/readyz", checker.Readiness)
+	ginRouter.Use(tracing.Middleware())
 	ginRouter.Use(auth.AuthContextMiddleware(newAuth))
 	ginRouter.Use(utils.GinContextMiddleware())
+	if limiter != nil {
+		ginRouter.Use(limiter.Middleware())
+	}
 
-	ginRouter.POST("/query", graphqlHandler(newAuth, pool))
-	ginRouter.GET("/", playgroundHandler())
+	// only required while webhooks are enabled, without it webhooks can't be registered from this replica
+	var webhookSecrets *webhooks.SecretBox
+	if cfg.Webhooks.SecretKey != "" {
+		if webhookSecrets, err = webhooks.NewSecretBox(cfg.Webhooks.SecretKey); err != nil {
+			fatal("Unable to set up webhook secret encryption", err)
+		}
+	}
+	webhookStore := webhooks.NewPostgresStore(pool, webhookSecrets)
+	if cfg.Webhooks.Enabled {
+		dispatcher := webhooks.NewDispatcher(webhookStore, webhooks.NewClient(cfg.Webhooks.Timeout), webhooks.RetryPolicy{
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			BaseDelay:   cfg.Webhooks.BackoffBase,
+			MaxDelay:    cfg.Webhooks.BackoffMax,
+		})
+		startWorker(workersCtx, &workers, func(ctx context.Context) {
+			dispatcher.Run(ctx, cfg.Webhooks.PollInterval)
+		})
+	}
 
-	log.Fatal(ginRouter.Run(":" + port))
-}
+	relay := outbox.NewRelay(outbox.NewPostgresStore(pool), outbox.NewNotifyPublisher(pool), webhooks.NewPublisher(webhookStore))
+	relay.BatchSize = cfg.Outbox.BatchSize
+	relay.MaxAttempts = cfg.Outbox.MaxAttempts
+	relay.Retention = cfg.Outbox.Retention
+	startWorker(workersCtx, &workers, func(ctx context.Context) {
+		relay.Run(ctx, cfg.Outbox.PollInterval)
+	})
+
+	if cfg.Reminders.Enabled {
+		scheduler := reminders.NewScheduler(reminders.NewPostgresStore(pool), newNotifier(cfg.Reminders), cfg.Reminders.Window)
+		startWorker(workersCtx, &workers, func(ctx context.Context) {
+			scheduler.Run(ctx, cfg.Reminders.PollInterval)
+		})
+	}
 
-func graphqlHandler(a *auth.Auth, pool *pgxpool.Pool) gin.HandlerFunc {
 	// TODO: Sponsor doesn't have a sense of ownership, maybe we should have sponsor linked users?
+	signer := newSigner(cfg.CheckIn)
+	hasRole := identity.HasRole(auth.HasRoleDirective{GetUserId: auth.DefaultGetUserId})
+	// checked by config.Validate as well
+	codeAttempts, _ := ratelimit.ParseLimit(cfg.CheckIn.CodeAttempts)
+	resolver := &graph.Resolver{
+		Repository:      repo,
+		Webhooks:        webhookStore,
+		Auth:            newAuth,
+		CheckIn:         signer,
+		CheckInAttempts: checkin.NewAttempts(attemptStore, codeAttempts),
+		FeedbackWindow:  cfg.Feedback.Window,
+	}
+	schema := graph.NewExecutableSchema(resolver, hasRole)
+
+	restHandler, err := rest.NewHandler(resolver, hasRole, schema.Schema())
+	if err != nil {
+		fatal("Unable to generate the OpenAPI document", err)
+	}
+	restHandler.Register(ginRouter, limiter)
+
+	queryHandler := graphqlHandler(schema, repo, cfg.GraphQL, allowList, limiter)
+	ginRouter.GET("/query", queryHandler)
+	ginRouter.POST("/query", queryHandler)
+	if cfg.Server.Playground {
+		ginRouter.GET("/", playgroundHandler())
+	}
+
+	server := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           ginRouter,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+	}
+	var metricsServer *http.Server
+	if cfg.Metrics.Enabled {
+		metricsServer = metrics.NewServer(":"+strconv.Itoa(cfg.Metrics.Port), cfg.Server.ReadHeaderTimeout)
+		go func() {
+			slog.Info("Serving metrics", "addr", metricsServer.Addr)
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("Metrics server stopped", "error", err)
+			}
+		}()
+	}
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		// a second signal kills the process straight away instead of waiting for the drain
+		<-signalCtx.Done()
+		stopSignals()
+	}()
+	serveErr := serve(signalCtx, server, checker, cfg.Server.DrainPeriod, cfg.Server.ShutdownTimeout)
+	if serveErr != nil {
+		slog.Error("HTTP server stopped", "error", serveErr)
+	}
 
-	hasRoleDirective := auth.HasRoleDirective{GetUserId: auth.DefaultGetUserId}
+	// everything below is bounded by a fresh timeout, the server may have used up its own
+	closeCtx, cancelClose := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelClose()
 
-	config := generated.Config{
-		Resolvers: &graph.Resolver{
-			Repository: repository.NewDatabaseRepository(pool),
-			Auth:       a,
-		},
-		Directives: generated.DirectiveRoot{
-			HasRole:    hasRoleDirective.Direct,
-			Pagination: pagination.Pagination,
-		},
+	stopWorkers()
+	if !waitWithContext(closeCtx, &workers) {
+		slog.Warn("Background workers did not stop before the shutdown timeout")
+	}
+	// kept up until the workers are done so their last metrics can still be scraped
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+	pool.Close()
+	if err := shutdownTracing(closeCtx); err != nil {
+		slog.Error("Unable to flush spans", "error", err)
 	}
-	srv := handler.NewDefaultServer(generated.NewExecutableSchema(config))
+	slog.Info("Shut down")
+	if serveErr != nil {
+		os.Exit(1)
+	}
+}
+
+// serve runs server until ctx is cancelled by a signal. Readiness then fails for drainPeriod so the orchestrator stops
+// routing new traffic here while requests keep being served, after which in-flight requests get shutdownTimeout to finish
+func serve(ctx context.Context, server *http.Server, checker *health.Checker, drainPeriod, shutdownTimeout time.Duration) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Draining", "drain_period", drainPeriod)
+	checker.SetDraining()
+	select {
+	case err := <-serverErr:
+		return err
+	case <-time.After(drainPeriod):
+	}
+
+	slog.Info("Shutting down", "timeout", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// requests that are still running are cut off
+		server.Close()
+		return err
+	}
+	return nil
+}
+
+// graphqlHandler serves queries over GET and POST, GET only accepts queries so responses fetched by APQ hash can be cached
+// allowList is the only source of persisted queries when it isn't nil, everything else is rejected.
+// limiter may be nil when rate limiting is disabled.
+func graphqlHandler(schema graphql.ExecutableSchema, repo repository.Repository, options config.GraphQL, allowList map[string]string, limiter *ratelimit.Limiter) gin.HandlerFunc {
+	srv := handler.New(schema)
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New(options.QueryCacheSize))
+	if options.Introspection {
+		srv.Use(extension.Introspection{})
+	}
+
+	if allowList != nil {
+		srv.Use(persisted.AllowList{Operations: allowList})
+		srv.Use(extension.AutomaticPersistedQuery{Cache: persisted.ReadOnlyCache(allowList)})
+	} else {
+		srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New(options.APQCacheSize)})
+	}
+	srv.Use(persisted.CacheControl{MaxAge: options.PersistedQueryMaxAge, Public: allowList})
+	srv.Use(extension.FixedComplexityLimit(options.ComplexityLimit))
+	srv.Use(limits.DepthLimit{Max: options.DepthLimit})
+	if limiter != nil {
+		srv.Use(ratelimit.Extension{Limiter: limiter})
+	}
+	srv.Use(apperrors.Extension{})
+	srv.Use(constraint.Extension{})
+	srv.Use(metrics.Extension{})
+	srv.Use(tracing.Extension{})
+
 	srv.SetRecoverFunc(func(ctx context.Context, iErr interface{}) error {
-		err := fmt.Errorf("%v", iErr)
-		log.Println(fmt.Sprintf("runtime error: %v\n", err))
-		debug.PrintStack()
-		return gqlerror.Errorf("Internal server error! Check logs for more details!")
+		logging.LogPanic(ctx, iErr)
+		return apperrors.Wrap(apperrors.CodeInternal, fmt.Errorf("panic: %v", iErr))
 	})
 	srv.SetErrorPresenter(func(ctx context.Context, err error) *gqlerror.Error {
-		log.Println("Error presented: ", err)
-		return graphql.DefaultErrorPresenter(ctx, err)
+		presented := apperrors.Present(ctx, err)
+		metrics.ObserveError(presented)
+		return presented
 	})
 	return func(c *gin.Context) {
-		srv.ServeHTTP(c.Writer, c.Request)
+		// loaders only live as long as the request so results are never shared between users
+		ctx := loaders.WithLoaders(c.Request.Context(), loaders.NewLoaders(repo))
+		if c.Request.Method == http.MethodGet {
+			ctx = persisted.WithCacheableResponse(ctx, c.Writer.Header(), c.GetHeader("Authorization") == "")
+		}
+		srv.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
 	}
 }
 
@@ -89,3 +358,102 @@ func playgroundHandler() gin.HandlerFunc {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
 }
+
+// connectDatabase opens the pool with query tracing and logging, retrying while the database is still starting up
+func connectDatabase(cfg config.Database) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URI)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.ConnConfig.Tracer = logging.QueryTracers{tracing.QueryTracer{}, logging.QueryTracer{}}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxConns)
+	}
+	poolConfig.MinConns = int32(cfg.MinConns)
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+
+	for attempt := 1; ; attempt++ {
+		pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+		if err == nil {
+			if err = pool.Ping(context.Background()); err == nil {
+				return pool, nil
+			}
+			pool.Close()
+		}
+		if attempt >= cfg.ConnectAttempts {
+			return nil, err
+		}
+		slog.Warn("Unable to connect to database", "attempt", attempt, "attempts", cfg.ConnectAttempts, "error", err)
+		time.Sleep(cfg.ConnectBackoff)
+	}
+}
+
+// fatal logs err and exits, log.Fatal would bypass the structured logger
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func deleteIdleRateLimitBuckets(ctx context.Context, store *ratelimit.PostgresStore) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := store.DeleteIdle(ctx, time.Now().Add(-rateLimitBucketRetention)); err != nil && ctx.Err() == nil {
+			slog.Error("Unable to delete idle rate limit buckets", "error", err)
+		}
+	}
+}
+
+// newNotifier builds the notifier options names, config.Validate already checked it has what it needs
+func newNotifier(options config.Reminders) reminders.Notifier {
+	switch options.Notifier {
+	case "smtp":
+		return reminders.NewSMTPNotifier(options.SMTP.Addr, options.SMTP.Username, options.SMTP.Password, options.SMTP.From)
+	case "webhook":
+		return reminders.NewWebhookNotifier(options.Webhook.URL, options.Webhook.Secret, &http.Client{Timeout: options.Webhook.Timeout})
+	default:
+		return reminders.LogNotifier{}
+	}
+}
+
+// newSigner builds the check-in token signer, config.Validate already checked the secret is set
+func newSigner(options config.CheckIn) *checkin.Signer {
+	signer := checkin.NewSigner(options.Secret, options.TokenTTL, options.OpensBefore)
+	signer.CodeStep = options.CodeStep
+	signer.DisplayTTL = options.DisplayTTL
+	return signer
+}
+
+// startWorker runs work in the background, wg lets shutdown wait for it to return after ctx is cancelled
+func startWorker(ctx context.Context, wg *sync.WaitGroup, work func(ctx context.Context)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		work(ctx)
+	}()
+}
+
+// waitWithContext waits for wg, it returns false when ctx expires first
+func waitWithContext(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const businessQueryTimeout = 2 * time.Second
+
+// businessCollector answers scrapes with a couple of cheap aggregate queries,
+// a failing query is logged and the metric is left out of that scrape
+type businessCollector struct {
+	pool *pgxpool.Pool
+
+	checkInsPerMinute *prometheus.Desc
+	events            *prometheus.Desc
+}
+
+func newBusinessCollector(pool *pgxpool.Pool) *businessCollector {
+	return &businessCollector{
+		pool:              pool,
+		checkInsPerMinute: prometheus.NewDesc("events_check_ins_per_minute", "Event check-ins recorded during the last minute", nil, nil),
+		events:            prometheus.NewDesc("events_events", "Events that currently exist", nil, nil),
+	}
+}
+
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), businessQueryTimeout)
+	defer cancel()
+
+	c.collectCount(ctx, ch, c.checkInsPerMinute, "SELECT COUNT(*) FROM event_attendance WHERE time > now() - interval '1 minute'")
+	c.collectCount(ctx, ch, c.events, "SELECT COUNT(*) FROM events")
+}
+
+func (c *businessCollector) collectCount(ctx context.Context, ch chan<- prometheus.Metric, desc *prometheus.Desc, sql string) {
+	var count int
+	if err := c.pool.QueryRow(ctx, sql).Scan(&count); err != nil {
+		slog.WarnContext(ctx, "unable to collect business metric", "metric", desc.String(), "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(count))
+}
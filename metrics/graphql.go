@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+var (
+	operationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "events",
+		Subsystem: "graphql",
+		Name:      "operations_total",
+		Help:      "GraphQL operations executed, by root field and operation type",
+	}, []string{"operation", "type"})
+	operationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "events",
+		Subsystem: "graphql",
+		Name:      "operation_duration_seconds",
+		Help:      "Time from receiving a GraphQL operation to having its response ready",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "type"})
+	fieldDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "events",
+		Subsystem: "graphql",
+		Name:      "field_duration_seconds",
+		Help:      "Time spent in GraphQL field resolvers, fields that are plain struct reads are not measured",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"object", "field"})
+	errorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "events",
+		Subsystem: "graphql",
+		Name:      "errors_total",
+		Help:      "GraphQL errors returned to clients, by error code",
+	}, []string{"code"})
+)
+
+// Extension
+// Records operation counts and latencies along with the latency of every resolver
+type Extension struct{}
+
+var _ interface {
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+	graphql.HandlerExtension
+} = Extension{}
+
+func (e Extension) ExtensionName() string {
+	return "Metrics"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	response := next(ctx)
+
+	rc := graphql.GetOperationContext(ctx)
+	operationName, operationType := operationLabels(rc)
+	operationsTotal.WithLabelValues(operationName, operationType).Inc()
+	operationDuration.WithLabelValues(operationName, operationType).Observe(time.Since(rc.Stats.OperationStart).Seconds())
+	return response
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !(fc.IsResolver || fc.IsMethod) {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	fieldDuration.WithLabelValues(fc.Object, fc.Field.Name).Observe(time.Since(start).Seconds())
+	return res, err
+}
+
+// ObserveError counts an error on its way out of the error presenter, errors without a code are counted as UNKNOWN
+func ObserveError(err *gqlerror.Error) {
+	code, ok := err.Extensions["code"].(string)
+	if !ok {
+		code = "UNKNOWN"
+	}
+	errorsTotal.WithLabelValues(code).Inc()
+}
+
+var rootTypes = map[ast.Operation]string{ast.Query: "Query", ast.Mutation: "Mutation", ast.Subscription: "Subscription"}
+
+// operationLabels labels operations by the root field they select instead of the name clients give them, so the
+// number of series is bounded by the schema. Operations selecting several root fields are labelled "multiple" and
+// the ones that never got parsed "other"
+func operationLabels(rc *graphql.OperationContext) (string, string) {
+	if rc.Operation == nil {
+		return "other", "unknown"
+	}
+
+	operation := "other"
+	rootType := []string{rootTypes[rc.Operation.Operation]}
+	for _, field := range graphql.CollectFields(rc, rc.Operation.SelectionSet, rootType) {
+		switch {
+		case field.Name == "__typename":
+		case operation == "other" || operation == field.Name:
+			operation = field.Name
+		default:
+			operation = "multiple"
+		}
+	}
+	return operation, string(rc.Operation.Operation)
+}
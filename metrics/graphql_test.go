@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestOperationLabels(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Query { events: [String] event: String }
+		type Mutation { createEvent: String }
+	`})
+
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		typ       string
+	}{
+		{name: "named by the client", query: `query MyVeryOwnName { events }`, operation: "events", typ: "query"},
+		{name: "alias", query: `{ renamed: event }`, operation: "event", typ: "query"},
+		{name: "same field twice", query: `{ a: event b: event __typename }`, operation: "event", typ: "query"},
+		{name: "several fields", query: `{ event events }`, operation: "multiple", typ: "query"},
+		{name: "fragment", query: `{ ... on Query { events } }`, operation: "events", typ: "query"},
+		{name: "mutation", query: `mutation { createEvent }`, operation: "createEvent", typ: "mutation"},
+		{name: "only __typename", query: `{ __typename }`, operation: "other", typ: "query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(schema, tt.query)
+			if errs != nil {
+				t.Fatalf("LoadQuery() error = %v", errs)
+			}
+			rc := &graphql.OperationContext{Doc: doc, Operation: doc.Operations[0]}
+
+			operation, typ := operationLabels(rc)
+			if operation != tt.operation || typ != tt.typ {
+				t.Errorf("operationLabels() = %q, %q, want %q, %q", operation, typ, tt.operation, tt.typ)
+			}
+		})
+	}
+
+	if operation, typ := operationLabels(&graphql.OperationContext{OperationName: "unparsed"}); operation != "other" || typ != "unknown" {
+		t.Errorf("operationLabels() = %q, %q without an operation, want \"other\", \"unknown\"", operation, typ)
+	}
+}
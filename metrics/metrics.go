@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RegisterDatabase adds the pool statistics and the business gauges that are computed from the database
+func RegisterDatabase(pool *pgxpool.Pool) {
+	prometheus.MustRegister(newPoolCollector(pool), newBusinessCollector(pool))
+}
+
+// NewServer serves everything registered with the default prometheus registry at /metrics, it is meant to listen on
+// an internal port that only the scraper can reach
+func NewServer(addr string, readHeaderTimeout time.Duration) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var repositoryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "events",
+	Subsystem: "repository",
+	Name:      "call_duration_seconds",
+	Help:      "Time spent in Repository methods, by method and whether it returned an error",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "error"})
+
+// Repository
+// Decorates a repository.Repository with a timing for every call
+type Repository struct {
+	Next repository.Repository
+}
+
+func NewRepository(next repository.Repository) *Repository {
+	return &Repository{Next: next}
+}
+
+// observe takes err by reference so it sees the value the deferring method returned
+func observe(method string, start time.Time, err *error) {
+	failed := "false"
+	if *err != nil {
+		failed = "true"
+	}
+	repositoryDuration.WithLabelValues(method, failed).Observe(time.Since(start).Seconds())
+}
+
+func (r *Repository) CreateEvent(ctx context.Context, input *model.NewEvent) (event *model.Event, err error) {
+	defer observe("CreateEvent", time.Now(), &err)
+	return r.Next.CreateEvent(ctx, input)
+}
+
+func (r *Repository) UpdateEvent(ctx context.Context, id string, input *model.UpdatedEvent) (event *model.Event, err error) {
+	defer observe("UpdateEvent", time.Now(), &err)
+	return r.Next.UpdateEvent(ctx, id, input)
+}
+
+func (r *Repository) DeleteEvent(ctx context.Context, id string) (deleted bool, err error) {
+	defer observe("DeleteEvent", time.Now(), &err)
+	return r.Next.DeleteEvent(ctx, id)
+}
+
+func (r *Repository) GetEvent(ctx context.Context, id string) (event *model.Event, err error) {
+	defer observe("GetEvent", time.Now(), &err)
+	return r.Next.GetEvent(ctx, id)
+}
+
+func (r *Repository) GetEventsByIDs(ctx context.Context, ids []string) (events []*model.Event, err error) {
+	defer observe("GetEventsByIDs", time.Now(), &err)
+	return r.Next.GetEventsByIDs(ctx, ids)
+}
+
+func (r *Repository) GetEvents(ctx context.Context, first int, after string) (events []*model.Event, total int, err error) {
+	defer observe("GetEvents", time.Now(), &err)
+	return r.Next.GetEvents(ctx, first, after)
+}
+
+func (r *Repository) SetRSVP(ctx context.Context, eventID string, userID string, going bool) (err error) {
+	defer observe("SetRSVP", time.Now(), &err)
+	return r.Next.SetRSVP(ctx, eventID, userID, going)
+}
+
+func (r *Repository) SetBookmark(ctx context.Context, eventID string, userID string, saved bool) (err error) {
+	defer observe("SetBookmark", time.Now(), &err)
+	return r.Next.SetBookmark(ctx, eventID, userID, saved)
+}
+
+func (r *Repository) CheckIn(ctx context.Context, eventID string, userID string, at time.Time) (attendance *model.Attendance, err error) {
+	defer observe("CheckIn", time.Now(), &err)
+	return r.Next.CheckIn(ctx, eventID, userID, at)
+}
+
+func (r *Repository) EventAttendance(ctx context.Context, eventID string, bucket time.Duration) (stats *model.EventAttendanceStats, err error) {
+	defer observe("EventAttendance", time.Now(), &err)
+	return r.Next.EventAttendance(ctx, eventID, bucket)
+}
+
+func (r *Repository) HackathonAttendance(ctx context.Context, hackathonID string, bucket time.Duration) (stats *model.HackathonAttendanceStats, err error) {
+	defer observe("HackathonAttendance", time.Now(), &err)
+	return r.Next.HackathonAttendance(ctx, hackathonID, bucket)
+}
+
+func (r *Repository) AdjustPoints(ctx context.Context, input *model.PointsAdjustment, adjustedBy string) (award *model.PointsAward, err error) {
+	defer observe("AdjustPoints", time.Now(), &err)
+	return r.Next.AdjustPoints(ctx, input, adjustedBy)
+}
+
+func (r *Repository) GetLeaderboard(ctx context.Context, hackathonID string, first int, after int) (entries []*model.LeaderboardEntry, total int, err error) {
+	defer observe("GetLeaderboard", time.Now(), &err)
+	return r.Next.GetLeaderboard(ctx, hackathonID, first, after)
+}
+
+func (r *Repository) GetPointsAwards(ctx context.Context, hackathonID string, userID string) (awards []*model.PointsAward, err error) {
+	defer observe("GetPointsAwards", time.Now(), &err)
+	return r.Next.GetPointsAwards(ctx, hackathonID, userID)
+}
+
+func (r *Repository) SetHost(ctx context.Context, eventID string, userID string, host bool) (err error) {
+	defer observe("SetHost", time.Now(), &err)
+	return r.Next.SetHost(ctx, eventID, userID, host)
+}
+
+func (r *Repository) HostedEvents(ctx context.Context, userID string, eventIDs []string) (hosted []string, err error) {
+	defer observe("HostedEvents", time.Now(), &err)
+	return r.Next.HostedEvents(ctx, userID, eventIDs)
+}
+
+func (r *Repository) SubmitFeedback(ctx context.Context, eventID string, userID string, rating int, comment string) (response *model.FeedbackResponse, err error) {
+	defer observe("SubmitFeedback", time.Now(), &err)
+	return r.Next.SubmitFeedback(ctx, eventID, userID, rating, comment)
+}
+
+func (r *Repository) GetFeedbackSummaries(ctx context.Context, eventIDs []string) (summaries map[string]*model.FeedbackSummary, err error) {
+	defer observe("GetFeedbackSummaries", time.Now(), &err)
+	return r.Next.GetFeedbackSummaries(ctx, eventIDs)
+}
@@ -0,0 +1,232 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// files holds NNNN_name.up.sql and NNNN_name.down.sql pairs, versions are applied in ascending order
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock every replica takes before migrating, it spells "khevents"
+const lockKey int64 = 0x6b68_6576_656e_7473
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration
+// One schema change and the statements that revert it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status of a migration, AppliedAt is nil while it is pending
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load reads the embedded migrations, every version needs both an up and a down file
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		contents, err := fs.ReadFile(files, path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator
+// Applies and reverts migrations, recording them in schema_migrations. Every operation holds an advisory lock
+// so replicas starting at the same time never migrate concurrently.
+type Migrator struct {
+	DatabasePool *pgxpool.Pool
+	Migrations   []Migration
+}
+
+func NewMigrator(databasePool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DatabasePool: databasePool, Migrations: migrations}, nil
+}
+
+// Up applies every pending migration, each one in its own transaction
+func (m *Migrator) Up(ctx context.Context) (applied []Migration, err error) {
+	err = m.withLock(ctx, func(conn *pgxpool.Conn, appliedAt map[int]time.Time) error {
+		for _, migration := range m.Migrations {
+			if _, ok := appliedAt[migration.Version]; ok {
+				continue
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("unable to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "Applied migration", "version", migration.Version, "name", migration.Name)
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest steps applied migrations, newest first
+func (m *Migrator) Down(ctx context.Context, steps int) (reverted []Migration, err error) {
+	if steps < 1 {
+		return nil, errors.New("steps must be at least 1")
+	}
+	err = m.withLock(ctx, func(conn *pgxpool.Conn, appliedAt map[int]time.Time) error {
+		for i := len(m.Migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.Migrations[i]
+			if _, ok := appliedAt[migration.Version]; !ok {
+				continue
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("unable to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "Reverted migration", "version", migration.Version, "name", migration.Name)
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration in order along with when it was applied
+func (m *Migrator) Status(ctx context.Context) (statuses []Status, err error) {
+	err = m.withLock(ctx, func(conn *pgxpool.Conn, appliedAt map[int]time.Time) error {
+		for _, migration := range m.Migrations {
+			status := Status{Migration: migration}
+			if t, ok := appliedAt[migration.Version]; ok {
+				status.AppliedAt = &t
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// withLock runs f on a single connection while holding the advisory lock, session level locks belong to a
+// connection so the same one has to be used for locking, migrating and unlocking
+func (m *Migrator) withLock(ctx context.Context, f func(conn *pgxpool.Conn, appliedAt map[int]time.Time) error) error {
+	conn, err := m.DatabasePool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return err
+	}
+	defer func() {
+		// ctx may already be cancelled, the lock still has to be released before the connection goes back to the pool
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			slog.Error("Unable to release the migration lock", "error", err)
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    integer                   not null
+        constraint schema_migrations_pk
+            primary key,
+    name       varchar                   not null,
+    applied_at timestamptz default now() not null
+)`)
+	if err != nil {
+		return err
+	}
+
+	appliedAt, err := m.appliedAt(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return f(conn, appliedAt)
+}
+
+func (m *Migrator) appliedAt(ctx context.Context, conn *pgxpool.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var t time.Time
+		if err = rows.Scan(&version, &t); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = t
+	}
+	return appliedAt, rows.Err()
+}
@@ -0,0 +1,8 @@
+-- events and event_attendance may have been adopted rather than created by 0001_create_events.up.sql, so reverting
+-- it refuses instead of dropping tables that hold data from before migrations existed
+do
+$$
+    begin
+        raise exception 'migration 0001_create_events can not be reverted, drop events and event_attendance by hand if they really have to go';
+    end
+$$;
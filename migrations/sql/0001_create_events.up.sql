@@ -0,0 +1,28 @@
+-- "if not exists" lets databases that were set up by hand before migrations existed adopt them
+create table if not exists events
+(
+    id           serial
+        constraint events_pk
+            primary key,
+    hackathon_id integer   not null
+        constraint events_hackathons_id_fk
+            references hackathons,
+    location     varchar   not null,
+    start_date   timestamp not null,
+    end_date     timestamp not null,
+    name         varchar   not null,
+    description  varchar   not null
+);
+
+create table if not exists event_attendance
+(
+    event_id integer                 not null
+        constraint event_attendance_events_id_fk
+            references events,
+    user_id  integer                 not null
+        constraint event_attendance_users_id_fk
+            references users,
+    time     timestamp default now() not null,
+    constraint event_attendance_pk
+        primary key (event_id, user_id)
+);
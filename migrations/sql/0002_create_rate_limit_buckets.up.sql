@@ -0,0 +1,8 @@
+create table if not exists rate_limit_buckets
+(
+    key        varchar          not null
+        constraint rate_limit_buckets_pk
+            primary key,
+    tokens     double precision not null,
+    updated_at timestamptz      not null
+);
@@ -0,0 +1,51 @@
+create table webhooks
+(
+    id         serial
+        constraint webhooks_pk
+            primary key,
+    url        varchar                   not null,
+    -- the key deliveries are signed with, it is only ever shown to the admin who registered the webhook
+    secret     varchar                   not null,
+    topics     varchar[]                 not null,
+    created_at timestamptz default now() not null
+);
+
+create table webhook_deliveries
+(
+    id               bigserial
+        constraint webhook_deliveries_pk
+            primary key,
+    webhook_id       integer                   not null
+        constraint webhook_deliveries_webhooks_id_fk
+            references webhooks
+            on delete cascade,
+    topic            varchar                   not null,
+    payload          jsonb                     not null,
+    status           varchar                   not null
+        constraint webhook_deliveries_status_check
+            check (status in ('pending', 'delivered', 'failed')),
+    attempts         integer     default 0     not null,
+    -- while pending this is when the delivery is due, a dispatcher that claims it pushes it back by its lease
+    next_attempt_at  timestamptz,
+    last_status_code integer,
+    last_error       varchar,
+    created_at       timestamptz default now() not null,
+    delivered_at     timestamptz
+);
+
+create index webhook_deliveries_due_idx on webhook_deliveries (next_attempt_at) where status = 'pending';
+create index webhook_deliveries_webhook_id_idx on webhook_deliveries (webhook_id, id);
+
+create table webhook_attempts
+(
+    delivery_id  bigint                    not null
+        constraint webhook_attempts_webhook_deliveries_id_fk
+            references webhook_deliveries
+            on delete cascade,
+    attempted_at timestamptz               not null,
+    status_code  integer,
+    error        varchar,
+    duration_ms  integer                   not null
+);
+
+create index webhook_attempts_delivery_id_idx on webhook_attempts (delivery_id, attempted_at);
@@ -0,0 +1,15 @@
+create table outbox
+(
+    id           bigserial
+        constraint outbox_pk
+            primary key,
+    -- the id of the event the message is about, messages about the same event are published in id order
+    aggregate_id varchar                   not null,
+    topic        varchar                   not null,
+    payload      jsonb                     not null,
+    created_at   timestamptz default now() not null,
+    published_at timestamptz
+);
+
+create index outbox_unpublished_idx on outbox (id) where published_at is null;
+create index outbox_published_at_idx on outbox (published_at) where published_at is not null;
@@ -0,0 +1,4 @@
+drop index if exists events_start_date_idx;
+drop table if exists sent_reminders;
+drop table if exists event_bookmarks;
+drop table if exists event_rsvps;
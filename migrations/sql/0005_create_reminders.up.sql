@@ -0,0 +1,43 @@
+create table event_rsvps
+(
+    event_id   integer                   not null
+        constraint event_rsvps_events_id_fk
+            references events
+            on delete cascade,
+    user_id    integer                   not null
+        constraint event_rsvps_users_id_fk
+            references users,
+    created_at timestamptz default now() not null,
+    constraint event_rsvps_pk
+        primary key (event_id, user_id)
+);
+
+create table event_bookmarks
+(
+    event_id   integer                   not null
+        constraint event_bookmarks_events_id_fk
+            references events
+            on delete cascade,
+    user_id    integer                   not null
+        constraint event_bookmarks_users_id_fk
+            references users,
+    created_at timestamptz default now() not null,
+    constraint event_bookmarks_pk
+        primary key (event_id, user_id)
+);
+
+-- start_date is part of the key so moving an event sends its reminders again for the new start
+create table sent_reminders
+(
+    event_id   integer                   not null
+        constraint sent_reminders_events_id_fk
+            references events
+            on delete cascade,
+    user_id    integer                   not null,
+    start_date timestamp                 not null,
+    sent_at    timestamptz default now() not null,
+    constraint sent_reminders_pk
+        primary key (event_id, user_id, start_date)
+);
+
+create index events_start_date_idx on events (start_date);
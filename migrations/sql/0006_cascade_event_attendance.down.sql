@@ -0,0 +1,4 @@
+alter table event_attendance
+    drop constraint event_attendance_events_id_fk,
+    add constraint event_attendance_events_id_fk
+        foreign key (event_id) references events;
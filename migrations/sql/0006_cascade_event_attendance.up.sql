@@ -0,0 +1,6 @@
+-- check-ins are recorded now, an event that had any couldn't be deleted while attendance kept referencing it
+alter table event_attendance
+    drop constraint event_attendance_events_id_fk,
+    add constraint event_attendance_events_id_fk
+        foreign key (event_id) references events
+            on delete cascade;
@@ -0,0 +1 @@
+drop index if exists events_hackathon_id_idx;
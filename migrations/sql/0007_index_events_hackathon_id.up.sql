@@ -0,0 +1,2 @@
+-- attendance stats look up every event of a hackathon
+create index events_hackathon_id_idx on events (hackathon_id);
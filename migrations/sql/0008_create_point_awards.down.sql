@@ -0,0 +1,5 @@
+drop trigger if exists event_attendance_award_points on event_attendance;
+drop function if exists award_attendance_points();
+drop table if exists point_awards;
+alter table events
+    drop column if exists points;
@@ -0,0 +1,50 @@
+alter table events
+    add column points integer default 0 not null
+        constraint events_points_check
+            check (points >= 0);
+
+-- a ledger that is only ever appended to, a leaderboard total is the sum of a user's rows
+create table point_awards
+(
+    id           bigserial
+        constraint point_awards_pk
+            primary key,
+    hackathon_id integer                   not null
+        constraint point_awards_hackathons_id_fk
+            references hackathons,
+    user_id      integer                   not null
+        constraint point_awards_users_id_fk
+            references users,
+    -- null for adjustments, the points of an event go when it is deleted like its attendance does
+    event_id     integer
+        constraint point_awards_events_id_fk
+            references events
+            on delete cascade,
+    points       integer                   not null,
+    reason       varchar                   not null,
+    -- the admin who made an adjustment, null for attendance
+    adjusted_by  integer,
+    created_at   timestamptz default now() not null
+);
+
+create index point_awards_hackathon_id_user_id_idx on point_awards (hackathon_id, user_id);
+
+-- a trigger rather than the service awards the points, so check-ins written by anything else count as well
+create function award_attendance_points() returns trigger
+    language plpgsql as
+$$
+begin
+    insert into point_awards (hackathon_id, user_id, event_id, points, reason, created_at)
+    select e.hackathon_id, new.user_id, e.id, e.points, 'attendance', new.time at time zone 'UTC'
+    from events e
+    where e.id = new.event_id
+      and e.points > 0;
+    return new;
+end
+$$;
+
+create trigger event_attendance_award_points
+    after insert
+    on event_attendance
+    for each row
+execute function award_attendance_points();
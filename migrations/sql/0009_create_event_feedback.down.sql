@@ -0,0 +1,2 @@
+drop table if exists event_hosts;
+drop table if exists event_feedback;
@@ -0,0 +1,30 @@
+-- referencing event_attendance lets only attendees who checked in give feedback, and drops it with their check-in
+create table event_feedback
+(
+    event_id   integer                   not null,
+    user_id    integer                   not null,
+    rating     smallint                  not null
+        constraint event_feedback_rating_check
+            check (rating between 1 and 5),
+    comment    varchar     default ''    not null,
+    created_at timestamptz default now() not null,
+    constraint event_feedback_pk
+        primary key (event_id, user_id),
+    constraint event_feedback_event_attendance_fk
+        foreign key (event_id, user_id) references event_attendance
+            on delete cascade
+);
+
+create table event_hosts
+(
+    event_id   integer                   not null
+        constraint event_hosts_events_id_fk
+            references events
+            on delete cascade,
+    user_id    integer                   not null
+        constraint event_hosts_users_id_fk
+            references users,
+    created_at timestamptz default now() not null,
+    constraint event_hosts_pk
+        primary key (event_id, user_id)
+);
@@ -0,0 +1,6 @@
+drop index if exists outbox_parked_idx;
+drop index if exists outbox_unpublished_idx;
+create index outbox_unpublished_idx on outbox (id) where published_at is null;
+alter table outbox
+    drop column if exists parked_at,
+    drop column if exists attempts;
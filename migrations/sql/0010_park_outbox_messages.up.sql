@@ -0,0 +1,8 @@
+-- a message that keeps failing is parked after outbox.max_attempts instead of holding back its event forever
+alter table outbox
+    add column attempts  integer default 0 not null,
+    add column parked_at timestamptz;
+
+drop index if exists outbox_unpublished_idx;
+create index outbox_unpublished_idx on outbox (id) where published_at is null and parked_at is null;
+create index outbox_parked_idx on outbox (id) where parked_at is not null;
@@ -0,0 +1,58 @@
+// Package outbox is the reliable signal that an event changed. Repositories write a Message in the same transaction
+// as the change itself, so there is a message exactly when the change is committed, and a Relay later hands every
+// message to the Publishers. Delivery is at least once: a relay that dies before marking a batch published sends it
+// again. Messages about the same event are always published in the order they were written.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_shared/database"
+)
+
+// Topics the repositories write, the data of created and updated is the Event, deleted only has its id and
+// checked in is the Attendance
+const (
+	TopicEventCreated        = "event.created"
+	TopicEventUpdated        = "event.updated"
+	TopicEventDeleted        = "event.deleted"
+	TopicAttendanceCheckedIn = "attendance.checked_in"
+)
+
+// Message
+// One change, AggregateID is the id of the event it is about and Payload is the JSON encoded data of the topic
+type Message struct {
+	ID          string          `json:"id"`
+	AggregateID string          `json:"aggregateId"`
+	Topic       string          `json:"topic"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	// Attempts is how many times publishing it failed before
+	Attempts int `json:"attempts"`
+}
+
+// Publisher
+// Anything that wants to hear about changes. Publish is called once per message in order, but a message may be
+// published again after a crash or after another publisher failed, so it has to be idempotent or tolerate duplicates.
+type Publisher interface {
+	Publish(ctx context.Context, message *Message) error
+}
+
+// PublisherFunc adapts a function to a Publisher
+type PublisherFunc func(ctx context.Context, message *Message) error
+
+func (f PublisherFunc) Publish(ctx context.Context, message *Message) error {
+	return f(ctx, message)
+}
+
+// Insert writes a message about aggregateID to the outbox table, tx has to be the transaction making the change
+func Insert(ctx context.Context, tx database.Queryable, aggregateID string, topic string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO outbox (aggregate_id, topic, payload) VALUES ($1, $2, $3)", aggregateID, topic, payload)
+	return err
+}
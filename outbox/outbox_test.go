@@ -0,0 +1,134 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/outbox"
+	"github.com/KnightHacks/knighthacks_events/outbox/outboxtest"
+)
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	store := outbox.NewMemoryStore()
+	outboxtest.Run(t, store, func(t *testing.T, aggregateID string, topic string) {
+		if err := store.Add(aggregateID, topic, map[string]string{"id": aggregateID}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	})
+}
+
+// recorder is a Publisher that keeps what it was sent, it fails every message whose aggregate is in fail
+type recorder struct {
+	fail      map[string]bool
+	published []string
+}
+
+func (r *recorder) Publish(ctx context.Context, message *outbox.Message) error {
+	if r.fail[message.AggregateID] {
+		return errors.New("publisher is down")
+	}
+	r.published = append(r.published, message.AggregateID+" "+message.Topic)
+	return nil
+}
+
+func add(t *testing.T, store *outbox.MemoryStore, aggregateID string, topic string) {
+	t.Helper()
+	if err := store.Add(aggregateID, topic, map[string]string{"id": aggregateID}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+}
+
+func relay(t *testing.T, relay *outbox.Relay, want int) {
+	t.Helper()
+	got, err := relay.RelayPending(context.Background())
+	if err != nil {
+		t.Fatalf("RelayPending() error = %v", err)
+	}
+	if got != want {
+		t.Fatalf("RelayPending() published %d messages, want %d", got, want)
+	}
+}
+
+func TestRelay(t *testing.T) {
+	store := outbox.NewMemoryStore()
+	first, second := &recorder{}, &recorder{}
+	relayer := outbox.NewRelay(store, first, second)
+	relayer.BatchSize = 2
+
+	add(t, store, "1", outbox.TopicEventCreated)
+	add(t, store, "2", outbox.TopicEventCreated)
+	add(t, store, "1", outbox.TopicEventDeleted)
+	relay(t, relayer, 2)
+	relay(t, relayer, 1)
+	relay(t, relayer, 0)
+
+	want := []string{"1 event.created", "2 event.created", "1 event.deleted"}
+	if !reflect.DeepEqual(first.published, want) || !reflect.DeepEqual(second.published, want) {
+		t.Errorf("published %v and %v, want both %v", first.published, second.published, want)
+	}
+}
+
+func TestRelay_HoldsBackAfterAFailure(t *testing.T) {
+	store := outbox.NewMemoryStore()
+	publisher := &recorder{fail: map[string]bool{"1": true}}
+	relayer := outbox.NewRelay(store, publisher)
+
+	add(t, store, "1", outbox.TopicEventCreated)
+	add(t, store, "2", outbox.TopicEventCreated)
+	add(t, store, "1", outbox.TopicEventUpdated)
+	add(t, store, "2", outbox.TopicEventDeleted)
+	relay(t, relayer, 2)
+	// the update of 1 can't overtake its creation, 2 isn't held up by it
+	if want := []string{"2 event.created", "2 event.deleted"}; !reflect.DeepEqual(publisher.published, want) {
+		t.Fatalf("published %v, want %v", publisher.published, want)
+	}
+
+	publisher.fail = nil
+	relay(t, relayer, 2)
+	relay(t, relayer, 0)
+	want := []string{"2 event.created", "2 event.deleted", "1 event.created", "1 event.updated"}
+	if !reflect.DeepEqual(publisher.published, want) {
+		t.Errorf("published %v, want %v", publisher.published, want)
+	}
+}
+
+func TestRelay_RepublishesWhenAnyPublisherFails(t *testing.T) {
+	store := outbox.NewMemoryStore()
+	healthy, failing := &recorder{}, &recorder{fail: map[string]bool{"1": true}}
+	relayer := outbox.NewRelay(store, healthy, failing)
+
+	add(t, store, "1", outbox.TopicEventCreated)
+	relay(t, relayer, 0)
+	failing.fail = nil
+	relay(t, relayer, 1)
+
+	// at least once, the publisher that took it the first time gets it again
+	if want := []string{"1 event.created", "1 event.created"}; !reflect.DeepEqual(healthy.published, want) {
+		t.Errorf("healthy publisher got %v, want %v", healthy.published, want)
+	}
+	if want := []string{"1 event.created"}; !reflect.DeepEqual(failing.published, want) {
+		t.Errorf("failing publisher got %v, want %v", failing.published, want)
+	}
+}
+
+func TestRelay_ParksAfterMaxAttempts(t *testing.T) {
+	store := outbox.NewMemoryStore()
+	publisher := &recorder{fail: map[string]bool{"1": true}}
+	relayer := outbox.NewRelay(store, publisher)
+	relayer.MaxAttempts = 2
+
+	add(t, store, "1", outbox.TopicEventCreated)
+	add(t, store, "1", outbox.TopicEventUpdated)
+	relay(t, relayer, 0)
+	relay(t, relayer, 0)
+
+	// the creation is parked, so the update no longer waits for it
+	publisher.fail = nil
+	relay(t, relayer, 1)
+	relay(t, relayer, 0)
+	if want := []string{"1 event.updated"}; !reflect.DeepEqual(publisher.published, want) {
+		t.Errorf("published %v, want %v", publisher.published, want)
+	}
+}
@@ -0,0 +1,252 @@
+// Package outboxtest is the test suite every outbox.Store has to pass
+package outboxtest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/outbox"
+)
+
+// Add writes a message about aggregateID with {"id": aggregateID} as its data, the way a repository would
+type Add func(t *testing.T, aggregateID string, topic string)
+
+// Run checks store against the Store contract, messages already in it are published and otherwise ignored
+func Run(t *testing.T, store outbox.Store, add Add) {
+	ctx := context.Background()
+	prefix := "outboxtest-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-"
+
+	// relay hands the messages to publish, only those of aggregates are returned and only those not in keep are published
+	relay := func(t *testing.T, limit int, aggregates []string, keep map[string]bool) []*outbox.Message {
+		t.Helper()
+		var own []*outbox.Message
+		_, err := store.Relay(ctx, limit, func(ctx context.Context, messages []*outbox.Message) outbox.Result {
+			var published []string
+			for _, message := range messages {
+				for _, aggregate := range aggregates {
+					if message.AggregateID == aggregate {
+						own = append(own, message)
+					}
+				}
+				if !keep[message.ID] {
+					published = append(published, message.ID)
+				}
+			}
+			return outbox.Result{Published: published}
+		})
+		if err != nil {
+			t.Fatalf("Relay() error = %v", err)
+		}
+		return own
+	}
+	drain := func(t *testing.T) {
+		t.Helper()
+		for {
+			relayed, err := store.Relay(ctx, 1000, func(ctx context.Context, messages []*outbox.Message) outbox.Result {
+				ids := make([]string, 0, len(messages))
+				for _, message := range messages {
+					ids = append(ids, message.ID)
+				}
+				return outbox.Result{Published: ids}
+			})
+			if err != nil {
+				t.Fatalf("Relay() error = %v", err)
+			}
+			if relayed < 1000 {
+				return
+			}
+		}
+	}
+
+	t.Run("Relay hands messages oldest first", func(t *testing.T) {
+		first, second := prefix+"order-1", prefix+"order-2"
+		add(t, first, outbox.TopicEventCreated)
+		add(t, second, outbox.TopicEventCreated)
+		add(t, first, outbox.TopicEventUpdated)
+
+		got := relay(t, 1000, []string{first, second}, nil)
+		var topics []string
+		for _, message := range got {
+			topics = append(topics, message.AggregateID+" "+message.Topic)
+		}
+		want := []string{first + " " + outbox.TopicEventCreated, second + " " + outbox.TopicEventCreated, first + " " + outbox.TopicEventUpdated}
+		if !reflect.DeepEqual(topics, want) {
+			t.Fatalf("Relay() handed %v, want %v", topics, want)
+		}
+		var data map[string]string
+		if err := json.Unmarshal(got[0].Payload, &data); err != nil || data["id"] != first {
+			t.Errorf("Payload = %s, want the id %s", got[0].Payload, first)
+		}
+		if got[0].ID == "" || got[0].CreatedAt.IsZero() {
+			t.Errorf("Relay() handed %+v, want an id and when it was created", got[0])
+		}
+	})
+
+	t.Run("Relay doesn't hand published messages again", func(t *testing.T) {
+		aggregate := prefix + "published"
+		add(t, aggregate, outbox.TopicEventCreated)
+		add(t, aggregate, outbox.TopicEventUpdated)
+
+		got := relay(t, 1000, []string{aggregate}, nil)
+		if len(got) != 2 {
+			t.Fatalf("Relay() handed %d messages, want 2", len(got))
+		}
+		add(t, aggregate, outbox.TopicEventDeleted)
+		got = relay(t, 1000, []string{aggregate}, nil)
+		if len(got) != 1 || got[0].Topic != outbox.TopicEventDeleted {
+			t.Fatalf("Relay() handed %v, want only the new message", got)
+		}
+	})
+
+	t.Run("Relay keeps what publish didn't return", func(t *testing.T) {
+		aggregate := prefix + "keep"
+		add(t, aggregate, outbox.TopicEventCreated)
+		add(t, aggregate, outbox.TopicEventUpdated)
+
+		// peek at the ids without publishing anything
+		var ids []string
+		_, err := store.Relay(ctx, 1000, func(ctx context.Context, messages []*outbox.Message) outbox.Result {
+			for _, message := range messages {
+				if message.AggregateID == aggregate {
+					ids = append(ids, message.ID)
+				}
+			}
+			return outbox.Result{}
+		})
+		if err != nil || len(ids) != 2 {
+			t.Fatalf("Relay() = %v, %v, want the 2 messages", ids, err)
+		}
+
+		got := relay(t, 1000, []string{aggregate}, map[string]bool{ids[1]: true})
+		if len(got) != 2 {
+			t.Fatalf("Relay() handed %d messages, want both again", len(got))
+		}
+		got = relay(t, 1000, []string{aggregate}, nil)
+		if len(got) != 1 || got[0].ID != ids[1] {
+			t.Fatalf("Relay() handed %v, want only the message that was kept", got)
+		}
+		if got = relay(t, 1000, []string{aggregate}, nil); len(got) != 0 {
+			t.Errorf("Relay() handed %v after everything was published", got)
+		}
+	})
+
+	t.Run("Relay returns how many were published", func(t *testing.T) {
+		drain(t)
+		published, kept := prefix+"count-1", prefix+"count-2"
+		add(t, published, outbox.TopicEventCreated)
+		add(t, kept, outbox.TopicEventCreated)
+
+		got, err := store.Relay(ctx, 1000, func(ctx context.Context, messages []*outbox.Message) outbox.Result {
+			var result outbox.Result
+			for _, message := range messages {
+				if message.AggregateID == published {
+					result.Published = append(result.Published, message.ID)
+				}
+			}
+			return result
+		})
+		if err != nil || got != 1 {
+			t.Fatalf("Relay() = %d, %v, want 1 of the 2 messages", got, err)
+		}
+		drain(t)
+	})
+
+	t.Run("Relay counts failures and parks messages", func(t *testing.T) {
+		aggregate := prefix + "park"
+		add(t, aggregate, outbox.TopicEventCreated)
+
+		// attempt fails the message of aggregate, or parks it, and publishes everything else
+		attempt := func(t *testing.T, park bool) []*outbox.Message {
+			t.Helper()
+			var own []*outbox.Message
+			_, err := store.Relay(ctx, 1000, func(ctx context.Context, messages []*outbox.Message) outbox.Result {
+				var result outbox.Result
+				for _, message := range messages {
+					switch {
+					case message.AggregateID != aggregate:
+						result.Published = append(result.Published, message.ID)
+					case park:
+						result.Parked = append(result.Parked, message.ID)
+					default:
+						result.Failed = append(result.Failed, message.ID)
+					}
+					if message.AggregateID == aggregate {
+						own = append(own, message)
+					}
+				}
+				return result
+			})
+			if err != nil {
+				t.Fatalf("Relay() error = %v", err)
+			}
+			return own
+		}
+
+		if got := attempt(t, false); len(got) != 1 || got[0].Attempts != 0 {
+			t.Fatalf("Relay() handed %+v, want the message without attempts", got)
+		}
+		if got := attempt(t, true); len(got) != 1 || got[0].Attempts != 1 {
+			t.Fatalf("Relay() handed %+v, want the message after 1 failed attempt", got)
+		}
+		if got := attempt(t, false); len(got) != 0 {
+			t.Errorf("Relay() handed %+v, want the parked message left out", got)
+		}
+	})
+
+	t.Run("Relay limit", func(t *testing.T) {
+		drain(t)
+		aggregate := prefix + "limit"
+		add(t, aggregate, outbox.TopicEventCreated)
+		add(t, aggregate, outbox.TopicEventUpdated)
+
+		got := relay(t, 1, []string{aggregate}, nil)
+		if len(got) != 1 || got[0].Topic != outbox.TopicEventCreated {
+			t.Fatalf("Relay(1) handed %v, want the oldest message", got)
+		}
+		got = relay(t, 1, []string{aggregate}, nil)
+		if len(got) != 1 || got[0].Topic != outbox.TopicEventUpdated {
+			t.Fatalf("Relay(1) handed %v, want the next message", got)
+		}
+	})
+
+	t.Run("Relay runs one at a time", func(t *testing.T) {
+		add(t, prefix+"exclusive", outbox.TopicEventCreated)
+		nested := -1
+		_, err := store.Relay(ctx, 1000, func(ctx context.Context, messages []*outbox.Message) outbox.Result {
+			var err error
+			nested, err = store.Relay(ctx, 1000, func(ctx context.Context, messages []*outbox.Message) outbox.Result {
+				t.Errorf("a second Relay() was handed %d messages while the first one was running", len(messages))
+				return outbox.Result{}
+			})
+			if err != nil {
+				t.Errorf("second Relay() error = %v", err)
+			}
+			return outbox.Result{}
+		})
+		if err != nil {
+			t.Fatalf("Relay() error = %v", err)
+		}
+		if nested != 0 {
+			t.Errorf("second Relay() = %d, want 0", nested)
+		}
+		drain(t)
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		published, pending := prefix+"prune-1", prefix+"prune-2"
+		add(t, published, outbox.TopicEventCreated)
+		drain(t)
+		add(t, pending, outbox.TopicEventCreated)
+
+		if pruned, err := store.Prune(ctx, time.Now().Add(time.Hour)); err != nil || pruned < 1 {
+			t.Fatalf("Prune() = %d, %v, want at least the published message", pruned, err)
+		}
+		if got := relay(t, 1000, []string{published, pending}, nil); len(got) != 1 || got[0].AggregateID != pending {
+			t.Errorf("Relay() after pruning handed %v, want only the unpublished message", got)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// relayLockKey is the transaction level advisory lock a relay holds, it spells outbox in ASCII
+const relayLockKey = 0x6f7574626f78
+
+// PostgresStore
+// The Store used in production. Relays on different replicas take turns through an advisory lock, which is what keeps
+// messages about the same event in order. The table is created by the migrations package.
+type PostgresStore struct {
+	DatabasePool *pgxpool.Pool
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+func NewPostgresStore(databasePool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{DatabasePool: databasePool}
+}
+
+func (s *PostgresStore) Relay(ctx context.Context, limit int, publish func(ctx context.Context, messages []*Message) Result) (int, error) {
+	published := 0
+	err := pgx.BeginTxFunc(ctx, s.DatabasePool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLockKey).Scan(&locked); err != nil || !locked {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, "SELECT id, aggregate_id, topic, payload, created_at, attempts FROM outbox WHERE published_at IS NULL AND parked_at IS NULL ORDER BY id LIMIT $1", limit)
+		if err != nil {
+			return err
+		}
+		var messages []*Message
+		for rows.Next() {
+			var id int64
+			message := &Message{}
+			if err = rows.Scan(&id, &message.AggregateID, &message.Topic, &message.Payload, &message.CreatedAt, &message.Attempts); err != nil {
+				rows.Close()
+				return err
+			}
+			message.ID = strconv.FormatInt(id, 10)
+			messages = append(messages, message)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil || len(messages) == 0 {
+			return err
+		}
+
+		result := publish(ctx, messages)
+		// a relay that dies before committing leaves the batch unpublished, it goes out again with the next one
+		commandTag, err := tx.Exec(ctx, "UPDATE outbox SET published_at = now() WHERE id = ANY($1)", outboxIDs(result.Published))
+		if err != nil {
+			return err
+		}
+		published = int(commandTag.RowsAffected())
+		if _, err = tx.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1 WHERE id = ANY($1)", outboxIDs(result.Failed)); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1, parked_at = now() WHERE id = ANY($1)", outboxIDs(result.Parked))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, nil
+}
+
+func outboxIDs(ids []string) []int64 {
+	keys := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		key, _ := strconv.ParseInt(id, 10, 64)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (s *PostgresStore) Prune(ctx context.Context, before time.Time) (int, error) {
+	commandTag, err := s.DatabasePool.Exec(ctx, "DELETE FROM outbox WHERE published_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return int(commandTag.RowsAffected()), nil
+}
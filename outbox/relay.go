@@ -0,0 +1,150 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// NotifyChannel is the postgres channel NotifyPublisher sends to, the payload is a Notification
+const NotifyChannel = "events_outbox"
+
+var relayed = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "events",
+	Subsystem: "outbox",
+	Name:      "messages_total",
+	Help:      "Outbox messages handed to the publishers, by result: published, failed, parked or held back behind a failed one",
+}, []string{"result"})
+
+// Relay
+// Publishes what the repositories wrote to a Store. A message counts as published once every publisher took it, when
+// one fails the message stays in the outbox and later messages about the same event are held back until it goes out or
+// is parked after MaxAttempts.
+type Relay struct {
+	store      Store
+	publishers []Publisher
+	// BatchSize is how many messages are relayed at once
+	BatchSize int
+	// MaxAttempts is how many times a message may fail before it is parked, 0 retries it forever
+	MaxAttempts int
+	// Retention is how long published messages are kept before they are pruned
+	Retention time.Duration
+	now       func() time.Time
+}
+
+func NewRelay(store Store, publishers ...Publisher) *Relay {
+	return &Relay{
+		store:       store,
+		publishers:  publishers,
+		BatchSize:   100,
+		MaxAttempts: 10,
+		Retention:   7 * 24 * time.Hour,
+		now:         time.Now,
+	}
+}
+
+// Run relays messages every interval until ctx is cancelled, a batch that published anything is followed by the next
+// one straight away.
+// Published messages older than Retention are pruned about once an hour.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var prunedAt time.Time
+	for {
+		published, err := r.RelayPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Unable to relay outbox messages", "error", err)
+		}
+		if now := r.now(); now.Sub(prunedAt) >= time.Hour {
+			if _, pruneErr := r.store.Prune(ctx, now.Add(-r.Retention)); pruneErr == nil {
+				prunedAt = now
+			} else if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Unable to prune outbox messages", "error", pruneErr)
+			}
+		}
+		if err == nil && published > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending relays one batch of unpublished messages, it returns how many were published
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	return r.store.Relay(ctx, r.BatchSize, r.publish)
+}
+
+func (r *Relay) publish(ctx context.Context, messages []*Message) Result {
+	var result Result
+	held := map[string]bool{}
+	for _, message := range messages {
+		if held[message.AggregateID] {
+			relayed.WithLabelValues("held").Inc()
+			continue
+		}
+		if err := r.publishOne(ctx, message); err != nil {
+			held[message.AggregateID] = true
+			attempts := message.Attempts + 1
+			if r.MaxAttempts > 0 && attempts >= r.MaxAttempts {
+				slog.ErrorContext(ctx, "Unable to publish outbox message, parking it",
+					"message_id", message.ID, "aggregate_id", message.AggregateID, "topic", message.Topic, "attempts", attempts, "error", err)
+				relayed.WithLabelValues("parked").Inc()
+				result.Parked = append(result.Parked, message.ID)
+				continue
+			}
+			slog.WarnContext(ctx, "Unable to publish outbox message, retrying with the next batch",
+				"message_id", message.ID, "aggregate_id", message.AggregateID, "topic", message.Topic, "attempts", attempts, "error", err)
+			relayed.WithLabelValues("failed").Inc()
+			result.Failed = append(result.Failed, message.ID)
+			continue
+		}
+		relayed.WithLabelValues("published").Inc()
+		result.Published = append(result.Published, message.ID)
+	}
+	return result
+}
+
+func (r *Relay) publishOne(ctx context.Context, message *Message) error {
+	for _, publisher := range r.publishers {
+		if err := publisher.Publish(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Notification
+// What NotifyPublisher sends, the payload is left out since NOTIFY payloads are limited to 8000 bytes
+type Notification struct {
+	ID          string `json:"id"`
+	AggregateID string `json:"aggregateId"`
+	Topic       string `json:"topic"`
+}
+
+// NotifyPublisher
+// Sends every message to NotifyChannel so anything with a database connection can LISTEN for changes
+type NotifyPublisher struct {
+	pool *pgxpool.Pool
+}
+
+func NewNotifyPublisher(pool *pgxpool.Pool) *NotifyPublisher {
+	return &NotifyPublisher{pool: pool}
+}
+
+func (p *NotifyPublisher) Publish(ctx context.Context, message *Message) error {
+	payload, err := json.Marshal(Notification{ID: message.ID, AggregateID: message.AggregateID, Topic: message.Topic})
+	if err != nil {
+		return err
+	}
+	_, err = p.pool.Exec(ctx, "SELECT pg_notify($1, $2)", NotifyChannel, string(payload))
+	return err
+}
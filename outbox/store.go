@@ -0,0 +1,147 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Result
+// What publish did with the messages it was handed, the ones in none of the lists were held back behind a failed one
+// and are left as they were
+type Result struct {
+	Published []string
+	// Failed messages are handed out again, their Attempts go up by one
+	Failed []string
+	// Parked messages failed too often, they aren't handed out again until parked_at is cleared
+	Parked []string
+}
+
+// Store is where messages wait to be published
+type Store interface {
+	// Relay hands up to limit unpublished messages that aren't parked, oldest first, to publish and records the Result
+	// it returns. Only one Relay runs at a time across every replica, a call made while another one is running returns
+	// 0 without calling publish. It returns how many messages were published.
+	Relay(ctx context.Context, limit int, publish func(ctx context.Context, messages []*Message) Result) (int, error)
+	// Prune deletes messages that were published before, it returns how many it deleted
+	Prune(ctx context.Context, before time.Time) (int, error)
+}
+
+// MemoryStore
+// The Store repository.MemoryRepository writes to with Add
+type MemoryStore struct {
+	// relaying is held for the whole of Relay, mu only while the messages are read or changed
+	relaying sync.Mutex
+	mu       sync.Mutex
+	messages map[int]*memoryMessage
+	lastID   int
+}
+
+type memoryMessage struct {
+	Message
+	publishedAt *time.Time
+	parkedAt    *time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{messages: map[int]*memoryMessage{}}
+}
+
+// Add writes a message like Insert does, callers make it atomic with their change by holding their own lock
+func (s *MemoryStore) Add(aggregateID string, topic string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	s.messages[s.lastID] = &memoryMessage{Message: Message{
+		ID:          strconv.Itoa(s.lastID),
+		AggregateID: aggregateID,
+		Topic:       topic,
+		Payload:     payload,
+		CreatedAt:   time.Now().UTC(),
+	}}
+	return nil
+}
+
+func (s *MemoryStore) Relay(ctx context.Context, limit int, publish func(ctx context.Context, messages []*Message) Result) (int, error) {
+	if !s.relaying.TryLock() {
+		return 0, nil
+	}
+	defer s.relaying.Unlock()
+
+	s.mu.Lock()
+	keys := make([]int, 0, len(s.messages))
+	for key, message := range s.messages {
+		if message.publishedAt == nil && message.parkedAt == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Ints(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	messages := make([]*Message, 0, len(keys))
+	for _, key := range keys {
+		message := s.messages[key].Message
+		messages = append(messages, &message)
+	}
+	s.mu.Unlock()
+
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	result := publish(ctx, messages)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	published := 0
+	for _, id := range result.Published {
+		if message, ok := s.message(id); ok {
+			message.publishedAt = &now
+			published++
+		}
+	}
+	for _, id := range result.Failed {
+		if message, ok := s.message(id); ok {
+			message.Attempts++
+		}
+	}
+	for _, id := range result.Parked {
+		if message, ok := s.message(id); ok {
+			message.Attempts++
+			message.parkedAt = &now
+		}
+	}
+	return published, nil
+}
+
+func (s *MemoryStore) message(id string) (*memoryMessage, bool) {
+	key, _ := strconv.Atoi(id)
+	message, ok := s.messages[key]
+	return message, ok
+}
+
+func (s *MemoryStore) Prune(ctx context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pruned := 0
+	for key, message := range s.messages {
+		if message.publishedAt != nil && message.publishedAt.Before(before) {
+			delete(s.messages, key)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
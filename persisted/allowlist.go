@@ -0,0 +1,127 @@
+package persisted
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	ErrPersistedQueryRequiredCode   = "PERSISTED_QUERY_REQUIRED"
+	ErrPersistedQueryNotAllowedCode = "PERSISTED_QUERY_NOT_ALLOWED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrPersistedQueryRequiredCode, errcode.KindProtocol)
+	errcode.RegisterErrorType(ErrPersistedQueryNotAllowedCode, errcode.KindProtocol)
+}
+
+// Manifest follows the format written by @apollo/generate-persisted-query-manifest
+type Manifest struct {
+	Format     string              `json:"format"`
+	Version    int                 `json:"version"`
+	Operations []ManifestOperation `json:"operations"`
+}
+
+type ManifestOperation struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Body string `json:"body"`
+}
+
+// LoadManifest reads the manifest at path and returns its operations keyed by sha256 hash,
+// every id is checked against its body so a stale manifest fails at startup instead of at request time
+func LoadManifest(path string) (map[string]string, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err = json.Unmarshal(file, &manifest); err != nil {
+		return nil, fmt.Errorf("unable to parse persisted query manifest: %w", err)
+	}
+	if manifest.Format != "apollo-persisted-query-manifest" || manifest.Version != 1 {
+		return nil, fmt.Errorf("unsupported persisted query manifest format %q version %d", manifest.Format, manifest.Version)
+	}
+
+	operations := make(map[string]string, len(manifest.Operations))
+	for _, operation := range manifest.Operations {
+		if hash := QueryHash(operation.Body); hash != operation.ID {
+			return nil, fmt.Errorf("persisted operation %q has id %s but its body hashes to %s", operation.Name, operation.ID, hash)
+		}
+		operations[operation.ID] = operation.Body
+	}
+	return operations, nil
+}
+
+// QueryHash is the hash APQ clients send in the persistedQuery extension
+func QueryHash(query string) string {
+	hash := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(hash[:])
+}
+
+// AllowList
+// Rejects every operation that isn't referenced by hash and present in Operations,
+// it has to be registered before extension.AutomaticPersistedQuery
+type AllowList struct {
+	Operations map[string]string
+}
+
+var _ interface {
+	graphql.OperationParameterMutator
+	graphql.HandlerExtension
+} = AllowList{}
+
+func (a AllowList) ExtensionName() string {
+	return "PersistedQueryAllowList"
+}
+
+func (a AllowList) Validate(schema graphql.ExecutableSchema) error {
+	if a.Operations == nil {
+		return fmt.Errorf("AllowList.Operations can not be nil")
+	}
+	return nil
+}
+
+func (a AllowList) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	if rawParams.Extensions["persistedQuery"] == nil {
+		err := gqlerror.Errorf("only persisted queries are accepted")
+		errcode.Set(err, ErrPersistedQueryRequiredCode)
+		return err
+	}
+
+	extension, ok := rawParams.Extensions["persistedQuery"].(map[string]interface{})
+	if !ok {
+		return gqlerror.Errorf("invalid APQ extension data")
+	}
+	hash, _ := extension["sha256Hash"].(string)
+
+	if _, ok = a.Operations[hash]; !ok {
+		err := gqlerror.Errorf("persisted query is not in the allow list")
+		errcode.Set(err, ErrPersistedQueryNotAllowedCode)
+		return err
+	}
+	return nil
+}
+
+// ReadOnlyCache serves the allow listed operations to extension.AutomaticPersistedQuery
+// and ignores the registrations clients attempt by sending a query along with its hash
+type ReadOnlyCache map[string]string
+
+var _ graphql.Cache = ReadOnlyCache{}
+
+func (c ReadOnlyCache) Get(ctx context.Context, key string) (value interface{}, ok bool) {
+	query, ok := c[key]
+	return query, ok
+}
+
+func (c ReadOnlyCache) Add(ctx context.Context, key string, value interface{}) {}
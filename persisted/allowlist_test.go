@@ -0,0 +1,165 @@
+package persisted_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/KnightHacks/knighthacks_events/graph/graphtest"
+	"github.com/KnightHacks/knighthacks_events/persisted"
+	"github.com/KnightHacks/knighthacks_events/repository"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const listed = `query Events { events(first: 10) { totalCount } }`
+
+// writeManifest writes a manifest with operations keyed by the ids given, which don't have to match their bodies
+func writeManifest(t *testing.T, format string, operations map[string]string) string {
+	t.Helper()
+	manifest := persisted.Manifest{Format: format, Version: 1}
+	for id, body := range operations {
+		manifest.Operations = append(manifest.Operations, persisted.ManifestOperation{ID: id, Name: "Events", Type: "query", Body: body})
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("unable to encode manifest: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("unable to write manifest: %v", err)
+	}
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	hash := persisted.QueryHash(listed)
+	operations, err := persisted.LoadManifest(writeManifest(t, "apollo-persisted-query-manifest", map[string]string{hash: listed}))
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if len(operations) != 1 || operations[hash] != listed {
+		t.Errorf("LoadManifest() = %v, want %s keyed by its hash", operations, listed)
+	}
+
+	tests := map[string]string{
+		"an id that isn't the hash of its body": writeManifest(t, "apollo-persisted-query-manifest", map[string]string{persisted.QueryHash("{ events(first: 1) { totalCount } }"): listed}),
+		"another format":                        writeManifest(t, "relay", map[string]string{hash: listed}),
+		"a missing file":                        filepath.Join(t.TempDir(), "missing.json"),
+	}
+	for name, path := range tests {
+		if _, err = persisted.LoadManifest(path); err == nil {
+			t.Errorf("LoadManifest() of %s error = nil, want an error", name)
+		}
+	}
+}
+
+func TestReadOnlyCache(t *testing.T) {
+	ctx := context.Background()
+	cache := persisted.ReadOnlyCache{"listed": listed}
+
+	cache.Add(ctx, "registered", "{ events(first: 100) { totalCount } }")
+	if _, ok := cache.Get(ctx, "registered"); ok {
+		t.Error("Get() found an operation a client registered, want registrations ignored")
+	}
+	cache.Add(ctx, "listed", "{ events(first: 100) { totalCount } }")
+	if query, ok := cache.Get(ctx, "listed"); !ok || query != listed {
+		t.Errorf("Get() = %v, %v, want the allow listed operation unchanged", query, ok)
+	}
+}
+
+// TestAllowList serves the schema the way main does in allow-list mode
+func TestAllowList(t *testing.T) {
+	allowList := map[string]string{persisted.QueryHash(listed): listed}
+	srv := handler.New(graph.NewExecutableSchema(&graph.Resolver{Repository: repository.NewMemoryRepository()}, graphtest.HasRole))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(persisted.AllowList{Operations: allowList})
+	srv.Use(extension.AutomaticPersistedQuery{Cache: persisted.ReadOnlyCache(allowList)})
+	srv.Use(persisted.CacheControl{MaxAge: time.Minute, Public: allowList})
+
+	extensions := func(query string) string {
+		return `{"persistedQuery": {"version": 1, "sha256Hash": "` + persisted.QueryHash(query) + `"}}`
+	}
+	unlisted := `{ events(first: 1) { totalCount } }`
+	tests := []struct {
+		name   string
+		method string
+		params url.Values
+		want   string
+		// wantCode is the code of the only error
+		wantCode      string
+		wantCacheable bool
+	}{
+		{
+			name:          "a persisted GET request",
+			method:        http.MethodGet,
+			params:        url.Values{"extensions": {extensions(listed)}},
+			want:          `{"events":{"totalCount":0}}`,
+			wantCacheable: true,
+		},
+		{
+			name:     "a query that isn't persisted",
+			method:   http.MethodGet,
+			params:   url.Values{"query": {listed}},
+			wantCode: persisted.ErrPersistedQueryRequiredCode,
+		},
+		{
+			name:     "a hash that isn't in the manifest",
+			method:   http.MethodGet,
+			params:   url.Values{"extensions": {extensions(unlisted)}},
+			wantCode: persisted.ErrPersistedQueryNotAllowedCode,
+		},
+		{
+			name:     "registering an operation",
+			method:   http.MethodPost,
+			params:   url.Values{"query": {unlisted}, "extensions": {extensions(unlisted)}},
+			wantCode: persisted.ErrPersistedQueryNotAllowedCode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request *http.Request
+			if tt.method == http.MethodGet {
+				request = httptest.NewRequest(http.MethodGet, "/query?"+tt.params.Encode(), nil)
+			} else {
+				body, _ := json.Marshal(map[string]interface{}{"query": tt.params.Get("query"), "extensions": json.RawMessage(tt.params.Get("extensions"))})
+				request = httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(string(body)))
+				request.Header.Set("Content-Type", "application/json")
+			}
+			recorder := httptest.NewRecorder()
+			ctx := persisted.WithCacheableResponse(request.Context(), recorder.Header(), true)
+			srv.ServeHTTP(recorder, request.WithContext(ctx))
+
+			var response struct {
+				Data   json.RawMessage `json:"data"`
+				Errors gqlerror.List   `json:"errors"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("unable to decode response %q: %v", recorder.Body.String(), err)
+			}
+			if tt.wantCode != "" {
+				if len(response.Errors) != 1 || response.Errors[0].Extensions["code"] != tt.wantCode {
+					t.Errorf("errors = %v, want one with code %s", response.Errors, tt.wantCode)
+				}
+				return
+			}
+			if len(response.Errors) != 0 || string(response.Data) != tt.want {
+				t.Errorf("response = %s, %v, want %s", response.Data, response.Errors, tt.want)
+			}
+			if got := recorder.Header().Get("Cache-Control"); tt.wantCacheable && got != "public, max-age=60" {
+				t.Errorf("Cache-Control = %q, want public, max-age=60", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package persisted
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type cacheableKey struct{}
+
+type cacheable struct {
+	header http.Header
+	shared bool
+}
+
+// WithCacheableResponse marks a GET request as cacheable, header is where Cache-Control will be written.
+// shared should be false for authenticated requests, their responses are only ever kept by the browser
+func WithCacheableResponse(ctx context.Context, header http.Header, shared bool) context.Context {
+	return context.WithValue(ctx, cacheableKey{}, &cacheable{header: header, shared: shared})
+}
+
+// CacheControl
+// Lets browsers cache successful query responses that were requested by APQ hash over GET. Only operations in Public
+// may be kept by CDNs and other shared caches, and only for anonymous requests, anything a client registered itself
+// could return whatever that client is allowed to see.
+type CacheControl struct {
+	MaxAge time.Duration
+	// Public holds the allow listed operations by hash, nil when there is no allow list
+	Public map[string]string
+}
+
+var _ interface {
+	graphql.ResponseInterceptor
+	graphql.HandlerExtension
+} = CacheControl{}
+
+func (c CacheControl) ExtensionName() string {
+	return "PersistedQueryCacheControl"
+}
+
+func (c CacheControl) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (c CacheControl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	response := next(ctx)
+
+	target, ok := ctx.Value(cacheableKey{}).(*cacheable)
+	stats := extension.GetApqStats(ctx)
+	if !ok || response == nil || len(response.Errors) > 0 || stats == nil {
+		return response
+	}
+	operationContext := graphql.GetOperationContext(ctx)
+	if operationContext.Operation == nil || operationContext.Operation.Operation != ast.Query {
+		return response
+	}
+
+	visibility := "private"
+	if _, allowListed := c.Public[stats.Hash]; target.shared && allowListed {
+		visibility = "public"
+	}
+	target.header.Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, int(c.MaxAge.Seconds())))
+	target.header.Add("Vary", "Authorization")
+	return response
+}
@@ -0,0 +1,56 @@
+package persisted
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestCacheControl_InterceptResponse(t *testing.T) {
+	const allowListed = "allow-listed-hash"
+	control := CacheControl{MaxAge: time.Minute, Public: map[string]string{allowListed: "{ events(first: 10) { totalCount } }"}}
+
+	tests := []struct {
+		name      string
+		hash      string
+		operation ast.Operation
+		shared    bool
+		errors    bool
+		notAPQ    bool
+		want      string
+	}{
+		{name: "allow listed and anonymous", hash: allowListed, operation: ast.Query, shared: true, want: "public, max-age=60"},
+		{name: "allow listed and authenticated", hash: allowListed, operation: ast.Query, want: "private, max-age=60"},
+		{name: "registered by a client", hash: "registered-hash", operation: ast.Query, shared: true, want: "private, max-age=60"},
+		{name: "a mutation", hash: allowListed, operation: ast.Mutation, shared: true},
+		{name: "a response with errors", hash: allowListed, operation: ast.Query, shared: true, errors: true},
+		{name: "not requested by hash", operation: ast.Query, shared: true, notAPQ: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			operationContext := &graphql.OperationContext{Operation: &ast.OperationDefinition{Operation: tt.operation}}
+			if !tt.notAPQ {
+				operationContext.Stats.SetExtension("APQ", &extension.ApqStats{Hash: tt.hash})
+			}
+			ctx := WithCacheableResponse(graphql.WithOperationContext(context.Background(), operationContext), header, tt.shared)
+
+			control.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+				response := &graphql.Response{Data: []byte(`{}`)}
+				if tt.errors {
+					response.Errors = gqlerror.List{gqlerror.Errorf("failed")}
+				}
+				return response
+			})
+			if got := header.Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
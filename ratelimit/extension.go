@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const ErrRateLimitedCode = apperrors.CodeRateLimited
+
+func init() {
+	errcode.RegisterErrorType(ErrRateLimitedCode, errcode.KindProtocol)
+}
+
+// Extension
+// Checks every root field of an operation against the Limiter before it is executed,
+// this runs after APQ so operations sent by hash are limited just like the others
+type Extension struct {
+	Limiter *Limiter
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = Extension{}
+
+func (e Extension) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	if e.Limiter == nil {
+		return fmt.Errorf("RateLimit.Limiter can not be nil")
+	}
+	return nil
+}
+
+func (e Extension) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	operation := rc.Doc.Operations.ForName(rc.OperationName)
+	if operation == nil {
+		return nil
+	}
+
+	for _, field := range graphql.CollectFields(rc, operation.SelectionSet, nil) {
+		if field.Name == "__typename" {
+			continue
+		}
+		if rejection := e.Limiter.Allow(ctx, field.Name); rejection != nil {
+			err := gqlerror.Errorf("%s", rejection.Error())
+			errcode.Set(err, ErrRateLimitedCode)
+			err.Extensions["retryAfter"] = rejection.RetryAfterSeconds()
+			err.Path = ast.Path{ast.PathName(field.Alias)}
+			return err
+		}
+	}
+	return nil
+}
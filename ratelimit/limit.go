@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnyOperation matches every operation that doesn't have a rule of its own
+const AnyOperation = "*"
+
+// Limit is a token bucket that refills Rate tokens per second and holds at most Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Rule applies Limit to Operation, when Role is empty it applies to every caller
+type Rule struct {
+	Operation string
+	Role      string
+	Limit     Limit
+}
+
+// Rules is searched from the most to the least specific match:
+// operation and role, operation, any operation and role, any operation
+type Rules []Rule
+
+// Match returns the rule that applies to operation for role, ok is false when nothing applies and the call is unlimited
+func (r Rules) Match(operation string, role string) (rule Rule, ok bool) {
+	candidates := []Rule{
+		{Operation: operation, Role: role},
+		{Operation: operation},
+		{Operation: AnyOperation, Role: role},
+		{Operation: AnyOperation},
+	}
+	for _, candidate := range candidates {
+		for _, rule := range r {
+			if rule.Operation == candidate.Operation && rule.Role == candidate.Role {
+				return rule, true
+			}
+		}
+	}
+	return Rule{}, false
+}
+
+// ParseRules reads rules separated by semicolons written as operation[:ROLE]=count/unit[+burst],
+// for example "checkIn=5/m;checkIn:ADMIN=60/m+20;*=300/m". The unit is one of s, m or h and burst defaults to count
+func ParseRules(value string) (Rules, error) {
+	var rules Rules
+	for _, definition := range strings.Split(value, ";") {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+
+		target, limit, found := strings.Cut(definition, "=")
+		if !found {
+			return nil, fmt.Errorf("rate limit %q is missing '='", definition)
+		}
+		operation, role, _ := strings.Cut(strings.TrimSpace(target), ":")
+		if operation == "" {
+			return nil, fmt.Errorf("rate limit %q is missing an operation", definition)
+		}
+
+		parsed, err := ParseLimit(strings.TrimSpace(limit))
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %q: %w", definition, err)
+		}
+		rules = append(rules, Rule{Operation: operation, Role: strings.ToUpper(role), Limit: parsed})
+	}
+	return rules, nil
+}
+
+// ParseLimit reads a single limit written as count/unit[+burst], such as 5/m or 60/m+20
+func ParseLimit(value string) (Limit, error) {
+	value, burstValue, hasBurst := strings.Cut(value, "+")
+	countValue, unit, found := strings.Cut(value, "/")
+	if !found {
+		return Limit{}, fmt.Errorf("expected count/unit")
+	}
+
+	count, err := strconv.Atoi(countValue)
+	if err != nil || count < 1 {
+		return Limit{}, fmt.Errorf("invalid count %q", countValue)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid unit %q", unit)
+	}
+
+	burst := count
+	if hasBurst {
+		burst, err = strconv.Atoi(burstValue)
+		if err != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("invalid burst %q", burstValue)
+		}
+	}
+	return Limit{Rate: float64(count) / period.Seconds(), Burst: burst}, nil
+}
+
+// bucket is the state shared by every Store implementation
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills the bucket up to now and spends one token if there is one,
+// when there isn't retryAfter is how long until the next token is available
+func (b *bucket) take(limit Limit, now time.Time) (allowed bool, retryAfter time.Duration) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Rules
+		wantErr bool
+	}{
+		{name: "empty", value: " ; "},
+		{
+			name:  "every form",
+			value: "checkIn=5/m; checkIn:admin=60/m+20;*=2/s",
+			want: Rules{
+				{Operation: "checkIn", Limit: Limit{Rate: 5.0 / 60, Burst: 5}},
+				{Operation: "checkIn", Role: "ADMIN", Limit: Limit{Rate: 1, Burst: 20}},
+				{Operation: AnyOperation, Limit: Limit{Rate: 2, Burst: 2}},
+			},
+		},
+		{name: "per hour", value: "events=36/h", want: Rules{{Operation: "events", Limit: Limit{Rate: 0.01, Burst: 36}}}},
+		{name: "missing =", value: "checkIn 5/m", wantErr: true},
+		{name: "missing operation", value: ":ADMIN=5/m", wantErr: true},
+		{name: "missing unit", value: "checkIn=5", wantErr: true},
+		{name: "unknown unit", value: "checkIn=5/d", wantErr: true},
+		{name: "zero count", value: "checkIn=0/m", wantErr: true},
+		{name: "invalid burst", value: "checkIn=5/m+x", wantErr: true},
+		{name: "zero burst", value: "checkIn=5/m+0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRules(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRules_Match(t *testing.T) {
+	rules, err := ParseRules("checkIn=5/m;checkIn:ADMIN=60/m;*:SPONSOR=10/m;*=300/m")
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	tests := []struct {
+		operation string
+		role      string
+		want      Rule
+	}{
+		{operation: "checkIn", role: "ADMIN", want: rules[1]},
+		{operation: "checkIn", role: "NORMAL", want: rules[0]},
+		{operation: "checkIn", role: "SPONSOR", want: rules[0]},
+		{operation: "events", role: "SPONSOR", want: rules[2]},
+		{operation: "events", role: "", want: rules[3]},
+	}
+	for _, tt := range tests {
+		if got, ok := rules.Match(tt.operation, tt.role); !ok || got != tt.want {
+			t.Errorf("Match(%q, %q) = %+v, %v, want %+v", tt.operation, tt.role, got, ok, tt.want)
+		}
+	}
+	if _, ok := rules[:2].Match("events", "ADMIN"); ok {
+		t.Error("Match() found a rule for an operation without any")
+	}
+}
+
+func TestBucket_Take(t *testing.T) {
+	start := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 2, Burst: 2}
+	b := &bucket{tokens: 2, updated: start}
+
+	tests := []struct {
+		name           string
+		at             time.Time
+		want           bool
+		wantRetryAfter time.Duration
+	}{
+		{name: "first token of the burst", at: start, want: true},
+		{name: "second token of the burst", at: start, want: true},
+		{name: "burst used up", at: start, wantRetryAfter: 500 * time.Millisecond},
+		{name: "half a token later", at: start.Add(250 * time.Millisecond), wantRetryAfter: 250 * time.Millisecond},
+		{name: "a token later", at: start.Add(500 * time.Millisecond), want: true},
+		// refills no further than the burst
+		{name: "long after", at: start.Add(time.Hour), want: true},
+		{name: "the rest of the burst", at: start.Add(time.Hour), want: true},
+		{name: "the burst used up again", at: start.Add(time.Hour), wantRetryAfter: 500 * time.Millisecond},
+		{name: "a clock going backwards", at: start, wantRetryAfter: 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		allowed, retryAfter := b.take(limit, tt.at)
+		if allowed != tt.want || retryAfter != tt.wantRetryAfter {
+			t.Errorf("%s: take() = %v, %v, want %v, %v", tt.name, allowed, retryAfter, tt.want, tt.wantRetryAfter)
+		}
+	}
+}
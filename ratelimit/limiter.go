@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/gin-gonic/gin"
+)
+
+// Limiter
+// Decides whether a client may run an operation, clients are authenticated users or otherwise their IP
+type Limiter struct {
+	Store Store
+	Rules Rules
+	now   func() time.Time
+}
+
+func NewLimiter(store Store, rules Rules) *Limiter {
+	return &Limiter{Store: store, Rules: rules, now: time.Now}
+}
+
+// Rejection is returned by Allow when the client has run out of tokens
+type Rejection struct {
+	Operation  string
+	RetryAfter time.Duration
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s, retry in %d seconds", r.Operation, r.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds up so clients never retry too early
+func (r *Rejection) RetryAfterSeconds() int {
+	return int(math.Ceil(r.RetryAfter.Seconds()))
+}
+
+// Allow spends a token for operation on behalf of the client in ctx, it returns a *Rejection when the client is limited.
+// A failing store lets the request through, being unable to rate limit shouldn't take the service down with it
+func (l *Limiter) Allow(ctx context.Context, operation string) *Rejection {
+	c, ok := clientFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	rule, ok := l.Rules.Match(operation, c.role)
+	if !ok {
+		return nil
+	}
+
+	key := rule.Operation + ":" + rule.Role + "|" + c.key
+	allowed, retryAfter, err := l.Store.Take(ctx, key, rule.Limit, l.now())
+	if err != nil {
+		slog.WarnContext(ctx, "unable to check rate limit", "key", key, "error", err)
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	rejection := &Rejection{Operation: operation, RetryAfter: retryAfter}
+	c.rejected = rejection
+	return rejection
+}
+
+type clientKey struct{}
+
+type client struct {
+	key  string
+	role string
+	// rejected is set when any check for this request was rejected so the response can be turned into a 429
+	rejected *Rejection
+}
+
+func clientFromContext(ctx context.Context) (*client, bool) {
+	c, ok := ctx.Value(clientKey{}).(*client)
+	return c, ok
+}
+
+// Middleware identifies the client of every request, it has to run after auth.AuthContextMiddleware.
+// Requests that were rejected further down are answered with a 429 and a Retry-After header
+func (l *Limiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cl := &client{key: "ip:" + c.ClientIP()}
+		if claims, ok := identity.FromContext(c.Request.Context()); ok {
+			cl.key = "user:" + claims.UserID
+			cl.role = string(claims.Role)
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), clientKey{}, cl))
+		c.Writer = &rejectingWriter{ResponseWriter: c.Writer, client: cl}
+		c.Next()
+	}
+}
+
+// rejectingWriter swaps whatever status the handler picked for a 429 once the request has been rate limited,
+// gqlgen decides the status of a rejected operation itself and has no way to pick 429
+type rejectingWriter struct {
+	gin.ResponseWriter
+	client *client
+}
+
+func (w *rejectingWriter) WriteHeader(code int) {
+	if rejection := w.client.rejected; rejection != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(rejection.RetryAfterSeconds()))
+		code = http.StatusTooManyRequests
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *rejectingWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *rejectingWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// WriteHeaderNow goes through WriteHeader so a request that never set a status still becomes a 429
+func (w *rejectingWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.WriteHeader(w.Status())
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
@@ -0,0 +1,74 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+	"github.com/KnightHacks/knighthacks_shared/auth"
+	"github.com/KnightHacks/knighthacks_shared/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLimiter_Middleware(t *testing.T) {
+	rules, err := ratelimit.ParseRules("ping=1/h;ping:ADMIN=2/h")
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rules)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err = router.SetTrustedProxies([]string{"10.0.0.1"}); err != nil {
+		t.Fatalf("SetTrustedProxies() error = %v", err)
+	}
+	router.Use(limiter.Middleware())
+	router.GET("/ping", func(c *gin.Context) {
+		if limiter.Allow(c.Request.Context(), "ping") != nil {
+			c.AbortWithStatus(http.StatusTooManyRequests)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	ping := func(remoteAddr string, forwardedFor string, claims *auth.UserClaims) int {
+		request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		request.RemoteAddr = remoteAddr + ":1234"
+		if forwardedFor != "" {
+			request.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		if claims != nil {
+			request = request.WithContext(identity.WithClaims(request.Context(), claims))
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	admin := &auth.UserClaims{UserID: "1", Role: models.RoleAdmin}
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		claims       *auth.UserClaims
+		want         int
+	}{
+		{name: "a client", remoteAddr: "203.0.113.1", want: http.StatusNoContent},
+		{name: "the client again", remoteAddr: "203.0.113.1", want: http.StatusTooManyRequests},
+		{name: "the client claiming another IP", remoteAddr: "203.0.113.1", forwardedFor: "198.51.100.7", want: http.StatusTooManyRequests},
+		{name: "another client", remoteAddr: "203.0.113.2", want: http.StatusNoContent},
+		{name: "a client behind the trusted proxy", remoteAddr: "10.0.0.1", forwardedFor: "198.51.100.7", want: http.StatusNoContent},
+		{name: "the client behind the trusted proxy again", remoteAddr: "10.0.0.1", forwardedFor: "198.51.100.7", want: http.StatusTooManyRequests},
+		// users are limited by id and role wherever they come from
+		{name: "an admin", remoteAddr: "203.0.113.1", claims: admin, want: http.StatusNoContent},
+		{name: "the admin from elsewhere", remoteAddr: "203.0.113.9", claims: admin, want: http.StatusNoContent},
+		{name: "the admin a third time", remoteAddr: "203.0.113.1", claims: admin, want: http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		if got := ping(tt.remoteAddr, tt.forwardedFor, tt.claims); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
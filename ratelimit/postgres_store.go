@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore
+// Shares buckets between every replica, each Take locks the row of its bucket for the length of one short transaction.
+// The rate_limit_buckets table is created by the migrations package.
+type PostgresStore struct {
+	DatabasePool *pgxpool.Pool
+}
+
+func NewPostgresStore(databasePool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{DatabasePool: databasePool}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	var allowed bool
+	var retryAfter time.Duration
+	err := pgx.BeginTxFunc(ctx, s.DatabasePool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "INSERT INTO rate_limit_buckets (key, tokens, updated_at) VALUES ($1, $2, $3) ON CONFLICT (key) DO NOTHING",
+			key, float64(limit.Burst), now)
+		if err != nil {
+			return err
+		}
+
+		var b bucket
+		err = tx.QueryRow(ctx, "SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE", key).Scan(&b.tokens, &b.updated)
+		if err != nil {
+			return err
+		}
+
+		allowed, retryAfter = b.take(limit, now)
+		_, err = tx.Exec(ctx, "UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2 WHERE key = $3", b.tokens, b.updated, key)
+		return err
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed, retryAfter, nil
+}
+
+// DeleteIdle removes buckets that haven't been touched since before, they would be full again by now anyway
+// as long as before is further back than the slowest refill
+func (s *PostgresStore) DeleteIdle(ctx context.Context, before time.Time) (int64, error) {
+	commandTag, err := s.DatabasePool.Exec(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return commandTag.RowsAffected(), nil
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 1, Burst: 1}
+	store := NewMemoryStore()
+
+	if allowed, _, err := store.Take(ctx, "a", limit, start); err != nil || !allowed {
+		t.Fatalf("Take() = %v, %v, want a full bucket to start with", allowed, err)
+	}
+	if allowed, retryAfter, _ := store.Take(ctx, "a", limit, start); allowed || retryAfter != time.Second {
+		t.Errorf("Take() of an empty bucket = %v, %v, want to retry in a second", allowed, retryAfter)
+	}
+	if allowed, _, _ := store.Take(ctx, "b", limit, start); !allowed {
+		t.Error("Take() of another key shared the bucket")
+	}
+	if allowed, _, _ := store.Take(ctx, "a", limit, start.Add(time.Second)); !allowed {
+		t.Error("Take() didn't refill the bucket")
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+
+	store.Take(ctx, "refilled", Limit{Rate: 1, Burst: 1}, start)
+	store.Take(ctx, "slow", Limit{Rate: 1.0 / 3600, Burst: 1}, start)
+	// the next Take after the sweep interval sweeps
+	store.Take(ctx, "new", Limit{Rate: 1, Burst: 1}, start.Add(2*sweepInterval))
+
+	if _, ok := store.buckets["refilled"]; ok {
+		t.Error("a refilled bucket was kept")
+	}
+	if _, ok := store.buckets["slow"]; !ok {
+		t.Error("a bucket that is still refilling was dropped")
+	}
+	if allowed, _, _ := store.Take(ctx, "slow", Limit{Rate: 1.0 / 3600, Burst: 1}, start.Add(2*sweepInterval)); allowed {
+		t.Error("Take() of a swept store forgot a bucket that is still refilling")
+	}
+}
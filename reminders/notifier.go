@@ -0,0 +1,118 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+)
+
+// Notifier
+// Delivers a reminder to its user, an error leaves the reminder unsent so it is tried again
+type Notifier interface {
+	Notify(ctx context.Context, reminder *Reminder) error
+}
+
+// LogNotifier
+// Only logs reminders, for local development and for deployments that don't send them yet
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, reminder *Reminder) error {
+	slog.InfoContext(ctx, "Reminder", "event_id", reminder.EventID, "user_id", reminder.UserID, "starts_at", reminder.StartDate)
+	return nil
+}
+
+// SMTPNotifier
+// Emails reminders as plain text through an SMTP server, the connection is upgraded with STARTTLS when offered
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPNotifier sends from the address from through the server at addr, host:port, logging in with PLAIN
+// authentication unless username is empty
+func NewSMTPNotifier(addr string, username string, password string, from string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{addr: addr, from: from, auth: auth, send: smtp.SendMail}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder *Reminder) error {
+	if strings.ContainsAny(reminder.Email, "\r\n") {
+		return fmt.Errorf("%q is not a valid email address", reminder.Email)
+	}
+	return n.send(n.addr, n.auth, n.from, []string{reminder.Email}, n.message(reminder))
+}
+
+// message is the email for reminder, newlines are taken out of anything that ends up in a header
+func (n *SMTPNotifier) message(reminder *Reminder) []byte {
+	oneLine := strings.NewReplacer("\r", " ", "\n", " ")
+	startsAt := reminder.StartDate.UTC().Format("Mon Jan 2 15:04 MST")
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", n.from)
+	fmt.Fprintf(&message, "To: %s\r\n", reminder.Email)
+	fmt.Fprintf(&message, "Subject: Reminder: %s starts at %s\r\n", oneLine.Replace(reminder.EventName), startsAt)
+	fmt.Fprintf(&message, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&message, "\r\n")
+	fmt.Fprintf(&message, "%s starts at %s in %s.\r\n", reminder.EventName, startsAt, reminder.Location)
+	fmt.Fprintf(&message, "\r\nYou're receiving this because you RSVP'd to or bookmarked this event.\r\n")
+	return message.Bytes()
+}
+
+// WebhookNotifier
+// Posts every reminder as JSON to a single URL, such as a bot that messages users. Requests are signed like webhook
+// deliveries, with webhooks.HeaderTimestamp and webhooks.HeaderSignature, when a secret is set.
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookNotifier(url string, secret string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, secret: secret, client: client, now: time.Now}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder *Reminder) error {
+	body, err := json.Marshal(reminder)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "knighthacks-events-reminders")
+	if n.secret != "" {
+		timestamp := n.now().Unix()
+		request.Header.Set(webhooks.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		request.Header.Set(webhooks.HeaderSignature, webhooks.Sign(n.secret, timestamp, body))
+	}
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("reminder endpoint responded with %s", response.Status)
+	}
+	return nil
+}
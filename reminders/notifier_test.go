@@ -0,0 +1,89 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+)
+
+var workshop = &Reminder{
+	EventID:   "7",
+	EventName: "Intro to Go\r\nBcc: everyone@example.com",
+	Location:  "HEC 101",
+	StartDate: time.Date(2023, time.February, 4, 15, 0, 0, 0, time.UTC),
+	UserID:    "3",
+	Email:     "knight@ucf.edu",
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	notifier := NewSMTPNotifier("smtp.example.com:587", "events", "password", "events@knighthacks.org")
+	var gotAddr string
+	var gotTo []string
+	var gotMessage string
+	notifier.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotTo, gotMessage = addr, to, string(msg)
+		return nil
+	}
+
+	if err := notifier.Notify(context.Background(), workshop); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" || len(gotTo) != 1 || gotTo[0] != "knight@ucf.edu" {
+		t.Errorf("sent to %v through %s", gotTo, gotAddr)
+	}
+	headers, body, _ := strings.Cut(gotMessage, "\r\n\r\n")
+	if want := "Subject: Reminder: Intro to Go  Bcc: everyone@example.com starts at Sat Feb 4 15:00 UTC"; !strings.Contains(headers, want) {
+		t.Errorf("headers = %q, want the subject on a single line %q", headers, want)
+	}
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("headers = %q, the event name added a header", headers)
+	}
+	if !strings.Contains(body, "HEC 101") {
+		t.Errorf("body = %q, want the location", body)
+	}
+
+	invalid := *workshop
+	invalid.Email = "knight@ucf.edu\r\nBcc: everyone@example.com"
+	if err := notifier.Notify(context.Background(), &invalid); err == nil {
+		t.Errorf("Notify() to an address with a newline succeeded")
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var request *http.Request
+	var body []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL, "secret", server.Client())
+	if err := notifier.Notify(context.Background(), workshop); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	timestamp, _ := strconv.ParseInt(request.Header.Get(webhooks.HeaderTimestamp), 10, 64)
+	if !webhooks.Verify("secret", timestamp, body, request.Header.Get(webhooks.HeaderSignature)) {
+		t.Errorf("%s = %q doesn't verify", webhooks.HeaderSignature, request.Header.Get(webhooks.HeaderSignature))
+	}
+	var got Reminder
+	if err := json.Unmarshal(body, &got); err != nil || got.EventID != "7" || got.Email != "knight@ucf.edu" {
+		t.Errorf("body = %s, want the reminder", body)
+	}
+
+	status = http.StatusBadGateway
+	if err := notifier.Notify(context.Background(), workshop); err == nil {
+		t.Errorf("Notify() succeeded although the endpoint responded with %d", status)
+	}
+}
@@ -0,0 +1,81 @@
+package reminders
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// schedulerLockKey is the session level advisory lock the sending replica holds, it spells remind in ASCII
+const schedulerLockKey = 0x72656d696e64
+
+// PostgresStore
+// The Store used in production, reminders go to users with a row in event_rsvps or event_bookmarks and are recorded
+// in sent_reminders. The tables are created by the migrations package.
+type PostgresStore struct {
+	DatabasePool *pgxpool.Pool
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+func NewPostgresStore(databasePool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{DatabasePool: databasePool}
+}
+
+func (s *PostgresStore) WithLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	conn, err := s.DatabasePool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", schedulerLockKey).Scan(&locked); err != nil || !locked {
+		return false, err
+	}
+	defer func() {
+		// ctx may be cancelled by now, and a connection that kept the lock would keep every replica from sending
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", schedulerLockKey); err != nil {
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+	return true, fn(ctx)
+}
+
+func (s *PostgresStore) Due(ctx context.Context, from time.Time, to time.Time, limit int) ([]*Reminder, error) {
+	rows, err := s.DatabasePool.Query(ctx, `SELECT e.id, e.name, e.location, e.start_date, u.id, u.email
+		FROM events e
+		JOIN (SELECT event_id, user_id FROM event_rsvps UNION SELECT event_id, user_id FROM event_bookmarks) interested ON interested.event_id = e.id
+		JOIN users u ON u.id = interested.user_id
+		WHERE e.start_date > $1 AND e.start_date <= $2
+		AND NOT EXISTS (SELECT 1 FROM sent_reminders r WHERE r.event_id = e.id AND r.user_id = u.id AND r.start_date = e.start_date)
+		ORDER BY e.start_date, e.id, u.id
+		LIMIT $3`, from.UTC(), to.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []*Reminder
+	for rows.Next() {
+		var eventID, userID int
+		reminder := &Reminder{}
+		if err = rows.Scan(&eventID, &reminder.EventName, &reminder.Location, &reminder.StartDate, &userID, &reminder.Email); err != nil {
+			return nil, err
+		}
+		reminder.EventID = strconv.Itoa(eventID)
+		reminder.UserID = strconv.Itoa(userID)
+		due = append(due, reminder)
+	}
+	return due, rows.Err()
+}
+
+func (s *PostgresStore) MarkSent(ctx context.Context, reminder *Reminder, sentAt time.Time) error {
+	// a reminder for an event deleted since it was sent has nothing left to record
+	_, err := s.DatabasePool.Exec(ctx, `INSERT INTO sent_reminders (event_id, user_id, start_date, sent_at)
+		SELECT id, $2, $3, $4 FROM events WHERE id = $1
+		ON CONFLICT DO NOTHING`, reminder.EventID, reminder.UserID, reminder.StartDate.UTC(), sentAt)
+	return err
+}
@@ -0,0 +1,108 @@
+// Package reminders tells users that an event they RSVP'd to or bookmarked is about to start. A Scheduler running on
+// every replica looks for events starting within its window, sends one reminder per user through a Notifier and
+// records it so it is never sent twice for the same start, even across restarts. Replicas take turns through a lock
+// in the Store so only one of them sends at a time.
+package reminders
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var sent = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "events",
+	Subsystem: "reminders",
+	Name:      "sent_total",
+	Help:      "Reminders handed to the notifier, by result: sent or failed",
+}, []string{"result"})
+
+// Reminder
+// One user to remind of one event, the start date is part of its identity so a moved event is reminded of again
+type Reminder struct {
+	EventID   string    `json:"eventId"`
+	EventName string    `json:"eventName"`
+	Location  string    `json:"location"`
+	StartDate time.Time `json:"startDate"`
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+}
+
+// Scheduler
+// Sends the reminders a Store reports as due, a reminder that fails is tried again on the next run while its event
+// still hasn't started
+type Scheduler struct {
+	store    Store
+	notifier Notifier
+	// Window is how long before an event starts its reminders go out
+	Window time.Duration
+	// BatchSize is how many reminders are sent per run
+	BatchSize int
+	now       func() time.Time
+}
+
+func NewScheduler(store Store, notifier Notifier, window time.Duration) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		notifier:  notifier,
+		Window:    window,
+		BatchSize: 500,
+		now:       time.Now,
+	}
+}
+
+// Run sends due reminders every interval until ctx is cancelled, a full batch is followed by the next one straight away.
+// interval has to be shorter than Window or events starting between two runs may be missed.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		count, err := s.SendDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Unable to send reminders", "error", err)
+		}
+		if err == nil && count == s.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends one batch of reminders for events starting within Window, it returns how many were sent.
+// Nothing is sent while another replica is sending.
+func (s *Scheduler) SendDue(ctx context.Context) (int, error) {
+	count := 0
+	locked, err := s.store.WithLock(ctx, func(ctx context.Context) error {
+		now := s.now()
+		due, err := s.store.Due(ctx, now, now.Add(s.Window), s.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, reminder := range due {
+			logger := slog.With("event_id", reminder.EventID, "user_id", reminder.UserID, "starts_at", reminder.StartDate)
+			if err = s.notifier.Notify(ctx, reminder); err != nil {
+				logger.WarnContext(ctx, "Unable to send reminder, retrying with the next run", "error", err)
+				sent.WithLabelValues("failed").Inc()
+				continue
+			}
+			sent.WithLabelValues("sent").Inc()
+			// recorded straight away so a crash later in the batch doesn't send this one again
+			if err = s.store.MarkSent(ctx, reminder, s.now()); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err == nil && !locked {
+		slog.DebugContext(ctx, "Another replica is sending reminders")
+	}
+	return count, err
+}
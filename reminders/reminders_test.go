@@ -0,0 +1,78 @@
+package reminders_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/reminders"
+	"github.com/KnightHacks/knighthacks_events/reminders/reminderstest"
+)
+
+// recorder is a Notifier that keeps the event ids it was sent, it fails the ones in fail
+type recorder struct {
+	fail map[string]bool
+	sent []string
+}
+
+func (r *recorder) Notify(ctx context.Context, reminder *reminders.Reminder) error {
+	if r.fail[reminder.EventID] {
+		return errors.New("mail server is down")
+	}
+	r.sent = append(r.sent, reminder.EventID)
+	return nil
+}
+
+func sendDue(t *testing.T, scheduler *reminders.Scheduler, want int) {
+	t.Helper()
+	got, err := scheduler.SendDue(context.Background())
+	if err != nil {
+		t.Fatalf("SendDue() error = %v", err)
+	}
+	if got != want {
+		t.Fatalf("SendDue() sent %d reminders, want %d", got, want)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	store := reminderstest.NewMemoryStore()
+	now := time.Now()
+	store.Add(reminders.Reminder{EventID: "1", UserID: "1", StartDate: now.Add(10 * time.Minute)})
+	store.Add(reminders.Reminder{EventID: "2", UserID: "1", StartDate: now.Add(5 * time.Minute)})
+	store.Add(reminders.Reminder{EventID: "3", UserID: "1", StartDate: now.Add(time.Hour)})
+	store.Add(reminders.Reminder{EventID: "4", UserID: "1", StartDate: now.Add(-time.Minute)})
+
+	notifier := &recorder{fail: map[string]bool{"1": true}}
+	scheduler := reminders.NewScheduler(store, notifier, 15*time.Minute)
+	sendDue(t, scheduler, 1)
+	if want := []string{"2"}; !reflect.DeepEqual(notifier.sent, want) {
+		t.Fatalf("sent %v, want %v", notifier.sent, want)
+	}
+
+	// the failed reminder is tried again, the one that went out isn't
+	notifier.fail = nil
+	sendDue(t, scheduler, 1)
+	sendDue(t, scheduler, 0)
+	if want := []string{"2", "1"}; !reflect.DeepEqual(notifier.sent, want) {
+		t.Errorf("sent %v, want %v", notifier.sent, want)
+	}
+}
+
+func TestScheduler_Locked(t *testing.T) {
+	store := reminderstest.NewMemoryStore()
+	store.Add(reminders.Reminder{EventID: "1", UserID: "1", StartDate: time.Now().Add(time.Minute)})
+	notifier := &recorder{}
+	scheduler := reminders.NewScheduler(store, notifier, 15*time.Minute)
+
+	// another replica holds the lock
+	_, err := store.WithLock(context.Background(), func(ctx context.Context) error {
+		sendDue(t, scheduler, 0)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithLock() error = %v", err)
+	}
+	sendDue(t, scheduler, 1)
+}
@@ -0,0 +1,122 @@
+// Package reminderstest is the test suite every reminders.Store has to pass and a MemoryStore for tests
+package reminderstest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/reminders"
+)
+
+// Seed creates an event starting at startDate and a new user who RSVP'd to it, bookmarked it, both or neither.
+// It returns the reminder the user should get.
+type Seed func(t *testing.T, startDate time.Time, rsvp bool, bookmark bool) reminders.Reminder
+
+// Run checks store against the Store contract, the events are seeded at times no other run uses
+func Run(t *testing.T, store reminders.Store, seed Seed) {
+	ctx := context.Background()
+	start := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(time.Now().Unix()%1_000_000) * time.Hour)
+
+	// due returns the reminders for events of own, in the order Due returned them
+	due := func(t *testing.T, from time.Time, to time.Time, limit int, own ...reminders.Reminder) []reminders.Reminder {
+		t.Helper()
+		got, err := store.Due(ctx, from, to, limit)
+		if err != nil {
+			t.Fatalf("Due() error = %v", err)
+		}
+		var filtered []reminders.Reminder
+		for _, reminder := range got {
+			for _, o := range own {
+				if reminder.EventID == o.EventID {
+					filtered = append(filtered, *reminder)
+				}
+			}
+		}
+		return filtered
+	}
+
+	t.Run("Due", func(t *testing.T) {
+		base := start
+		atFrom := seed(t, base, true, false)
+		soonest := seed(t, base.Add(time.Minute), true, false)
+		atTo := seed(t, base.Add(15*time.Minute), false, true)
+		both := seed(t, base.Add(10*time.Minute), true, true)
+		later := seed(t, base.Add(16*time.Minute), true, false)
+		uninterested := seed(t, base.Add(5*time.Minute), false, false)
+
+		got := due(t, base, base.Add(15*time.Minute), 100, atFrom, soonest, atTo, both, later, uninterested)
+		want := []reminders.Reminder{soonest, both, atTo}
+		if !equal(got, want) {
+			t.Errorf("Due() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Due limit", func(t *testing.T) {
+		base := start.Add(time.Hour)
+		first := seed(t, base.Add(time.Minute), true, false)
+		second := seed(t, base.Add(2*time.Minute), true, false)
+
+		got := due(t, base, base.Add(2*time.Minute), 1, first, second)
+		if !equal(got, []reminders.Reminder{first}) {
+			t.Errorf("Due(limit 1) = %v, want only %v", got, first)
+		}
+	})
+
+	t.Run("MarkSent", func(t *testing.T) {
+		base := start.Add(2 * time.Hour)
+		reminded := seed(t, base.Add(time.Minute), true, false)
+		waiting := seed(t, base.Add(2*time.Minute), true, false)
+
+		for i := 0; i < 2; i++ {
+			if err := store.MarkSent(ctx, &reminded, base); err != nil {
+				t.Fatalf("MarkSent() error = %v", err)
+			}
+		}
+		got := due(t, base, base.Add(time.Hour), 100, reminded, waiting)
+		if !equal(got, []reminders.Reminder{waiting}) {
+			t.Errorf("Due() after MarkSent = %v, want only %v", got, waiting)
+		}
+	})
+
+	t.Run("WithLock", func(t *testing.T) {
+		ran := false
+		locked, err := store.WithLock(ctx, func(ctx context.Context) error {
+			ran = true
+			nested, err := store.WithLock(ctx, func(ctx context.Context) error {
+				t.Errorf("a second WithLock() ran while the lock was held")
+				return nil
+			})
+			if err != nil || nested {
+				t.Errorf("second WithLock() = %v, %v, want false", nested, err)
+			}
+			return nil
+		})
+		if err != nil || !locked || !ran {
+			t.Fatalf("WithLock() = %v, %v and ran = %v, want it to run", locked, err, ran)
+		}
+
+		// the lock is released afterwards
+		if locked, err = store.WithLock(ctx, func(ctx context.Context) error { return nil }); err != nil || !locked {
+			t.Errorf("WithLock() after the first one = %v, %v, want true", locked, err)
+		}
+	})
+}
+
+func equal(got []reminders.Reminder, want []reminders.Reminder) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		g, w := got[i], want[i]
+		if !g.StartDate.Equal(w.StartDate) {
+			return false
+		}
+		g.StartDate, w.StartDate = time.Time{}, time.Time{}
+		if !reflect.DeepEqual(g, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package reminderstest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/reminders"
+)
+
+// MemoryStore
+// A reminders.Store kept in memory for tests, candidates are given to it with Add instead of coming from RSVPs
+type MemoryStore struct {
+	locked     sync.Mutex
+	mu         sync.Mutex
+	candidates []reminders.Reminder
+	sent       map[reminderKey]time.Time
+}
+
+// reminderKey identifies a reminder, like the primary key of sent_reminders
+type reminderKey struct {
+	eventID   string
+	userID    string
+	startDate time.Time
+}
+
+func keyOf(reminder *reminders.Reminder) reminderKey {
+	return reminderKey{eventID: reminder.EventID, userID: reminder.UserID, startDate: reminder.StartDate.UTC()}
+}
+
+var _ reminders.Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sent: map[reminderKey]time.Time{}}
+}
+
+// Add makes reminder a candidate, as if its user had RSVP'd to its event. Adding the same reminder twice has no effect.
+func (s *MemoryStore) Add(reminder reminders.Reminder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, candidate := range s.candidates {
+		if keyOf(&candidate) == keyOf(&reminder) {
+			return
+		}
+	}
+	s.candidates = append(s.candidates, reminder)
+}
+
+// Sent reports whether reminder was marked as sent
+func (s *MemoryStore) Sent(reminder reminders.Reminder) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.sent[keyOf(&reminder)]
+	return ok
+}
+
+func (s *MemoryStore) WithLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	if !s.locked.TryLock() {
+		return false, nil
+	}
+	defer s.locked.Unlock()
+	return true, fn(ctx)
+}
+
+func (s *MemoryStore) Due(ctx context.Context, from time.Time, to time.Time, limit int) ([]*reminders.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*reminders.Reminder
+	for _, candidate := range s.candidates {
+		if !candidate.StartDate.After(from) || candidate.StartDate.After(to) {
+			continue
+		}
+		if _, ok := s.sent[keyOf(&candidate)]; ok {
+			continue
+		}
+		reminder := candidate
+		due = append(due, &reminder)
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].StartDate.Before(due[j].StartDate)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *MemoryStore) MarkSent(ctx context.Context, reminder *reminders.Reminder, sentAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent[keyOf(reminder)] = sentAt
+	return nil
+}
@@ -0,0 +1,27 @@
+package reminderstest_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/reminders"
+	"github.com/KnightHacks/knighthacks_events/reminders/reminderstest"
+)
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	store := reminderstest.NewMemoryStore()
+	lastID := 0
+	reminderstest.Run(t, store, func(t *testing.T, startDate time.Time, rsvp bool, bookmark bool) reminders.Reminder {
+		lastID++
+		id := strconv.Itoa(lastID)
+		reminder := reminders.Reminder{EventID: id, EventName: "Event " + id, Location: "UCF", StartDate: startDate, UserID: id, Email: id + "@knighthacks.org"}
+		if rsvp {
+			store.Add(reminder)
+		}
+		if bookmark {
+			store.Add(reminder)
+		}
+		return reminder
+	})
+}
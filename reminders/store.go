@@ -0,0 +1,18 @@
+package reminders
+
+import (
+	"context"
+	"time"
+)
+
+// Store finds who to remind and remembers who was reminded
+type Store interface {
+	// WithLock runs fn while holding a lock shared by every replica, it returns false without running fn when another
+	// replica holds it
+	WithLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error)
+	// Due returns up to limit reminders for events starting after from and no later than to, soonest first. Users who
+	// RSVP'd and bookmarked get a single reminder, and reminders already marked as sent for that start are left out.
+	Due(ctx context.Context, from time.Time, to time.Time, limit int) ([]*Reminder, error)
+	// MarkSent records reminder so Due doesn't return it again
+	MarkSent(ctx context.Context, reminder *Reminder, sentAt time.Time) error
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+)
+
+// MaxHistogramBuckets is how many buckets a histogram may have, check-ins further apart need wider buckets
+const MaxHistogramBuckets = 10_000
+
+// attendanceCounts
+// What DatabaseRepository and MemoryRepository count for the attendance stats, put together the same way by both
+type attendanceCounts struct {
+	// events ordered by start date, without their histograms and conversion rates
+	events []*model.EventAttendanceStats
+	// buckets holds the check-ins per bucket and event id, a bucket is the unix time of its start divided by its width
+	buckets map[string]map[int64]int
+	// frequency holds how many attendees came to how many events
+	frequency map[int]int
+}
+
+// event finishes the stats of the only event that was counted
+func (c *attendanceCounts) event(bucket time.Duration) (*model.EventAttendanceStats, error) {
+	stats := c.events[0]
+	stats.ConversionRate = conversionRate(stats.RsvpsAttended, stats.Rsvps)
+	var err error
+	if stats.Histogram, err = histogram(c.buckets[stats.EventID], bucket); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// hackathon finishes the stats of every event and adds them up
+func (c *attendanceCounts) hackathon(hackathonID string, bucket time.Duration) (*model.HackathonAttendanceStats, error) {
+	stats := &model.HackathonAttendanceStats{
+		HackathonID: hackathonID,
+		Frequency:   []*model.AttendanceFrequency{},
+		Events:      make([]*model.EventAttendanceStats, 0, len(c.events)),
+	}
+	total := map[int64]int{}
+	var err error
+	for _, event := range c.events {
+		event.ConversionRate = conversionRate(event.RsvpsAttended, event.Rsvps)
+		if event.Histogram, err = histogram(c.buckets[event.EventID], bucket); err != nil {
+			return nil, err
+		}
+		stats.Events = append(stats.Events, event)
+
+		stats.CheckIns += event.CheckIns
+		stats.Rsvps += event.Rsvps
+		stats.RsvpsAttended += event.RsvpsAttended
+		for start, checkIns := range c.buckets[event.EventID] {
+			total[start] += checkIns
+		}
+	}
+	stats.ConversionRate = conversionRate(stats.RsvpsAttended, stats.Rsvps)
+	if stats.Histogram, err = histogram(total, bucket); err != nil {
+		return nil, err
+	}
+
+	for events, attendees := range c.frequency {
+		stats.Frequency = append(stats.Frequency, &model.AttendanceFrequency{Events: events, Attendees: attendees})
+		stats.UniqueAttendees += attendees
+		if events > 1 {
+			stats.RepeatAttendees += attendees
+		}
+	}
+	sort.Slice(stats.Frequency, func(i, j int) bool {
+		return stats.Frequency[i].Events < stats.Frequency[j].Events
+	})
+	return stats, nil
+}
+
+func conversionRate(attended int, rsvps int) float64 {
+	if rsvps == 0 {
+		return 0
+	}
+	return float64(attended) / float64(rsvps)
+}
+
+// histogram turns counts per bucket into every bucket from the first to the last, empty ones included. It fails when
+// that would be more than MaxHistogramBuckets.
+func histogram(counts map[int64]int, bucket time.Duration) ([]*model.AttendanceBucket, error) {
+	buckets := []*model.AttendanceBucket{}
+	if len(counts) == 0 {
+		return buckets, nil
+	}
+	first, last := int64(math.MaxInt64), int64(math.MinInt64)
+	for start := range counts {
+		if start < first {
+			first = start
+		}
+		if start > last {
+			last = start
+		}
+	}
+
+	if last-first >= MaxHistogramBuckets {
+		return nil, apperrors.Invalid("bucketMinutes", fmt.Sprintf("the check-ins span more than %d buckets of %d minutes, use wider buckets", MaxHistogramBuckets, int(bucket/time.Minute)))
+	}
+	seconds := int64(bucket / time.Second)
+	for start := first; start <= last; start++ {
+		buckets = append(buckets, &model.AttendanceBucket{Start: time.Unix(start*seconds, 0).UTC(), CheckIns: counts[start]})
+	}
+	return buckets, nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+)
+
+func TestHistogram(t *testing.T) {
+	got, err := histogram(map[int64]int{10: 2, 10 + MaxHistogramBuckets - 1: 1}, time.Minute)
+	if err != nil {
+		t.Fatalf("histogram() of %d buckets error = %v", MaxHistogramBuckets, err)
+	}
+	if len(got) != MaxHistogramBuckets || got[0].CheckIns != 2 || got[1].CheckIns != 0 || got[len(got)-1].CheckIns != 1 {
+		t.Errorf("histogram() = %d buckets, want %d with the empty ones in between", len(got), MaxHistogramBuckets)
+	}
+	if !got[0].Start.Equal(time.Unix(10*60, 0)) {
+		t.Errorf("first bucket starts at %v, want %v", got[0].Start, time.Unix(10*60, 0).UTC())
+	}
+
+	if _, err = histogram(map[int64]int{10: 2, 10 + MaxHistogramBuckets: 1}, time.Minute); apperrors.CodeOf(err) != apperrors.CodeValidationFailed {
+		t.Errorf("histogram() of %d buckets error = %v, want %s", MaxHistogramBuckets+1, err, apperrors.CodeValidationFailed)
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// ttlCache
+// A size bounded LRU where every entry also expires after ttl, safe for concurrent use
+type ttlCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[K]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+func newTTLCache[K comparable, V any](ttl time.Duration, size int) *ttlCache[K, V] {
+	return &ttlCache[K, V]{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[K]*list.Element, size),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *ttlCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := element.Value.(*cacheEntry[K, V])
+	if c.now().After(entry.expires) {
+		c.removeElement(element)
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set stores value under key and returns how many entries had to be evicted to make room
+func (c *ttlCache[K, V]) Set(key K, value V) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry[K, V])
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(element)
+		return 0
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry[K, V]{key: key, value: value, expires: expires})
+
+	evicted := 0
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+		evicted++
+	}
+	return evicted
+}
+
+func (c *ttlCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+func (c *ttlCache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[K]*list.Element, c.size)
+	c.order.Init()
+}
+
+func (c *ttlCache[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry[K, V]).key)
+}
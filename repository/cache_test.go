@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCache_Expiry(t *testing.T) {
+	now := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	cache := newTTLCache[string, int](time.Minute, 10)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", 1)
+	now = now.Add(time.Minute)
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Errorf("Get() at the ttl = %d, %v, want 1, true", value, ok)
+	}
+	now = now.Add(time.Nanosecond)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Get() after the ttl found the entry")
+	}
+	if len(cache.entries) != 0 || cache.order.Len() != 0 {
+		t.Errorf("an expired entry is kept after Get(), %d entries", len(cache.entries))
+	}
+
+	// setting again restarts the ttl
+	cache.Set("b", 1)
+	now = now.Add(50 * time.Second)
+	cache.Set("b", 2)
+	now = now.Add(50 * time.Second)
+	if value, ok := cache.Get("b"); !ok || value != 2 {
+		t.Errorf("Get() of a renewed entry = %d, %v, want 2, true", value, ok)
+	}
+}
+
+func TestTTLCache_Eviction(t *testing.T) {
+	cache := newTTLCache[string, int](time.Minute, 2)
+
+	if evicted := cache.Set("a", 1) + cache.Set("b", 2); evicted != 0 {
+		t.Errorf("Set() evicted %d entries below the size", evicted)
+	}
+	// a is used more recently than b, which goes first
+	cache.Get("a")
+	if evicted := cache.Set("c", 3); evicted != 1 {
+		t.Errorf("Set() evicted %d entries, want 1", evicted)
+	}
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Get() found the least recently used entry")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if value, ok := cache.Get(key); !ok || value != want {
+			t.Errorf("Get(%q) = %d, %v, want %d, true", key, value, ok, want)
+		}
+	}
+	if evicted := cache.Set("c", 4); evicted != 0 {
+		t.Errorf("Set() of an existing key evicted %d entries", evicted)
+	}
+}
+
+func TestTTLCache_DeleteAndPurge(t *testing.T) {
+	cache := newTTLCache[string, int](time.Minute, 10)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	cache.Delete("a")
+	cache.Delete("missing")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Get() found a deleted entry")
+	}
+	if _, ok := cache.Get("b"); !ok {
+		t.Error("Delete() removed another entry")
+	}
+	cache.Purge()
+	if _, ok := cache.Get("b"); ok || cache.order.Len() != 0 {
+		t.Error("Purge() left entries behind")
+	}
+}
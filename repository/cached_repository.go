@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// CacheInvalidationChannel is the postgres channel replicas use to tell each other an event changed,
+// the payload is the id of the changed event or empty when only the lists are stale
+const CacheInvalidationChannel = "events_cache_invalidation"
+
+var cacheOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "events",
+	Subsystem: "cache",
+	Name:      "operations_total",
+	Help:      "Events cache lookups and maintenance, by result: hit, miss, eviction or invalidation",
+}, []string{"result"})
+
+type eventsPageKey struct {
+	first int
+	after string
+}
+
+type eventsPage struct {
+	events []*model.Event
+	total  int
+}
+
+// CachedRepository
+// Decorates another Repository with an in memory read-through cache for GetEvent and GetEvents,
+// mutations go straight through and invalidate the cache on every replica via NOTIFY
+type CachedRepository struct {
+	Repository
+	pool *pgxpool.Pool
+
+	events *ttlCache[string, model.Event]
+	pages  *ttlCache[eventsPageKey, eventsPage]
+
+	// generation is bumped on every invalidation so reads that raced with a mutation are not stored
+	generation atomic.Uint64
+}
+
+// NewCachedRepository wraps repository, pool may be nil in which case invalidations stay local to this replica
+func NewCachedRepository(repository Repository, pool *pgxpool.Pool, ttl time.Duration, size int) *CachedRepository {
+	return &CachedRepository{
+		Repository: repository,
+		pool:       pool,
+		events:     newTTLCache[string, model.Event](ttl, size),
+		pages:      newTTLCache[eventsPageKey, eventsPage](ttl, size),
+	}
+}
+
+func (r *CachedRepository) GetEvent(ctx context.Context, id string) (*model.Event, error) {
+	if event, ok := r.events.Get(id); ok {
+		cacheOperations.WithLabelValues("hit").Inc()
+		return &event, nil
+	}
+	cacheOperations.WithLabelValues("miss").Inc()
+
+	generation := r.generation.Load()
+	event, err := r.Repository.GetEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.storeEvent(generation, event)
+	return event, nil
+}
+
+func (r *CachedRepository) GetEventsByIDs(ctx context.Context, ids []string) ([]*model.Event, error) {
+	events := make([]*model.Event, 0, len(ids))
+	missing := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if event, ok := r.events.Get(id); ok {
+			cacheOperations.WithLabelValues("hit").Inc()
+			events = append(events, &event)
+			continue
+		}
+		cacheOperations.WithLabelValues("miss").Inc()
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return events, nil
+	}
+
+	generation := r.generation.Load()
+	fetched, err := r.Repository.GetEventsByIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range fetched {
+		r.storeEvent(generation, event)
+	}
+	return append(events, fetched...), nil
+}
+
+func (r *CachedRepository) GetEvents(ctx context.Context, first int, after string) ([]*model.Event, int, error) {
+	key := eventsPageKey{first: first, after: after}
+	if page, ok := r.pages.Get(key); ok {
+		cacheOperations.WithLabelValues("hit").Inc()
+		return copyEvents(page.events), page.total, nil
+	}
+	cacheOperations.WithLabelValues("miss").Inc()
+
+	generation := r.generation.Load()
+	events, total, err := r.Repository.GetEvents(ctx, first, after)
+	if err != nil {
+		return nil, 0, err
+	}
+	if r.generation.Load() == generation {
+		cacheOperations.WithLabelValues("eviction").Add(float64(r.pages.Set(key, eventsPage{events: copyEvents(events), total: total})))
+	}
+	return events, total, nil
+}
+
+func (r *CachedRepository) CreateEvent(ctx context.Context, input *model.NewEvent) (*model.Event, error) {
+	event, err := r.Repository.CreateEvent(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, "")
+	return event, nil
+}
+
+func (r *CachedRepository) UpdateEvent(ctx context.Context, id string, input *model.UpdatedEvent) (*model.Event, error) {
+	event, err := r.Repository.UpdateEvent(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	r.invalidate(ctx, id)
+	return event, nil
+}
+
+func (r *CachedRepository) DeleteEvent(ctx context.Context, id string) (bool, error) {
+	deleted, err := r.Repository.DeleteEvent(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	r.invalidate(ctx, id)
+	return deleted, nil
+}
+
+// Invalidate drops the cached event with id along with every cached list, an empty id only drops the lists
+func (r *CachedRepository) Invalidate(id string) {
+	r.generation.Add(1)
+	if id != "" {
+		r.events.Delete(id)
+	}
+	r.pages.Purge()
+	cacheOperations.WithLabelValues("invalidation").Inc()
+}
+
+// Listen applies invalidations published by other replicas until ctx is cancelled,
+// the connection is re-established if it drops and everything is purged since notifications may have been missed
+func (r *CachedRepository) Listen(ctx context.Context) {
+	if r.pool == nil {
+		return
+	}
+	for {
+		err := r.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.WarnContext(ctx, "Events cache listener stopped, reconnecting", "error", err)
+		r.Invalidate("")
+		r.events.Purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (r *CachedRepository) listen(ctx context.Context) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+CacheInvalidationChannel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		r.Invalidate(notification.Payload)
+	}
+}
+
+// invalidate clears the local cache straight away and then tells the other replicas,
+// failing to notify is logged rather than failing a mutation that already succeeded
+func (r *CachedRepository) invalidate(ctx context.Context, id string) {
+	r.Invalidate(id)
+	if r.pool == nil {
+		return
+	}
+	if _, err := r.pool.Exec(ctx, "SELECT pg_notify($1, $2)", CacheInvalidationChannel, id); err != nil {
+		slog.ErrorContext(ctx, "Unable to publish events cache invalidation", "id", id, "error", err)
+	}
+}
+
+func (r *CachedRepository) storeEvent(generation uint64, event *model.Event) {
+	if r.generation.Load() != generation {
+		return
+	}
+	cacheOperations.WithLabelValues("eviction").Add(float64(r.events.Set(event.ID, *event)))
+}
+
+// copyEvents makes sure callers can never modify what is stored in the cache
+func copyEvents(events []*model.Event) []*model.Event {
+	copied := make([]*model.Event, len(events))
+	for i, event := range events {
+		e := *event
+		copied[i] = &e
+	}
+	return copied
+}
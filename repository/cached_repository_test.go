@@ -0,0 +1,85 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+// counting counts the reads that get past the cache
+type counting struct {
+	*repository.MemoryRepository
+	reads int
+}
+
+func (c *counting) GetEvent(ctx context.Context, id string) (*model.Event, error) {
+	c.reads++
+	return c.MemoryRepository.GetEvent(ctx, id)
+}
+
+func (c *counting) GetEvents(ctx context.Context, first int, after string) ([]*model.Event, int, error) {
+	c.reads++
+	return c.MemoryRepository.GetEvents(ctx, first, after)
+}
+
+func TestCachedRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	next := &counting{MemoryRepository: repository.NewMemoryRepository()}
+	repo := repository.NewCachedRepository(next, nil, time.Minute, 100)
+	read := func(wantReads int) {
+		t.Helper()
+		if _, err := repo.GetEvent(ctx, "1"); err != nil && !errors.Is(err, repository.EventNotFound) {
+			t.Fatalf("GetEvent() error = %v", err)
+		}
+		if _, _, err := repo.GetEvents(ctx, 10, "0"); err != nil {
+			t.Fatalf("GetEvents() error = %v", err)
+		}
+		if next.reads != wantReads {
+			t.Errorf("reads = %d, want %d", next.reads, wantReads)
+		}
+	}
+
+	if _, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: "Opening"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	read(2)
+	read(2)
+
+	name := "Opening Ceremony"
+	if _, err := repo.UpdateEvent(ctx, "1", &model.UpdatedEvent{Name: &name}); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	read(4)
+	if event, _ := repo.GetEvent(ctx, "1"); event.Name != name {
+		t.Errorf("GetEvent() after UpdateEvent() name = %q, want %q", event.Name, name)
+	}
+	if events, _, _ := repo.GetEvents(ctx, 10, "0"); events[0].Name != name {
+		t.Errorf("GetEvents() after UpdateEvent() name = %q, want %q", events[0].Name, name)
+	}
+
+	if _, err := repo.DeleteEvent(ctx, "1"); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if _, err := repo.GetEvent(ctx, "1"); !errors.Is(err, repository.EventNotFound) {
+		t.Errorf("GetEvent() after DeleteEvent() error = %v, want %v", err, repository.EventNotFound)
+	}
+	if events, total, _ := repo.GetEvents(ctx, 10, "0"); len(events) != 0 || total != 0 {
+		t.Errorf("GetEvents() after DeleteEvent() = %v, %d, want nothing", events, total)
+	}
+
+	// invalidations published by another replica
+	if _, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: "Workshop"}); err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	repo.GetEvent(ctx, "2")
+	reads := next.reads
+	repo.Invalidate("2")
+	repo.GetEvent(ctx, "2")
+	if next.reads != reads+1 {
+		t.Errorf("GetEvent() after Invalidate() was served from the cache")
+	}
+}
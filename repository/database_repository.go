@@ -3,21 +3,39 @@ package repository
 import (
 	"context"
 	"errors"
+	"github.com/KnightHacks/knighthacks_events/apperrors"
 	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/outbox"
 	"github.com/KnightHacks/knighthacks_shared/database"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"strconv"
 	"time"
 )
 
 var (
-	EventAlreadyExists = errors.New("event with id already exists")
-	EventNotFound      = errors.New("event was not found")
+	EventAlreadyExists = apperrors.New(apperrors.CodeConflict, "event with id already exists")
+	EventNotFound      = apperrors.New(apperrors.CodeNotFound, "event was not found")
+	EmptyEventUpdate   = apperrors.Invalid("input", "empty event field")
+	HackathonNotFound  = apperrors.Invalid("input.hackathonId", "hackathon was not found")
+	AlreadyCheckedIn   = apperrors.New(apperrors.CodeConflict, "already checked in to this event")
+	// EmptyPointsAdjustment is the points adjustment counterpart of EmptyEventUpdate
+	EmptyPointsAdjustment    = apperrors.Invalid("input.points", "an adjustment must change the points")
+	UserNotFound             = apperrors.Invalid("input.userId", "user was not found")
+	NotCheckedIn             = apperrors.New(apperrors.CodeForbidden, "only attendees who checked in can give feedback")
+	FeedbackAlreadySubmitted = apperrors.New(apperrors.CodeConflict, "feedback was already given for this event")
+)
+
+// SQLSTATEs Postgres reports when a referenced row doesn't exist and when a key is taken
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
 )
 
 // DatabaseRepository
-// Implements the Repository interface's functions
+// Implements the Repository interface's functions, its tables are created by the migrations package.
+// Every mutation writes an outbox message in the same transaction, outbox.Relay publishes them.
 type DatabaseRepository struct {
 	DatabasePool *pgxpool.Pool
 }
@@ -28,61 +46,70 @@ func NewDatabaseRepository(databasePool *pgxpool.Pool) *DatabaseRepository {
 	}
 }
 
-/*
-create table events
-(
-
-	id           serial,
-	hackathon_id integer   not null,
-	location     varchar   not null,
-	start_date   timestamp not null,
-	end_date     timestamp not null,
-	name         varchar   not null,
-	description  varchar   not null,
-	constraint events_pk
-	    primary key (id),
-	constraint events_hackathons_id_fk
-	    foreign key (hackathon_id) references hackathons
-
-);
-*/
 func (r *DatabaseRepository) CreateEvent(ctx context.Context, input *model.NewEvent) (*model.Event, error) {
-	var eventIdInt int
-	err := r.DatabasePool.QueryRow(ctx, "INSERT INTO events (hackathon_id, location, start_date, end_date, name, description) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
-		input.HackathonID,
-		input.Location,
-		input.StartDate,
-		input.EndDate,
-		input.Name,
-		input.Description,
-	).Scan(&eventIdInt)
+	var event *model.Event
+	err := pgx.BeginTxFunc(ctx, r.DatabasePool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		var eventIdInt int
+		var points int
+		if input.Points != nil {
+			points = *input.Points
+		}
+		err := tx.QueryRow(ctx, "INSERT INTO events (hackathon_id, location, start_date, end_date, name, description, points) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+			input.HackathonID,
+			input.Location,
+			input.StartDate,
+			input.EndDate,
+			input.Name,
+			input.Description,
+			points,
+		).Scan(&eventIdInt)
+		if err != nil {
+			return err
+		}
+
+		event = &model.Event{
+			ID:          strconv.Itoa(eventIdInt),
+			Location:    input.Location,
+			StartDate:   input.StartDate,
+			EndDate:     input.EndDate,
+			Name:        input.Name,
+			Description: input.Description,
+			Points:      points,
+		}
+		return outbox.Insert(ctx, tx, event.ID, outbox.TopicEventCreated, event)
+	})
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return nil, HackathonNotFound
+		}
 		return nil, err
 	}
-
-	return &model.Event{
-		ID:          strconv.Itoa(eventIdInt),
-		Location:    input.Location,
-		StartDate:   input.StartDate,
-		EndDate:     input.EndDate,
-		Name:        input.Name,
-		Description: input.Description,
-	}, nil
+	return event, nil
 }
 
 func (r *DatabaseRepository) DeleteEvent(ctx context.Context, id string) (bool, error) {
+	if !isEventID(id) {
+		return false, EventNotFound
+	}
 
-	// removes event
-	commandTag, err := r.DatabasePool.Exec(ctx, "DELETE FROM events WHERE id = $1", id)
+	err := pgx.BeginTxFunc(ctx, r.DatabasePool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		// removes event
+		commandTag, err := tx.Exec(ctx, "DELETE FROM events WHERE id = $1", id)
 
-	// checks if there is an error
+		// checks if there is an error
+		if err != nil {
+			return err
+		}
+		// checking to see if there is 1 row affected for deleted events if not there is an issue
+		if commandTag.RowsAffected() != 1 {
+			return EventNotFound
+		}
+		return outbox.Insert(ctx, tx, id, outbox.TopicEventDeleted, map[string]string{"id": id})
+	})
 	if err != nil {
 		return false, err
 	}
-	// checking to see if there is 1 row affected for deleted events if not there is an issue
-	if commandTag.RowsAffected() != 1 {
-		return false, EventNotFound
-	}
 
 	// if the above conditions dont execute everything is good
 	return true, nil
@@ -93,9 +120,12 @@ func (r *DatabaseRepository) GetEvent(ctx context.Context, id string) (*model.Ev
 }
 
 func (r *DatabaseRepository) GetEventWithQueryable(ctx context.Context, id string, queryable database.Queryable) (*model.Event, error) {
+	if !isEventID(id) {
+		return nil, EventNotFound
+	}
 	var event model.Event
-	err := queryable.QueryRow(ctx, "SELECT id, location, start_date, end_date, name, description FROM events WHERE id = $1", id).Scan(&event.ID, &event.Location,
-		&event.StartDate, &event.EndDate, &event.Name, &event.Description)
+	err := queryable.QueryRow(ctx, "SELECT id, location, start_date, end_date, name, description, points FROM events WHERE id = $1", id).Scan(&event.ID, &event.Location,
+		&event.StartDate, &event.EndDate, &event.Name, &event.Description, &event.Points)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -107,10 +137,38 @@ func (r *DatabaseRepository) GetEventWithQueryable(ctx context.Context, id strin
 	return &event, err
 }
 
+// GetEventsByIDs looks up every id in a single query, ids that don't exist are simply missing from the result
+// and the order of the returned events is not guaranteed to match ids
+func (r *DatabaseRepository) GetEventsByIDs(ctx context.Context, ids []string) ([]*model.Event, error) {
+	intIds := eventIntIDs(ids)
+	events := make([]*model.Event, 0, len(intIds))
+	if len(intIds) == 0 {
+		return events, nil
+	}
+
+	rows, err := r.DatabasePool.Query(ctx, "SELECT id, location, start_date, end_date, name, description, points FROM events WHERE id = ANY($1)", intIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event model.Event
+		if err = rows.Scan(&event.ID, &event.Location, &event.StartDate, &event.EndDate, &event.Name, &event.Description, &event.Points); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
 // UpdateEvent works where it checks to see if fields are nil or empty strings then it'll call the helper functions made
 func (r *DatabaseRepository) UpdateEvent(ctx context.Context, id string, input *model.UpdatedEvent) (*model.Event, error) {
-	if input.Name == nil && input.StartDate == nil && input.EndDate == nil && input.Description == nil && input.Location == nil {
-		return nil, errors.New("empty event field")
+	if input.Name == nil && input.StartDate == nil && input.EndDate == nil && input.Description == nil && input.Location == nil && input.Points == nil {
+		return nil, EmptyEventUpdate
+	}
+	if !isEventID(id) {
+		return nil, EventNotFound
 	}
 	var event *model.Event
 	var err error
@@ -145,11 +203,17 @@ func (r *DatabaseRepository) UpdateEvent(ctx context.Context, id string, input *
 				return err
 			}
 		}
+		if input.Points != nil {
+			err := r.UpdatePoints(ctx, id, *input.Points, tx)
+			if err != nil {
+				return err
+			}
+		}
 		event, err = r.GetEventWithQueryable(ctx, id, tx)
 		if err != nil {
 			return err
 		}
-		return nil
+		return outbox.Insert(ctx, tx, id, outbox.TopicEventUpdated, event)
 	})
 	if err != nil {
 		return nil, err
@@ -210,25 +274,44 @@ func (r *DatabaseRepository) UpdateLocation(ctx context.Context, id string, loca
 	return nil
 }
 
+func (r *DatabaseRepository) UpdatePoints(ctx context.Context, id string, points int, tx database.Queryable) error {
+	commandTag, err := tx.Exec(ctx, "UPDATE events SET points = $1 WHERE id = $2", points, id)
+	if err != nil {
+		return err
+	}
+	if commandTag.RowsAffected() != 1 {
+		return EventNotFound
+	}
+	return nil
+}
+
+// GetEvents returns up to first events with an id greater than after in ascending id order, along with the total
+// number of events. Both are read from the same snapshot so the total matches the page.
 func (r *DatabaseRepository) GetEvents(ctx context.Context, first int, after string) ([]*model.Event, int, error) {
+	// a negative first is an empty page rather than a panic or a LIMIT error
+	first = max(first, 0)
 	events := make([]*model.Event, 0, first)
 	var total int
-	err := pgx.BeginTxFunc(ctx, r.DatabasePool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		rows, err := r.DatabasePool.Query(ctx, "SELECT id, location, start_date, end_date, name, description FROM events WHERE id > $1 ORDER BY `id` DESC LIMIT $2", after, first)
+	err := pgx.BeginTxFunc(ctx, r.DatabasePool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM events").Scan(&total); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, "SELECT id, location, start_date, end_date, name, description, points FROM events WHERE id > $1 ORDER BY id LIMIT $2", after, first)
 		if err != nil {
 			return err
 		}
+		defer rows.Close()
 
 		for rows.Next() {
 			var event model.Event
 
-			if err = rows.Scan(&event.ID, &event.Location, &event.StartDate, &event.EndDate, &event.Name, &event.Description); err != nil {
+			if err = rows.Scan(&event.ID, &event.Location, &event.StartDate, &event.EndDate, &event.Name, &event.Description, &event.Points); err != nil {
 				return err
 			}
 			events = append(events, &event)
 		}
-
-		return r.DatabasePool.QueryRow(ctx, "SELECT COUNT(*) FROM events").Scan(&total)
+		return rows.Err()
 	})
 
 	if err != nil {
@@ -237,3 +320,399 @@ func (r *DatabaseRepository) GetEvents(ctx context.Context, first int, after str
 
 	return events, total, nil
 }
+
+func (r *DatabaseRepository) SetRSVP(ctx context.Context, eventID string, userID string, going bool) error {
+	return r.setInterest(ctx, "event_rsvps", eventID, userID, going)
+}
+
+func (r *DatabaseRepository) SetBookmark(ctx context.Context, eventID string, userID string, saved bool) error {
+	return r.setInterest(ctx, "event_bookmarks", eventID, userID, saved)
+}
+
+// setInterest adds or removes the row of userID in table, which is event_rsvps, event_bookmarks or event_hosts.
+// Both are idempotent so repeating a call changes nothing.
+func (r *DatabaseRepository) setInterest(ctx context.Context, table string, eventID string, userID string, set bool) error {
+	if !isEventID(eventID) {
+		return EventNotFound
+	}
+	if !set {
+		_, err := r.DatabasePool.Exec(ctx, "DELETE FROM "+table+" WHERE event_id = $1 AND user_id = $2", eventID, userID)
+		return err
+	}
+
+	_, err := r.DatabasePool.Exec(ctx, "INSERT INTO "+table+" (event_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", eventID, userID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == table+"_events_id_fk" {
+		return EventNotFound
+	}
+	return err
+}
+
+// CheckIn adds a row to event_attendance and writes an attendance.checked_in outbox message with it
+func (r *DatabaseRepository) CheckIn(ctx context.Context, eventID string, userID string, at time.Time) (*model.Attendance, error) {
+	if !isEventID(eventID) {
+		return nil, EventNotFound
+	}
+
+	attendance := &model.Attendance{EventID: eventID, UserID: userID, Time: at.UTC().Round(time.Microsecond)}
+	err := pgx.BeginTxFunc(ctx, r.DatabasePool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "INSERT INTO event_attendance (event_id, user_id, time) VALUES ($1, $2, $3)", eventID, userID, attendance.Time)
+		if err != nil {
+			return err
+		}
+		return outbox.Insert(ctx, tx, eventID, outbox.TopicAttendanceCheckedIn, attendance)
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == uniqueViolation && pgErr.ConstraintName == "event_attendance_pk":
+			return nil, AlreadyCheckedIn
+		case pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == "event_attendance_events_id_fk":
+			return nil, EventNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// EventAttendance counts within a read only snapshot of the event's hackathon, which returning attendees need
+func (r *DatabaseRepository) EventAttendance(ctx context.Context, eventID string, bucket time.Duration) (*model.EventAttendanceStats, error) {
+	if !isEventID(eventID) {
+		return nil, EventNotFound
+	}
+
+	var stats *model.EventAttendanceStats
+	err := pgx.BeginTxFunc(ctx, r.DatabasePool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		var hackathonID string
+		err := tx.QueryRow(ctx, "SELECT hackathon_id FROM events WHERE id = $1", eventID).Scan(&hackathonID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return EventNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		counts, err := countAttendance(ctx, tx, hackathonID, &eventID, bucket)
+		if err != nil {
+			return err
+		}
+		stats, err = counts.event(bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// HackathonAttendance counts within a read only snapshot so the totals match the events
+func (r *DatabaseRepository) HackathonAttendance(ctx context.Context, hackathonID string, bucket time.Duration) (*model.HackathonAttendanceStats, error) {
+	// hackathon ids are serials too, anything else has no events
+	if !isEventID(hackathonID) {
+		return (&attendanceCounts{}).hackathon(hackathonID, bucket)
+	}
+
+	var stats *model.HackathonAttendanceStats
+	err := pgx.BeginTxFunc(ctx, r.DatabasePool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		counts, err := countAttendance(ctx, tx, hackathonID, nil, bucket)
+		if err != nil {
+			return err
+		}
+		stats, err = counts.hackathon(hackathonID, bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// countAttendance aggregates event_attendance of the hackathon in Postgres, only for eventID unless it is nil.
+// Returning attendees are numbered with a window over the whole hackathon either way.
+func countAttendance(ctx context.Context, tx pgx.Tx, hackathonID string, eventID *string, bucket time.Duration) (*attendanceCounts, error) {
+	counts := &attendanceCounts{buckets: map[string]map[int64]int{}, frequency: map[int]int{}}
+
+	rows, err := tx.Query(ctx, `
+		SELECT e.id, e.name, e.start_date, count(a.user_id), rsvps.total,
+		       count(a.user_id) FILTER (WHERE a.rsvpd), count(a.user_id) FILTER (WHERE a.visit > 1)
+		FROM events e
+		    LEFT JOIN (
+		        SELECT a.event_id, a.user_id,
+		               EXISTS (SELECT 1 FROM event_rsvps r WHERE r.event_id = a.event_id AND r.user_id = a.user_id) AS rsvpd,
+		               row_number() OVER (PARTITION BY a.user_id ORDER BY a.time, a.event_id) AS visit
+		        FROM event_attendance a
+		            JOIN events h ON h.id = a.event_id
+		        WHERE h.hackathon_id = $1
+		    ) a ON a.event_id = e.id
+		    CROSS JOIN LATERAL (SELECT count(*) AS total FROM event_rsvps r WHERE r.event_id = e.id) rsvps
+		WHERE e.hackathon_id = $1 AND ($2::integer IS NULL OR e.id = $2)
+		GROUP BY e.id, rsvps.total
+		ORDER BY e.start_date, e.id`, hackathonID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event model.EventAttendanceStats
+		if err = rows.Scan(&event.EventID, &event.Name, &event.StartDate, &event.CheckIns, &event.Rsvps, &event.RsvpsAttended, &event.ReturningAttendees); err != nil {
+			return nil, err
+		}
+		counts.events = append(counts.events, &event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT a.event_id, floor(extract(epoch FROM a.time) / $3::integer)::bigint AS bucket, count(*)
+		FROM event_attendance a
+		    JOIN events e ON e.id = a.event_id
+		WHERE e.hackathon_id = $1 AND ($2::integer IS NULL OR e.id = $2)
+		GROUP BY a.event_id, bucket`, hackathonID, eventID, int64(bucket/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var start int64
+		var checkIns int
+		if err = rows.Scan(&id, &start, &checkIns); err != nil {
+			return nil, err
+		}
+		if counts.buckets[id] == nil {
+			counts.buckets[id] = map[int64]int{}
+		}
+		counts.buckets[id][start] = checkIns
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if eventID != nil {
+		return counts, nil
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT visits, count(*)
+		FROM (SELECT count(*) AS visits
+		      FROM event_attendance a
+		          JOIN events e ON e.id = a.event_id
+		      WHERE e.hackathon_id = $1
+		      GROUP BY a.user_id) per_user
+		GROUP BY visits`, hackathonID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var events, attendees int
+		if err = rows.Scan(&events, &attendees); err != nil {
+			return nil, err
+		}
+		counts.frequency[events] = attendees
+	}
+	return counts, rows.Err()
+}
+
+// AdjustPoints appends to point_awards, which the event_attendance trigger of the migrations appends check-ins to
+func (r *DatabaseRepository) AdjustPoints(ctx context.Context, input *model.PointsAdjustment, adjustedBy string) (*model.PointsAward, error) {
+	if input.Points == 0 {
+		return nil, EmptyPointsAdjustment
+	}
+
+	award := &model.PointsAward{HackathonID: input.HackathonID, UserID: input.UserID, Points: input.Points, Reason: input.Reason, AdjustedBy: &adjustedBy}
+	err := r.DatabasePool.QueryRow(ctx, `INSERT INTO point_awards (hackathon_id, user_id, points, reason, adjusted_by) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`, input.HackathonID, input.UserID, input.Points, input.Reason, adjustedBy).Scan(&award.ID, &award.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		switch pgErr.ConstraintName {
+		case "point_awards_hackathons_id_fk":
+			return nil, HackathonNotFound
+		case "point_awards_users_id_fk":
+			return nil, UserNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	award.CreatedAt = award.CreatedAt.UTC()
+	return award, nil
+}
+
+// GetLeaderboard ranks the totals of point_awards, the latest award of a total is when the user completed it
+func (r *DatabaseRepository) GetLeaderboard(ctx context.Context, hackathonID string, first int, after int) ([]*model.LeaderboardEntry, int, error) {
+	first = max(first, 0)
+	entries := make([]*model.LeaderboardEntry, 0, first)
+	// hackathon ids are serials too, anything else has no awards
+	if !isEventID(hackathonID) {
+		return entries, 0, nil
+	}
+
+	var total int
+	err := pgx.BeginTxFunc(ctx, r.DatabasePool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `SELECT count(*) FROM (
+			SELECT 1 FROM point_awards WHERE hackathon_id = $1 GROUP BY user_id HAVING sum(points) > 0) totals`, hackathonID).Scan(&total)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT rank, user_id, points, completed_at
+			FROM (SELECT row_number() OVER (ORDER BY sum(points) DESC, max(created_at), user_id) AS rank,
+			             user_id, sum(points) AS points, max(created_at) AS completed_at
+			      FROM point_awards
+			      WHERE hackathon_id = $1
+			      GROUP BY user_id
+			      HAVING sum(points) > 0) ranked
+			WHERE rank > $2
+			ORDER BY rank
+			LIMIT $3`, hackathonID, after, first)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var entry model.LeaderboardEntry
+			if err = rows.Scan(&entry.Rank, &entry.UserID, &entry.Points, &entry.CompletedAt); err != nil {
+				return err
+			}
+			entry.CompletedAt = entry.CompletedAt.UTC()
+			entries = append(entries, &entry)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+func (r *DatabaseRepository) GetPointsAwards(ctx context.Context, hackathonID string, userID string) ([]*model.PointsAward, error) {
+	awards := []*model.PointsAward{}
+	// as are user ids
+	if !isEventID(hackathonID) || !isEventID(userID) {
+		return awards, nil
+	}
+
+	rows, err := r.DatabasePool.Query(ctx, `SELECT id, hackathon_id, user_id, event_id, points, reason, adjusted_by, created_at
+		FROM point_awards WHERE hackathon_id = $1 AND user_id = $2 ORDER BY created_at, id`, hackathonID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var award model.PointsAward
+		err = rows.Scan(&award.ID, &award.HackathonID, &award.UserID, &award.EventID, &award.Points, &award.Reason, &award.AdjustedBy, &award.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		award.CreatedAt = award.CreatedAt.UTC()
+		awards = append(awards, &award)
+	}
+	return awards, rows.Err()
+}
+
+func (r *DatabaseRepository) SetHost(ctx context.Context, eventID string, userID string, host bool) error {
+	return r.setInterest(ctx, "event_hosts", eventID, userID, host)
+}
+
+func (r *DatabaseRepository) HostedEvents(ctx context.Context, userID string, eventIDs []string) ([]string, error) {
+	hosted := []string{}
+	intIds := eventIntIDs(eventIDs)
+	if !isEventID(userID) || len(intIds) == 0 {
+		return hosted, nil
+	}
+
+	rows, err := r.DatabasePool.Query(ctx, "SELECT event_id FROM event_hosts WHERE event_id = ANY($1) AND user_id = $2", intIds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventID int
+		if err = rows.Scan(&eventID); err != nil {
+			return nil, err
+		}
+		hosted = append(hosted, strconv.Itoa(eventID))
+	}
+	return hosted, rows.Err()
+}
+
+// SubmitFeedback relies on event_feedback referencing event_attendance to turn away users who didn't check in
+func (r *DatabaseRepository) SubmitFeedback(ctx context.Context, eventID string, userID string, rating int, comment string) (*model.FeedbackResponse, error) {
+	if !isEventID(eventID) {
+		return nil, EventNotFound
+	}
+
+	response := &model.FeedbackResponse{UserID: &userID, Rating: rating, Comment: comment, CreatedAt: &time.Time{}}
+	err := r.DatabasePool.QueryRow(ctx, "INSERT INTO event_feedback (event_id, user_id, rating, comment) VALUES ($1, $2, $3, $4) RETURNING created_at",
+		eventID, userID, rating, comment).Scan(response.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == uniqueViolation && pgErr.ConstraintName == "event_feedback_pk":
+			return nil, FeedbackAlreadySubmitted
+		case pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == "event_feedback_event_attendance_fk":
+			return nil, NotCheckedIn
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	*response.CreatedAt = response.CreatedAt.UTC()
+	return response, nil
+}
+
+func (r *DatabaseRepository) GetFeedbackSummaries(ctx context.Context, eventIDs []string) (map[string]*model.FeedbackSummary, error) {
+	responses := map[string][]*model.FeedbackResponse{}
+	if intIds := eventIntIDs(eventIDs); len(intIds) > 0 {
+		rows, err := r.DatabasePool.Query(ctx, `SELECT event_id, user_id, rating, comment, created_at FROM event_feedback
+			WHERE event_id = ANY($1) ORDER BY created_at DESC, user_id`, intIds)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var eventID, userID int
+			var createdAt time.Time
+			response := &model.FeedbackResponse{}
+			if err = rows.Scan(&eventID, &userID, &response.Rating, &response.Comment, &createdAt); err != nil {
+				return nil, err
+			}
+			id := strconv.Itoa(userID)
+			createdAt = createdAt.UTC()
+			response.UserID, response.CreatedAt = &id, &createdAt
+			key := strconv.Itoa(eventID)
+			responses[key] = append(responses[key], response)
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	summaries := make(map[string]*model.FeedbackSummary, len(eventIDs))
+	for _, id := range eventIDs {
+		summaries[id] = summarizeFeedback(responses[id])
+	}
+	return summaries, nil
+}
+
+// isEventID reports whether id could belong to an event, anything that isn't a number in the range of a serial can
+// never match one
+func isEventID(id string) bool {
+	_, err := strconv.ParseInt(id, 10, 32)
+	return err == nil
+}
+
+// eventIntIDs converts the ids that could belong to an event for use with ANY, the others are left out
+func eventIntIDs(ids []string) []int {
+	intIds := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if intId, err := strconv.ParseInt(id, 10, 32); err == nil {
+			intIds = append(intIds, int(intId))
+		}
+	}
+	return intIds
+}
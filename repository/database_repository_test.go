@@ -0,0 +1,24 @@
+package repository
+
+import "testing"
+
+func TestIsEventID(t *testing.T) {
+	tests := map[string]bool{
+		"1":            true,
+		"2147483647":   true,
+		"2147483648":   false,
+		"99999999999":  false,
+		"-1":           true,
+		"1.5":          false,
+		"":             false,
+		"not-a-number": false,
+	}
+	for id, want := range tests {
+		if got := isEventID(id); got != want {
+			t.Errorf("isEventID(%q) = %v, want %v", id, got, want)
+		}
+	}
+	if got := eventIntIDs([]string{"1", "99999999999", "not-a-number", "3"}); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Errorf("eventIntIDs() = %v, want [1 3]", got)
+	}
+}
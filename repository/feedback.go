@@ -0,0 +1,33 @@
+package repository
+
+import "github.com/KnightHacks/knighthacks_events/graph/model"
+
+// The ratings an attendee can give, event_feedback checks the same range
+const (
+	minRating = 1
+	maxRating = 5
+)
+
+// summarizeFeedback adds up the responses of an event for DatabaseRepository and MemoryRepository alike
+func summarizeFeedback(responses []*model.FeedbackResponse) *model.FeedbackSummary {
+	summary := &model.FeedbackSummary{
+		Count:     len(responses),
+		Ratings:   make([]*model.RatingCount, 0, maxRating-minRating+1),
+		Responses: []*model.FeedbackResponse{},
+	}
+	counts := map[int]int{}
+	total := 0
+	for _, response := range responses {
+		counts[response.Rating]++
+		total += response.Rating
+		summary.Responses = append(summary.Responses, response)
+	}
+	for rating := minRating; rating <= maxRating; rating++ {
+		summary.Ratings = append(summary.Ratings, &model.RatingCount{Rating: rating, Responses: counts[rating]})
+	}
+	if len(responses) > 0 {
+		average := float64(total) / float64(len(responses))
+		summary.AverageRating = &average
+	}
+	return summary
+}
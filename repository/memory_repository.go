@@ -0,0 +1,553 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/outbox"
+)
+
+// MemoryRepository
+// Implements the Repository interface without a database for tests and local development. It behaves like
+// DatabaseRepository: ids are assigned like a serial, pages are ordered by id and missing events are EventNotFound.
+// Hackathons aren't known here, so unlike the database any hackathon id is accepted.
+type MemoryRepository struct {
+	// Outbox gets a message for every mutation, like the outbox table does
+	Outbox *outbox.MemoryStore
+
+	mu     sync.RWMutex
+	events map[int]model.Event
+	lastID int
+	// rsvps and bookmarks hold the user ids per event
+	rsvps     map[int]map[string]bool
+	bookmarks map[int]map[string]bool
+	// attendance holds the check-in time per user and event
+	attendance map[int]map[string]time.Time
+	// hackathons holds the hackathon id per event
+	hackathons map[int]string
+	// awards is the point_awards ledger, appended to by CheckIn like the trigger of the migrations does
+	awards      []model.PointsAward
+	lastAwardID int
+	// hosts holds the user ids per event and feedback the response per user and event
+	hosts    map[int]map[string]bool
+	feedback map[int]map[string]model.FeedbackResponse
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		Outbox:     outbox.NewMemoryStore(),
+		events:     map[int]model.Event{},
+		rsvps:      map[int]map[string]bool{},
+		bookmarks:  map[int]map[string]bool{},
+		attendance: map[int]map[string]time.Time{},
+		hackathons: map[int]string{},
+		hosts:      map[int]map[string]bool{},
+		feedback:   map[int]map[string]model.FeedbackResponse{},
+	}
+}
+
+func (r *MemoryRepository) CreateEvent(ctx context.Context, input *model.NewEvent) (*model.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	event := model.Event{
+		ID:          strconv.Itoa(r.lastID),
+		Name:        input.Name,
+		StartDate:   input.StartDate,
+		EndDate:     input.EndDate,
+		Description: input.Description,
+		Location:    input.Location,
+	}
+	if input.Points != nil {
+		event.Points = *input.Points
+	}
+	r.events[r.lastID] = stored(event)
+	r.hackathons[r.lastID] = input.HackathonID
+	if err := r.Outbox.Add(event.ID, outbox.TopicEventCreated, event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *MemoryRepository) UpdateEvent(ctx context.Context, id string, input *model.UpdatedEvent) (*model.Event, error) {
+	if input.Name == nil && input.StartDate == nil && input.EndDate == nil && input.Description == nil && input.Location == nil && input.Points == nil {
+		return nil, EmptyEventUpdate
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.key(id)
+	if !ok {
+		return nil, EventNotFound
+	}
+	event := r.events[key]
+	if input.Name != nil {
+		event.Name = *input.Name
+	}
+	if input.StartDate != nil {
+		event.StartDate = *input.StartDate
+	}
+	if input.EndDate != nil {
+		event.EndDate = *input.EndDate
+	}
+	if input.Description != nil {
+		event.Description = *input.Description
+	}
+	if input.Location != nil {
+		event.Location = *input.Location
+	}
+	if input.Points != nil {
+		event.Points = *input.Points
+	}
+	event = stored(event)
+	r.events[key] = event
+	if err := r.Outbox.Add(id, outbox.TopicEventUpdated, event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *MemoryRepository) DeleteEvent(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.key(id)
+	if !ok {
+		return false, EventNotFound
+	}
+	delete(r.events, key)
+	delete(r.rsvps, key)
+	delete(r.bookmarks, key)
+	delete(r.attendance, key)
+	delete(r.hackathons, key)
+	delete(r.hosts, key)
+	delete(r.feedback, key)
+	// the awards of the event go with its attendance, like the cascade in the database
+	awards := r.awards[:0]
+	for _, award := range r.awards {
+		if award.EventID == nil || *award.EventID != id {
+			awards = append(awards, award)
+		}
+	}
+	r.awards = awards
+	if err := r.Outbox.Add(id, outbox.TopicEventDeleted, map[string]string{"id": id}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *MemoryRepository) GetEvent(ctx context.Context, id string) (*model.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.key(id)
+	if !ok {
+		return nil, EventNotFound
+	}
+	event := r.events[key]
+	return &event, nil
+}
+
+// GetEventsByIDs returns every event that exists once, in no particular order, like DatabaseRepository
+func (r *MemoryRepository) GetEventsByIDs(ctx context.Context, ids []string) ([]*model.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]*model.Event, 0, len(ids))
+	seen := map[int]bool{}
+	for _, id := range ids {
+		key, ok := r.key(id)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		event := r.events[key]
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+func (r *MemoryRepository) GetEvents(ctx context.Context, first int, after string) ([]*model.Event, int, error) {
+	afterID, err := strconv.Atoi(after)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]int, 0, len(r.events))
+	for key := range r.events {
+		if key > afterID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Ints(keys)
+	if first = max(first, 0); len(keys) > first {
+		keys = keys[:first]
+	}
+
+	events := make([]*model.Event, 0, len(keys))
+	for _, key := range keys {
+		event := r.events[key]
+		events = append(events, &event)
+	}
+	return events, len(r.events), nil
+}
+
+func (r *MemoryRepository) SetRSVP(ctx context.Context, eventID string, userID string, going bool) error {
+	return r.setInterest(r.rsvps, eventID, userID, going)
+}
+
+func (r *MemoryRepository) SetBookmark(ctx context.Context, eventID string, userID string, saved bool) error {
+	return r.setInterest(r.bookmarks, eventID, userID, saved)
+}
+
+// Interested returns the ids of the users who RSVP'd to or bookmarked the event, sorted, for tests to inspect
+func (r *MemoryRepository) Interested(eventID string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, _ := strconv.Atoi(eventID)
+	var userIDs []string
+	for userID := range r.rsvps[key] {
+		userIDs = append(userIDs, userID)
+	}
+	for userID := range r.bookmarks[key] {
+		if !r.rsvps[key][userID] {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+// setInterest is SetRSVP, SetBookmark and SetHost, removing what isn't there succeeds like the DELETE of DatabaseRepository
+func (r *MemoryRepository) setInterest(interests map[int]map[string]bool, eventID string, userID string, set bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.key(eventID)
+	if !set {
+		delete(interests[key], userID)
+		return nil
+	}
+	if !ok {
+		return EventNotFound
+	}
+	if interests[key] == nil {
+		interests[key] = map[string]bool{}
+	}
+	interests[key][userID] = true
+	return nil
+}
+
+func (r *MemoryRepository) CheckIn(ctx context.Context, eventID string, userID string, at time.Time) (*model.Attendance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.key(eventID)
+	if !ok {
+		return nil, EventNotFound
+	}
+	if _, ok = r.attendance[key][userID]; ok {
+		return nil, AlreadyCheckedIn
+	}
+	attendance := &model.Attendance{EventID: eventID, UserID: userID, Time: at.UTC().Round(time.Microsecond)}
+	if err := r.Outbox.Add(eventID, outbox.TopicAttendanceCheckedIn, attendance); err != nil {
+		return nil, err
+	}
+	if r.attendance[key] == nil {
+		r.attendance[key] = map[string]time.Time{}
+	}
+	r.attendance[key][userID] = attendance.Time
+	if points := r.events[key].Points; points > 0 {
+		r.award(model.PointsAward{
+			HackathonID: r.hackathons[key], UserID: userID, EventID: &attendance.EventID, Points: points, Reason: "attendance", CreatedAt: attendance.Time,
+		})
+	}
+	return attendance, nil
+}
+
+func (r *MemoryRepository) EventAttendance(ctx context.Context, eventID string, bucket time.Duration) (*model.EventAttendanceStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.key(eventID)
+	if !ok {
+		return nil, EventNotFound
+	}
+	return r.countAttendance(r.hackathons[key], &key, bucket).event(bucket)
+}
+
+func (r *MemoryRepository) HackathonAttendance(ctx context.Context, hackathonID string, bucket time.Duration) (*model.HackathonAttendanceStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.countAttendance(hackathonID, nil, bucket).hackathon(hackathonID, bucket)
+}
+
+// countAttendance counts what the queries of DatabaseRepository do, only for the event with key only unless it is
+// nil. Check-ins are numbered per user across the whole hackathon like its window. r.mu has to be held.
+func (r *MemoryRepository) countAttendance(hackathonID string, only *int, bucket time.Duration) *attendanceCounts {
+	counts := &attendanceCounts{buckets: map[string]map[int64]int{}, frequency: map[int]int{}}
+
+	var keys []int
+	type checkIn struct {
+		key    int
+		userID string
+		at     time.Time
+	}
+	var checkIns []checkIn
+	for key, id := range r.hackathons {
+		if id != hackathonID {
+			continue
+		}
+		for userID, at := range r.attendance[key] {
+			checkIns = append(checkIns, checkIn{key: key, userID: userID, at: at})
+		}
+		if only == nil || *only == key {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := r.events[keys[i]], r.events[keys[j]]
+		if !a.StartDate.Equal(b.StartDate) {
+			return a.StartDate.Before(b.StartDate)
+		}
+		return keys[i] < keys[j]
+	})
+	stats := map[int]*model.EventAttendanceStats{}
+	for _, key := range keys {
+		event := r.events[key]
+		stats[key] = &model.EventAttendanceStats{EventID: event.ID, Name: event.Name, StartDate: event.StartDate, Rsvps: len(r.rsvps[key])}
+		counts.events = append(counts.events, stats[key])
+	}
+
+	sort.Slice(checkIns, func(i, j int) bool {
+		if !checkIns[i].at.Equal(checkIns[j].at) {
+			return checkIns[i].at.Before(checkIns[j].at)
+		}
+		return checkIns[i].key < checkIns[j].key
+	})
+	visits := map[string]int{}
+	seconds := int64(bucket / time.Second)
+	for _, c := range checkIns {
+		visits[c.userID]++
+		event, ok := stats[c.key]
+		if !ok {
+			continue
+		}
+		event.CheckIns++
+		if r.rsvps[c.key][c.userID] {
+			event.RsvpsAttended++
+		}
+		if visits[c.userID] > 1 {
+			event.ReturningAttendees++
+		}
+		if counts.buckets[event.EventID] == nil {
+			counts.buckets[event.EventID] = map[int64]int{}
+		}
+		// floors like the database does for check-ins before 1970
+		start := c.at.Unix() / seconds
+		if c.at.Unix()%seconds < 0 {
+			start--
+		}
+		counts.buckets[event.EventID][start]++
+	}
+	for _, events := range visits {
+		counts.frequency[events]++
+	}
+	return counts
+}
+
+func (r *MemoryRepository) AdjustPoints(ctx context.Context, input *model.PointsAdjustment, adjustedBy string) (*model.PointsAward, error) {
+	if input.Points == 0 {
+		return nil, EmptyPointsAdjustment
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	award := r.award(model.PointsAward{
+		HackathonID: input.HackathonID, UserID: input.UserID, Points: input.Points, Reason: input.Reason, AdjustedBy: &adjustedBy,
+		CreatedAt: time.Now().UTC().Round(time.Microsecond),
+	})
+	return &award, nil
+}
+
+// GetLeaderboard ranks like DatabaseRepository: most points first, then who got there first, then by user id
+func (r *MemoryRepository) GetLeaderboard(ctx context.Context, hackathonID string, first int, after int) ([]*model.LeaderboardEntry, int, error) {
+	first = max(first, 0)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	totals := map[string]*model.LeaderboardEntry{}
+	for _, award := range r.awards {
+		if award.HackathonID != hackathonID {
+			continue
+		}
+		entry, ok := totals[award.UserID]
+		if !ok {
+			entry = &model.LeaderboardEntry{UserID: award.UserID}
+			totals[award.UserID] = entry
+		}
+		entry.Points += award.Points
+		if award.CreatedAt.After(entry.CompletedAt) {
+			entry.CompletedAt = award.CreatedAt
+		}
+	}
+
+	ranked := make([]*model.LeaderboardEntry, 0, len(totals))
+	for _, entry := range totals {
+		if entry.Points > 0 {
+			ranked = append(ranked, entry)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if !a.CompletedAt.Equal(b.CompletedAt) {
+			return a.CompletedAt.Before(b.CompletedAt)
+		}
+		// user ids are compared as the numbers they are in the database
+		aID, _ := strconv.Atoi(a.UserID)
+		bID, _ := strconv.Atoi(b.UserID)
+		return aID < bID
+	})
+	for i, entry := range ranked {
+		entry.Rank = i + 1
+	}
+
+	if after > len(ranked) {
+		after = len(ranked)
+	}
+	page := ranked[max(after, 0):]
+	if len(page) > first {
+		page = page[:first]
+	}
+	return page, len(ranked), nil
+}
+
+func (r *MemoryRepository) GetPointsAwards(ctx context.Context, hackathonID string, userID string) ([]*model.PointsAward, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	awards := []*model.PointsAward{}
+	for _, award := range r.awards {
+		if award.HackathonID == hackathonID && award.UserID == userID {
+			award := award
+			awards = append(awards, &award)
+		}
+	}
+	sort.SliceStable(awards, func(i, j int) bool {
+		return awards[i].CreatedAt.Before(awards[j].CreatedAt)
+	})
+	return awards, nil
+}
+
+func (r *MemoryRepository) SetHost(ctx context.Context, eventID string, userID string, host bool) error {
+	return r.setInterest(r.hosts, eventID, userID, host)
+}
+
+func (r *MemoryRepository) HostedEvents(ctx context.Context, userID string, eventIDs []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hosted := []string{}
+	for _, id := range eventIDs {
+		key, err := strconv.Atoi(id)
+		if err == nil && r.hosts[key][userID] {
+			hosted = append(hosted, id)
+		}
+	}
+	return hosted, nil
+}
+
+func (r *MemoryRepository) SubmitFeedback(ctx context.Context, eventID string, userID string, rating int, comment string) (*model.FeedbackResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.key(eventID)
+	if !ok {
+		return nil, EventNotFound
+	}
+	if _, ok = r.attendance[key][userID]; !ok {
+		return nil, NotCheckedIn
+	}
+	if _, ok = r.feedback[key][userID]; ok {
+		return nil, FeedbackAlreadySubmitted
+	}
+	createdAt := time.Now().UTC().Round(time.Microsecond)
+	response := model.FeedbackResponse{UserID: &userID, Rating: rating, Comment: comment, CreatedAt: &createdAt}
+	if r.feedback[key] == nil {
+		r.feedback[key] = map[string]model.FeedbackResponse{}
+	}
+	r.feedback[key][userID] = response
+	return &response, nil
+}
+
+// GetFeedbackSummaries orders the responses like DatabaseRepository: newest first, then by user id
+func (r *MemoryRepository) GetFeedbackSummaries(ctx context.Context, eventIDs []string) (map[string]*model.FeedbackSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	summaries := make(map[string]*model.FeedbackSummary, len(eventIDs))
+	for _, id := range eventIDs {
+		summaries[id] = r.feedbackSummary(id)
+	}
+	return summaries, nil
+}
+
+func (r *MemoryRepository) feedbackSummary(eventID string) *model.FeedbackSummary {
+	key, _ := strconv.Atoi(eventID)
+	responses := make([]*model.FeedbackResponse, 0, len(r.feedback[key]))
+	for _, response := range r.feedback[key] {
+		response := response
+		responses = append(responses, &response)
+	}
+	sort.Slice(responses, func(i, j int) bool {
+		a, b := responses[i], responses[j]
+		if !a.CreatedAt.Equal(*b.CreatedAt) {
+			return a.CreatedAt.After(*b.CreatedAt)
+		}
+		aID, _ := strconv.Atoi(*a.UserID)
+		bID, _ := strconv.Atoi(*b.UserID)
+		return aID < bID
+	})
+	return summarizeFeedback(responses)
+}
+
+// award appends to the ledger with the next id, r.mu has to be held
+func (r *MemoryRepository) award(award model.PointsAward) model.PointsAward {
+	r.lastAwardID++
+	award.ID = strconv.Itoa(r.lastAwardID)
+	r.awards = append(r.awards, award)
+	return award
+}
+
+// key finds the map key of an existing event, r.mu has to be held
+func (r *MemoryRepository) key(id string) (int, bool) {
+	key, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, false
+	}
+	_, ok := r.events[key]
+	return key, ok
+}
+
+// stored rounds times the way a Postgres timestamp column does, so reads return what the database would
+func stored(event model.Event) model.Event {
+	event.StartDate = event.StartDate.UTC().Round(time.Microsecond)
+	event.EndDate = event.EndDate.UTC().Round(time.Microsecond)
+	return event
+}
@@ -0,0 +1,80 @@
+package repository_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+	"github.com/KnightHacks/knighthacks_events/repository/repositorytest"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	repositorytest.Run(t, repository.NewMemoryRepository(), "1")
+}
+
+func TestMemoryRepository_Outbox(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	repositorytest.RunOutbox(t, repo, "1", repo.Outbox)
+}
+
+func TestMemoryRepository_Interests(t *testing.T) {
+	repositorytest.RunInterests(t, repository.NewMemoryRepository(), "1", "1")
+}
+
+func TestMemoryRepository_Attendance(t *testing.T) {
+	repositorytest.RunAttendance(t, repository.NewMemoryRepository(), "1", "1")
+}
+
+func TestMemoryRepository_AttendanceStats(t *testing.T) {
+	repositorytest.RunAttendanceStats(t, repository.NewMemoryRepository(), "1", [3]string{"1", "2", "3"})
+}
+
+func TestMemoryRepository_Points(t *testing.T) {
+	repositorytest.RunPoints(t, repository.NewMemoryRepository(), "1", [3]string{"1", "2", "3"})
+}
+
+func TestMemoryRepository_Feedback(t *testing.T) {
+	repositorytest.RunFeedback(t, repository.NewMemoryRepository(), "1", [3]string{"1", "2", "3"})
+}
+
+func TestCachedRepository_Conformance(t *testing.T) {
+	repositorytest.Run(t, repository.NewCachedRepository(repository.NewMemoryRepository(), nil, time.Minute, 100), "1")
+}
+
+func TestMemoryRepository_Concurrent(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			event, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: "Concurrent " + strconv.Itoa(i)})
+			if err != nil {
+				t.Errorf("CreateEvent() error = %v", err)
+				return
+			}
+			name := "Renamed " + strconv.Itoa(i)
+			if _, err = repo.UpdateEvent(ctx, event.ID, &model.UpdatedEvent{Name: &name}); err != nil {
+				t.Errorf("UpdateEvent() error = %v", err)
+			}
+			if _, _, err = repo.GetEvents(ctx, 10, "0"); err != nil {
+				t.Errorf("GetEvents() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	_, total, err := repo.GetEvents(ctx, 1, "0")
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if total != 20 {
+		t.Errorf("GetEvents() total = %d, want 20", total)
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/KnightHacks/knighthacks_events/graph/model"
 )
@@ -11,5 +12,34 @@ type Repository interface {
 	UpdateEvent(ctx context.Context, id string, input *model.UpdatedEvent) (*model.Event, error)
 	DeleteEvent(ctx context.Context, id string) (bool, error)
 	GetEvent(ctx context.Context, id string) (*model.Event, error)
+	GetEventsByIDs(ctx context.Context, ids []string) ([]*model.Event, error)
 	GetEvents(ctx context.Context, first int, after string) ([]*model.Event, int, error)
+	// SetRSVP records whether userID is going to the event, reminders are sent to users who are
+	SetRSVP(ctx context.Context, eventID string, userID string, going bool) error
+	// SetBookmark saves or removes the event from the bookmarks of userID, bookmarked events are reminded of too
+	SetBookmark(ctx context.Context, eventID string, userID string, saved bool) error
+	// CheckIn records that userID attended the event at the given time, checking in twice is AlreadyCheckedIn
+	CheckIn(ctx context.Context, eventID string, userID string, at time.Time) (*model.Attendance, error)
+	// EventAttendance counts the check-ins of the event, histograms count them per bucket of the given width.
+	// Attendees are returning when they checked in to an earlier event of the same hackathon.
+	EventAttendance(ctx context.Context, eventID string, bucket time.Duration) (*model.EventAttendanceStats, error)
+	// HackathonAttendance is EventAttendance for every event of the hackathon, added up
+	HackathonAttendance(ctx context.Context, hackathonID string, bucket time.Duration) (*model.HackathonAttendanceStats, error)
+	// AdjustPoints adds an award of input.Points on behalf of the admin adjustedBy, 0 points is EmptyPointsAdjustment
+	AdjustPoints(ctx context.Context, input *model.PointsAdjustment, adjustedBy string) (*model.PointsAward, error)
+	// GetLeaderboard returns up to first entries ranked below after along with how many users have points. Check-ins
+	// award the points of their event.
+	GetLeaderboard(ctx context.Context, hackathonID string, first int, after int) ([]*model.LeaderboardEntry, int, error)
+	// GetPointsAwards returns every award of userID in the hackathon, oldest first
+	GetPointsAwards(ctx context.Context, hackathonID string, userID string) ([]*model.PointsAward, error)
+	// SetHost adds or removes userID from the hosts of the event, hosts get to see its feedback
+	SetHost(ctx context.Context, eventID string, userID string, host bool) error
+	// HostedEvents returns the ids of eventIDs that userID hosts, in no particular order
+	HostedEvents(ctx context.Context, userID string, eventIDs []string) ([]string, error)
+	// SubmitFeedback records what an attendee thought of the event. Users who didn't check in are NotCheckedIn and
+	// giving feedback twice is FeedbackAlreadySubmitted.
+	SubmitFeedback(ctx context.Context, eventID string, userID string, rating int, comment string) (*model.FeedbackResponse, error)
+	// GetFeedbackSummaries adds up the feedback of every event in a single round trip, keyed by event id. Every id gets
+	// a summary and every response carries who gave it and when.
+	GetFeedbackSummaries(ctx context.Context, eventIDs []string) (map[string]*model.FeedbackSummary, error)
 }
@@ -0,0 +1,42 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+// RunAttendance checks CheckIn, userID has to reference an existing user for implementations that enforce it
+func RunAttendance(t *testing.T, repo repository.Repository, hackathonID string, userID string) {
+	ctx := context.Background()
+	at := time.Date(2023, time.February, 3, 17, 45, 30, 123456789, time.UTC)
+
+	event, err := repo.CreateEvent(ctx, newEvent("Conformance CheckIn", hackathonID))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	attendance, err := repo.CheckIn(ctx, event.ID, userID, at)
+	if err != nil {
+		t.Fatalf("CheckIn() error = %v", err)
+	}
+	if attendance.EventID != event.ID || attendance.UserID != userID || !attendance.Time.Equal(at.Round(time.Microsecond)) {
+		t.Errorf("CheckIn() = %+v, want user %s at %v", attendance, userID, at.Round(time.Microsecond))
+	}
+	if _, err = repo.CheckIn(ctx, event.ID, userID, at.Add(time.Minute)); !errors.Is(err, repository.AlreadyCheckedIn) {
+		t.Errorf("second CheckIn() error = %v, want %v", err, repository.AlreadyCheckedIn)
+	}
+
+	// attendance doesn't keep an event from being deleted
+	if _, err = repo.DeleteEvent(ctx, event.ID); err != nil {
+		t.Fatalf("DeleteEvent() of an event with attendance error = %v", err)
+	}
+	if _, err = repo.CheckIn(ctx, event.ID, userID, at); !errors.Is(err, repository.EventNotFound) {
+		t.Errorf("CheckIn() of a deleted event error = %v, want %v", err, repository.EventNotFound)
+	}
+	if _, err = repo.CheckIn(ctx, "not a number", userID, at); !errors.Is(err, repository.EventNotFound) {
+		t.Errorf("CheckIn() of an invalid id error = %v, want %v", err, repository.EventNotFound)
+	}
+}
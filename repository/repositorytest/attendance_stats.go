@@ -0,0 +1,121 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+// RunAttendanceStats checks EventAttendance and HackathonAttendance. hackathonID must not have events of other tests
+// and the users have to exist for implementations that enforce it.
+func RunAttendanceStats(t *testing.T, repo repository.Repository, hackathonID string, userIDs [3]string) {
+	ctx := context.Background()
+	opening := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	create := func(name string, start time.Time) string {
+		t.Helper()
+		input := newEvent(name, hackathonID)
+		input.StartDate, input.EndDate = start, start.Add(time.Hour)
+		event, err := repo.CreateEvent(ctx, input)
+		if err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+		return event.ID
+	}
+	// created out of order, the stats are ordered by start date
+	workshop := create("Conformance Workshop", opening.Add(2*time.Hour))
+	ceremony := create("Conformance Ceremony", opening)
+	empty := create("Conformance Empty", opening.Add(3*time.Hour))
+
+	for _, rsvp := range []struct{ eventID, userID string }{{ceremony, userIDs[0]}, {ceremony, userIDs[1]}, {workshop, userIDs[2]}} {
+		if err := repo.SetRSVP(ctx, rsvp.eventID, rsvp.userID, true); err != nil {
+			t.Fatalf("SetRSVP() error = %v", err)
+		}
+	}
+	for _, checkIn := range []struct {
+		eventID string
+		userID  string
+		at      time.Time
+	}{
+		{ceremony, userIDs[0], opening.Add(-10 * time.Minute)},
+		{ceremony, userIDs[2], opening.Add(5 * time.Minute)},
+		{workshop, userIDs[0], opening.Add(115 * time.Minute)},
+		{workshop, userIDs[1], opening.Add(130 * time.Minute)},
+	} {
+		if _, err := repo.CheckIn(ctx, checkIn.eventID, checkIn.userID, checkIn.at); err != nil {
+			t.Fatalf("CheckIn() error = %v", err)
+		}
+	}
+
+	event, err := repo.EventAttendance(ctx, ceremony, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("EventAttendance() error = %v", err)
+	}
+	assertEventStats(t, event, &model.EventAttendanceStats{
+		EventID: ceremony, Name: "Conformance Ceremony", StartDate: opening, CheckIns: 2, Rsvps: 2, RsvpsAttended: 1, ConversionRate: 0.5,
+		Histogram: []*model.AttendanceBucket{{Start: opening.Add(-15 * time.Minute), CheckIns: 1}, {Start: opening, CheckIns: 1}},
+	})
+
+	hackathon, err := repo.HackathonAttendance(ctx, hackathonID, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("HackathonAttendance() error = %v", err)
+	}
+	if len(hackathon.Events) != 3 {
+		t.Fatalf("HackathonAttendance() has %d events, want 3", len(hackathon.Events))
+	}
+	assertEventStats(t, hackathon.Events[0], event)
+	// the first of userIDs checked in to the ceremony before
+	assertEventStats(t, hackathon.Events[1], &model.EventAttendanceStats{
+		EventID: workshop, Name: "Conformance Workshop", StartDate: opening.Add(2 * time.Hour), CheckIns: 2, Rsvps: 1, ReturningAttendees: 1,
+		Histogram: []*model.AttendanceBucket{{Start: opening.Add(105 * time.Minute), CheckIns: 1}, {Start: opening.Add(2 * time.Hour), CheckIns: 1}},
+	})
+	assertEventStats(t, hackathon.Events[2], &model.EventAttendanceStats{
+		EventID: empty, Name: "Conformance Empty", StartDate: opening.Add(3 * time.Hour), Histogram: []*model.AttendanceBucket{},
+	})
+	if hackathon.CheckIns != 4 || hackathon.UniqueAttendees != 3 || hackathon.RepeatAttendees != 1 || hackathon.Rsvps != 3 ||
+		hackathon.RsvpsAttended != 1 || math.Abs(hackathon.ConversionRate-1.0/3) > 1e-9 {
+		t.Errorf("HackathonAttendance() = %+v, want 4 check-ins by 3 attendees, 1 repeating, and 1 of 3 RSVPs attended", hackathon)
+	}
+	if len(hackathon.Frequency) != 2 || *hackathon.Frequency[0] != (model.AttendanceFrequency{Events: 1, Attendees: 2}) ||
+		*hackathon.Frequency[1] != (model.AttendanceFrequency{Events: 2, Attendees: 1}) {
+		t.Errorf("HackathonAttendance() frequency = %v, want 2 attendees at 1 event and 1 at 2", hackathon.Frequency)
+	}
+	// from 17:45 to 20:00, the empty buckets in between included
+	if len(hackathon.Histogram) != 10 || hackathon.Histogram[2].CheckIns != 0 || hackathon.Histogram[9].CheckIns != 1 {
+		t.Errorf("HackathonAttendance() histogram has %d buckets, want 10 from the first to the last check-in: %v", len(hackathon.Histogram), hackathon.Histogram)
+	}
+
+	if _, err = repo.EventAttendance(ctx, "not a number", time.Hour); !errors.Is(err, repository.EventNotFound) {
+		t.Errorf("EventAttendance() of an invalid id error = %v, want %v", err, repository.EventNotFound)
+	}
+	if _, err = repo.DeleteEvent(ctx, empty); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if _, err = repo.EventAttendance(ctx, empty, time.Hour); !errors.Is(err, repository.EventNotFound) {
+		t.Errorf("EventAttendance() of a deleted event error = %v, want %v", err, repository.EventNotFound)
+	}
+	if none, err := repo.HackathonAttendance(ctx, "not a number", time.Hour); err != nil || none.CheckIns != 0 || len(none.Events) != 0 {
+		t.Errorf("HackathonAttendance() of an unknown hackathon = %+v, %v, want no events", none, err)
+	}
+}
+
+func assertEventStats(t *testing.T, got *model.EventAttendanceStats, want *model.EventAttendanceStats) {
+	t.Helper()
+	if got.EventID != want.EventID || got.Name != want.Name || !got.StartDate.Equal(want.StartDate) || got.CheckIns != want.CheckIns ||
+		got.Rsvps != want.Rsvps || got.RsvpsAttended != want.RsvpsAttended || got.ConversionRate != want.ConversionRate ||
+		got.ReturningAttendees != want.ReturningAttendees {
+		t.Errorf("stats of %s = %+v, want %+v", want.Name, got, want)
+	}
+	if len(got.Histogram) != len(want.Histogram) {
+		t.Fatalf("histogram of %s has %d buckets, want %d", want.Name, len(got.Histogram), len(want.Histogram))
+	}
+	for i, bucket := range got.Histogram {
+		if !bucket.Start.Equal(want.Histogram[i].Start) || bucket.CheckIns != want.Histogram[i].CheckIns {
+			t.Errorf("bucket %d of %s = %+v, want %+v", i, want.Name, bucket, want.Histogram[i])
+		}
+	}
+}
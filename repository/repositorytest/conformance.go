@@ -0,0 +1,214 @@
+// Package repositorytest is the test suite every repository.Repository implementation has to pass
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+// missingID is a valid serial that no test ever gets to
+const missingID = "2147483647"
+
+// Run checks repo against the Repository contract. The repository may already hold events, hackathonID has to
+// reference an existing hackathon for implementations that enforce it.
+func Run(t *testing.T, repo repository.Repository, hackathonID string) {
+	ctx := context.Background()
+	create := func(t *testing.T, name string) *model.Event {
+		t.Helper()
+		event, err := repo.CreateEvent(ctx, newEvent(name, hackathonID))
+		if err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+		return event
+	}
+
+	t.Run("CreateEvent then GetEvent", func(t *testing.T) {
+		input := newEvent("Conformance Create", hackathonID)
+		created, err := repo.CreateEvent(ctx, input)
+		if err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+		if created.ID == "" {
+			t.Fatalf("CreateEvent() returned an event without an id")
+		}
+		want := &model.Event{
+			ID:          created.ID,
+			Name:        input.Name,
+			StartDate:   input.StartDate,
+			EndDate:     input.EndDate,
+			Description: input.Description,
+			Location:    input.Location,
+		}
+		if !reflect.DeepEqual(created, want) {
+			t.Errorf("CreateEvent() got = %v, want %v", created, want)
+		}
+
+		got, err := repo.GetEvent(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetEvent() error = %v", err)
+		}
+		assertEqual(t, got, want)
+	})
+
+	t.Run("GetEvent missing", func(t *testing.T) {
+		for _, id := range []string{missingID, "not-a-number", "99999999999"} {
+			if _, err := repo.GetEvent(ctx, id); !errors.Is(err, repository.EventNotFound) {
+				t.Errorf("GetEvent(%q) error = %v, want %v", id, err, repository.EventNotFound)
+			}
+		}
+	})
+
+	t.Run("UpdateEvent", func(t *testing.T) {
+		created := create(t, "Conformance Update")
+		name := "Conformance Updated"
+		endDate := created.EndDate.Add(time.Hour)
+
+		got, err := repo.UpdateEvent(ctx, created.ID, &model.UpdatedEvent{Name: &name, EndDate: &endDate})
+		if err != nil {
+			t.Fatalf("UpdateEvent() error = %v", err)
+		}
+		want := *created
+		want.Name = name
+		want.EndDate = endDate
+		assertEqual(t, got, &want)
+
+		got, err = repo.GetEvent(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("GetEvent() error = %v", err)
+		}
+		assertEqual(t, got, &want)
+	})
+
+	t.Run("UpdateEvent without fields", func(t *testing.T) {
+		created := create(t, "Conformance Empty Update")
+		if _, err := repo.UpdateEvent(ctx, created.ID, &model.UpdatedEvent{}); !errors.Is(err, repository.EmptyEventUpdate) {
+			t.Errorf("UpdateEvent() error = %v, want %v", err, repository.EmptyEventUpdate)
+		}
+	})
+
+	t.Run("UpdateEvent missing", func(t *testing.T) {
+		name := "Conformance Missing"
+		if _, err := repo.UpdateEvent(ctx, missingID, &model.UpdatedEvent{Name: &name}); !errors.Is(err, repository.EventNotFound) {
+			t.Errorf("UpdateEvent() error = %v, want %v", err, repository.EventNotFound)
+		}
+	})
+
+	t.Run("DeleteEvent", func(t *testing.T) {
+		created := create(t, "Conformance Delete")
+		deleted, err := repo.DeleteEvent(ctx, created.ID)
+		if err != nil || !deleted {
+			t.Fatalf("DeleteEvent() got = %v, error = %v", deleted, err)
+		}
+		if _, err = repo.GetEvent(ctx, created.ID); !errors.Is(err, repository.EventNotFound) {
+			t.Errorf("GetEvent() after delete error = %v, want %v", err, repository.EventNotFound)
+		}
+		if deleted, err = repo.DeleteEvent(ctx, created.ID); deleted || !errors.Is(err, repository.EventNotFound) {
+			t.Errorf("DeleteEvent() twice got = %v, error = %v, want %v", deleted, err, repository.EventNotFound)
+		}
+	})
+
+	t.Run("GetEventsByIDs", func(t *testing.T) {
+		first := create(t, "Conformance Batch 1")
+		second := create(t, "Conformance Batch 2")
+
+		got, err := repo.GetEventsByIDs(ctx, []string{second.ID, missingID, "not-a-number", "99999999999", first.ID, second.ID})
+		if err != nil {
+			t.Fatalf("GetEventsByIDs() error = %v", err)
+		}
+		if ids := sortedIDs(got); !reflect.DeepEqual(ids, sortedIDs([]*model.Event{first, second})) {
+			t.Errorf("GetEventsByIDs() got ids %v, want %v and %v once each", ids, first.ID, second.ID)
+		}
+	})
+
+	t.Run("GetEvents", func(t *testing.T) {
+		_, before, err := repo.GetEvents(ctx, 1, "0")
+		if err != nil {
+			t.Fatalf("GetEvents() error = %v", err)
+		}
+
+		var created []*model.Event
+		for i := 0; i < 5; i++ {
+			created = append(created, create(t, "Conformance Page "+strconv.Itoa(i)))
+		}
+		// serials only grow, so everything after the id just below the first new event is one of ours
+		firstID, _ := strconv.Atoi(created[0].ID)
+		after := strconv.Itoa(firstID - 1)
+
+		var paged []*model.Event
+		for page := 0; page < 3; page++ {
+			events, total, err := repo.GetEvents(ctx, 2, after)
+			if err != nil {
+				t.Fatalf("GetEvents() error = %v", err)
+			}
+			if total != before+len(created) {
+				t.Errorf("GetEvents() total = %d, want %d", total, before+len(created))
+			}
+			if len(events) == 0 {
+				break
+			}
+			paged = append(paged, events...)
+			after = events[len(events)-1].ID
+		}
+
+		if len(paged) != len(created) {
+			t.Fatalf("GetEvents() paged through %d events, want %d", len(paged), len(created))
+		}
+		for i := range created {
+			assertEqual(t, paged[i], created[i])
+		}
+
+		events, _, err := repo.GetEvents(ctx, 2, after)
+		if err != nil {
+			t.Fatalf("GetEvents() error = %v", err)
+		}
+		if len(events) != 0 {
+			t.Errorf("GetEvents() after the last event got %d events, want none", len(events))
+		}
+		for _, first := range []int{0, -1} {
+			events, total, err := repo.GetEvents(ctx, first, "0")
+			if err != nil || len(events) != 0 || total != before+len(created) {
+				t.Errorf("GetEvents() with first %d = %d events, total %d, %v, want an empty page", first, len(events), total, err)
+			}
+		}
+	})
+}
+
+func newEvent(name string, hackathonID string) *model.NewEvent {
+	return &model.NewEvent{
+		HackathonID: hackathonID,
+		Name:        name,
+		StartDate:   time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC),
+		EndDate:     time.Date(2023, time.February, 3, 20, 0, 0, 0, time.UTC),
+		Description: name + " Description",
+		Location:    "UCF",
+	}
+}
+
+// assertEqual compares instants rather than time.Time values, the database may hand back another location
+func assertEqual(t *testing.T, got *model.Event, want *model.Event) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("got nil, want %v", want)
+	}
+	if got.ID != want.ID || got.Name != want.Name || got.Description != want.Description || got.Location != want.Location ||
+		!got.StartDate.Equal(want.StartDate) || !got.EndDate.Equal(want.EndDate) {
+		t.Errorf("got = %v, want %v", got, want)
+	}
+}
+
+func sortedIDs(events []*model.Event) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
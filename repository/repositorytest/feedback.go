@@ -0,0 +1,132 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+// RunFeedback checks SetHost, HostedEvents, SubmitFeedback and GetFeedbackSummaries. The users have to exist for
+// implementations that enforce it.
+func RunFeedback(t *testing.T, repo repository.Repository, hackathonID string, userIDs [3]string) {
+	ctx := context.Background()
+	event, err := repo.CreateEvent(ctx, newEvent("Conformance Feedback", hackathonID))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	other, err := repo.CreateEvent(ctx, newEvent("Conformance Feedback Other", hackathonID))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	ids := []string{event.ID, other.ID, "not a number"}
+	isHost := func(userID string) bool {
+		t.Helper()
+		hosted, err := repo.HostedEvents(ctx, userID, ids)
+		if err != nil {
+			t.Fatalf("HostedEvents() error = %v", err)
+		}
+		if len(hosted) > 1 || len(hosted) == 1 && hosted[0] != event.ID {
+			t.Errorf("HostedEvents() = %v, want %s at most", hosted, event.ID)
+		}
+		return len(hosted) == 1
+	}
+	summaryOf := func() *model.FeedbackSummary {
+		t.Helper()
+		summaries, err := repo.GetFeedbackSummaries(ctx, ids)
+		if err != nil {
+			t.Fatalf("GetFeedbackSummaries() error = %v", err)
+		}
+		for _, id := range ids[1:] {
+			if summary := summaries[id]; summary == nil || summary.Count != 0 {
+				t.Errorf("GetFeedbackSummaries()[%s] = %+v, want an empty summary", id, summary)
+			}
+		}
+		if summaries[event.ID] == nil {
+			t.Fatalf("GetFeedbackSummaries() = %v, want a summary of %s", summaries, event.ID)
+		}
+		return summaries[event.ID]
+	}
+
+	if err = repo.SetHost(ctx, event.ID, userIDs[2], true); err != nil {
+		t.Fatalf("SetHost() error = %v", err)
+	}
+	if !isHost(userIDs[2]) {
+		t.Errorf("HostedEvents() of the host is empty, want %s", event.ID)
+	}
+	if isHost(userIDs[0]) {
+		t.Errorf("HostedEvents() of an attendee = %s, want none", event.ID)
+	}
+	if err = repo.SetHost(ctx, "not a number", userIDs[2], true); !errors.Is(err, repository.EventNotFound) {
+		t.Errorf("SetHost() of an invalid id error = %v, want %v", err, repository.EventNotFound)
+	}
+
+	summary := summaryOf()
+	if summary.Count != 0 || summary.AverageRating != nil || len(summary.Ratings) != 5 || len(summary.Responses) != 0 {
+		t.Errorf("GetFeedbackSummaries() without feedback = %+v, want every rating at 0 and no average", summary)
+	}
+
+	if _, err = repo.SubmitFeedback(ctx, event.ID, userIDs[0], 5, "Loved it"); !errors.Is(err, repository.NotCheckedIn) {
+		t.Errorf("SubmitFeedback() before checking in error = %v, want %v", err, repository.NotCheckedIn)
+	}
+	at := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	for _, userID := range userIDs[:2] {
+		if _, err = repo.CheckIn(ctx, event.ID, userID, at); err != nil {
+			t.Fatalf("CheckIn() error = %v", err)
+		}
+	}
+	response, err := repo.SubmitFeedback(ctx, event.ID, userIDs[0], 5, "Loved it")
+	if err != nil {
+		t.Fatalf("SubmitFeedback() error = %v", err)
+	}
+	if response.UserID == nil || *response.UserID != userIDs[0] || response.Rating != 5 || response.Comment != "Loved it" || response.CreatedAt == nil {
+		t.Errorf("SubmitFeedback() = %+v, want a rating of 5 by %s", response, userIDs[0])
+	}
+	if _, err = repo.SubmitFeedback(ctx, event.ID, userIDs[0], 1, "Changed my mind"); !errors.Is(err, repository.FeedbackAlreadySubmitted) {
+		t.Errorf("SubmitFeedback() twice error = %v, want %v", err, repository.FeedbackAlreadySubmitted)
+	}
+	if _, err = repo.SubmitFeedback(ctx, event.ID, userIDs[1], 2, ""); err != nil {
+		t.Fatalf("SubmitFeedback() error = %v", err)
+	}
+	if _, err = repo.SubmitFeedback(ctx, "not a number", userIDs[1], 2, ""); !errors.Is(err, repository.EventNotFound) {
+		t.Errorf("SubmitFeedback() of an invalid id error = %v, want %v", err, repository.EventNotFound)
+	}
+
+	summary = summaryOf()
+	if summary.Count != 2 || summary.AverageRating == nil || *summary.AverageRating != 3.5 {
+		t.Errorf("GetFeedbackSummaries() = %+v, want 2 responses averaging 3.5", summary)
+	}
+	for _, count := range summary.Ratings {
+		want := 0
+		if count.Rating == 2 || count.Rating == 5 {
+			want = 1
+		}
+		if count.Responses != want {
+			t.Errorf("GetFeedbackSummaries() has %d responses rating %d, want %d", count.Responses, count.Rating, want)
+		}
+	}
+	comments := map[string]string{}
+	for _, response := range summary.Responses {
+		if response.UserID == nil || response.CreatedAt == nil {
+			t.Fatalf("GetFeedbackSummaries() response = %+v, want who gave it and when", response)
+		}
+		comments[*response.UserID] = response.Comment
+	}
+	if len(comments) != 2 || comments[userIDs[0]] != "Loved it" || comments[userIDs[1]] != "" {
+		t.Errorf("GetFeedbackSummaries() comments = %v, want those of the two attendees", comments)
+	}
+
+	// feedback and hosts go with the event
+	if _, err = repo.DeleteEvent(ctx, event.ID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	if summary = summaryOf(); summary.Count != 0 {
+		t.Errorf("GetFeedbackSummaries() of a deleted event = %+v, want no responses", summary)
+	}
+	if isHost(userIDs[2]) {
+		t.Errorf("HostedEvents() of a deleted event = %s, want none", event.ID)
+	}
+}
@@ -0,0 +1,42 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+// RunInterests checks SetRSVP and SetBookmark, userID has to reference an existing user for implementations that
+// enforce it
+func RunInterests(t *testing.T, repo repository.Repository, hackathonID string, userID string) {
+	ctx := context.Background()
+	setters := map[string]func(ctx context.Context, eventID string, userID string, set bool) error{
+		"SetRSVP":     repo.SetRSVP,
+		"SetBookmark": repo.SetBookmark,
+	}
+	for name, set := range setters {
+		t.Run(name, func(t *testing.T) {
+			event, err := repo.CreateEvent(ctx, newEvent("Conformance "+name, hackathonID))
+			if err != nil {
+				t.Fatalf("CreateEvent() error = %v", err)
+			}
+			// setting and removing are both idempotent
+			for _, value := range []bool{true, true, false, false, true} {
+				if err = set(ctx, event.ID, userID, value); err != nil {
+					t.Fatalf("%s(%v) error = %v", name, value, err)
+				}
+			}
+			if _, err = repo.DeleteEvent(ctx, event.ID); err != nil {
+				t.Fatalf("DeleteEvent() of an event with interest error = %v", err)
+			}
+			if err = set(ctx, event.ID, userID, true); !errors.Is(err, repository.EventNotFound) {
+				t.Errorf("%s() of a deleted event error = %v, want %v", name, err, repository.EventNotFound)
+			}
+			if err = set(ctx, "not a number", userID, true); !errors.Is(err, repository.EventNotFound) {
+				t.Errorf("%s() of an invalid id error = %v, want %v", name, err, repository.EventNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package repositorytest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/outbox"
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+// RunOutbox checks that every mutation of repo that succeeds writes one message to store, which may already hold
+// messages about other events. Whatever store hands out is marked published.
+func RunOutbox(t *testing.T, repo repository.Repository, hackathonID string, store outbox.Store) {
+	ctx := context.Background()
+
+	event, err := repo.CreateEvent(ctx, newEvent("Conformance Outbox", hackathonID))
+	if err != nil {
+		t.Fatalf("CreateEvent() error = %v", err)
+	}
+	name := "Conformance Outbox Renamed"
+	if _, err = repo.UpdateEvent(ctx, event.ID, &model.UpdatedEvent{Name: &name}); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+	if _, err = repo.DeleteEvent(ctx, event.ID); err != nil {
+		t.Fatalf("DeleteEvent() error = %v", err)
+	}
+	// mutations that fail leave nothing behind
+	if _, err = repo.UpdateEvent(ctx, event.ID, &model.UpdatedEvent{Name: &name}); err == nil {
+		t.Fatalf("UpdateEvent() of a deleted event succeeded")
+	}
+	if _, err = repo.DeleteEvent(ctx, event.ID); err == nil {
+		t.Fatalf("DeleteEvent() of a deleted event succeeded")
+	}
+
+	var messages []*outbox.Message
+	for {
+		relayed, err := store.Relay(ctx, 1000, func(ctx context.Context, batch []*outbox.Message) outbox.Result {
+			ids := make([]string, 0, len(batch))
+			for _, message := range batch {
+				if message.AggregateID == event.ID {
+					messages = append(messages, message)
+				}
+				ids = append(ids, message.ID)
+			}
+			return outbox.Result{Published: ids}
+		})
+		if err != nil {
+			t.Fatalf("Relay() error = %v", err)
+		}
+		if relayed < 1000 {
+			break
+		}
+	}
+
+	var topics []string
+	for _, message := range messages {
+		topics = append(topics, message.Topic)
+	}
+	if want := []string{outbox.TopicEventCreated, outbox.TopicEventUpdated, outbox.TopicEventDeleted}; !reflect.DeepEqual(topics, want) {
+		t.Fatalf("outbox got %v, want %v", topics, want)
+	}
+
+	var created, updated model.Event
+	if err = json.Unmarshal(messages[0].Payload, &created); err != nil || created.ID != event.ID || created.Name != event.Name {
+		t.Errorf("%s payload = %s, want the created event", outbox.TopicEventCreated, messages[0].Payload)
+	}
+	if err = json.Unmarshal(messages[1].Payload, &updated); err != nil || updated.ID != event.ID || updated.Name != name {
+		t.Errorf("%s payload = %s, want the updated event", outbox.TopicEventUpdated, messages[1].Payload)
+	}
+	var deleted map[string]string
+	if err = json.Unmarshal(messages[2].Payload, &deleted); err != nil || !reflect.DeepEqual(deleted, map[string]string{"id": event.ID}) {
+		t.Errorf("%s payload = %s, want only the id", outbox.TopicEventDeleted, messages[2].Payload)
+	}
+}
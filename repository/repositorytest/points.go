@@ -0,0 +1,114 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/repository"
+)
+
+// RunPoints checks that check-ins award the points of their event, AdjustPoints, GetLeaderboard and GetPointsAwards.
+// hackathonID must not have awards of other tests and the users have to exist for implementations that enforce it.
+func RunPoints(t *testing.T, repo repository.Repository, hackathonID string, userIDs [3]string) {
+	ctx := context.Background()
+	at := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	create := func(name string, points int) string {
+		t.Helper()
+		input := newEvent(name, hackathonID)
+		input.Points = &points
+		event, err := repo.CreateEvent(ctx, input)
+		if err != nil {
+			t.Fatalf("CreateEvent() error = %v", err)
+		}
+		if event.Points != points {
+			t.Errorf("CreateEvent() points = %d, want %d", event.Points, points)
+		}
+		return event.ID
+	}
+	workshop := create("Conformance Points Workshop", 10)
+	talk := create("Conformance Points Talk", 0)
+	// only later check-ins get the new points
+	updated := 5
+	if event, err := repo.UpdateEvent(ctx, talk, &model.UpdatedEvent{Points: &updated}); err != nil || event.Points != updated {
+		t.Fatalf("UpdateEvent() = %+v, %v, want %d points", event, err, updated)
+	}
+	unscored := create("Conformance Points Social", 0)
+
+	for i, checkIn := range []struct{ eventID, userID string }{
+		{workshop, userIDs[0]}, {workshop, userIDs[1]}, {talk, userIDs[1]}, {talk, userIDs[2]}, {unscored, userIDs[0]},
+	} {
+		if _, err := repo.CheckIn(ctx, checkIn.eventID, checkIn.userID, at.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("CheckIn() error = %v", err)
+		}
+	}
+
+	// ties the first of userIDs, who got to 10 points long before
+	adjustment := &model.PointsAdjustment{HackathonID: hackathonID, UserID: userIDs[2], Points: 5, Reason: "Helped at registration"}
+	award, err := repo.AdjustPoints(ctx, adjustment, userIDs[0])
+	if err != nil {
+		t.Fatalf("AdjustPoints() error = %v", err)
+	}
+	if award.ID == "" || award.Points != 5 || award.Reason != adjustment.Reason || award.EventID != nil || award.AdjustedBy == nil || *award.AdjustedBy != userIDs[0] {
+		t.Errorf("AdjustPoints() = %+v, want 5 points adjusted by %s", award, userIDs[0])
+	}
+	if _, err = repo.AdjustPoints(ctx, &model.PointsAdjustment{HackathonID: hackathonID, UserID: userIDs[2], Reason: "Nothing"}, userIDs[0]); !errors.Is(err, repository.EmptyPointsAdjustment) {
+		t.Errorf("AdjustPoints() of 0 points error = %v, want %v", err, repository.EmptyPointsAdjustment)
+	}
+
+	entries, total, err := repo.GetLeaderboard(ctx, hackathonID, 2, 0)
+	if err != nil {
+		t.Fatalf("GetLeaderboard() error = %v", err)
+	}
+	if total != 3 {
+		t.Errorf("GetLeaderboard() total = %d, want 3", total)
+	}
+	assertLeaderboard(t, entries, []model.LeaderboardEntry{
+		{Rank: 1, UserID: userIDs[1], Points: 15, CompletedAt: at.Add(2 * time.Minute)},
+		{Rank: 2, UserID: userIDs[0], Points: 10, CompletedAt: at},
+	})
+	if entries, _, err = repo.GetLeaderboard(ctx, hackathonID, 2, 2); err != nil || len(entries) != 1 || entries[0].Rank != 3 || entries[0].UserID != userIDs[2] || entries[0].Points != 10 {
+		t.Errorf("GetLeaderboard() after rank 2 = %v, %v, want %s at rank 3", entries, err, userIDs[2])
+	}
+	for _, first := range []int{0, -1} {
+		if entries, total, err = repo.GetLeaderboard(ctx, hackathonID, first, 0); err != nil || len(entries) != 0 || total != 3 {
+			t.Errorf("GetLeaderboard(first %d) = %v, %d, %v, want an empty page of 3", first, entries, total, err)
+		}
+	}
+
+	awards, err := repo.GetPointsAwards(ctx, hackathonID, userIDs[2])
+	if err != nil {
+		t.Fatalf("GetPointsAwards() error = %v", err)
+	}
+	if len(awards) != 2 || awards[0].EventID == nil || *awards[0].EventID != talk || awards[0].Reason != "attendance" || awards[0].Points != 5 ||
+		awards[1].ID != award.ID || awards[1].Reason != adjustment.Reason {
+		t.Errorf("GetPointsAwards() = %v, want the check-in to the talk and then the adjustment", awards)
+	}
+
+	// taking every point away takes the first of userIDs off the leaderboard
+	if _, err = repo.AdjustPoints(ctx, &model.PointsAdjustment{HackathonID: hackathonID, UserID: userIDs[0], Points: -10, Reason: "Disqualified"}, userIDs[1]); err != nil {
+		t.Fatalf("AdjustPoints() error = %v", err)
+	}
+	if _, total, err = repo.GetLeaderboard(ctx, hackathonID, 10, 0); err != nil || total != 2 {
+		t.Errorf("GetLeaderboard() total = %d, %v, want 2", total, err)
+	}
+	if entries, total, err = repo.GetLeaderboard(ctx, "not a number", 10, 0); err != nil || total != 0 || len(entries) != 0 {
+		t.Errorf("GetLeaderboard() of an unknown hackathon = %v, %d, %v, want nothing", entries, total, err)
+	}
+}
+
+func assertLeaderboard(t *testing.T, got []*model.LeaderboardEntry, want []model.LeaderboardEntry) {
+	t.Helper()
+	entries := make([]model.LeaderboardEntry, len(got))
+	for i, entry := range got {
+		entries[i] = *entry
+		// the database may hand back another location
+		entries[i].CompletedAt = entry.CompletedAt.UTC()
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("GetLeaderboard() = %+v, want %+v", entries, want)
+	}
+}
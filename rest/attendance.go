@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/gin-gonic/gin"
+)
+
+// The bucket widths when none is asked for, the same as the defaults of the GraphQL arguments
+const (
+	defaultEventBucketMinutes     = 15
+	defaultHackathonBucketMinutes = 60
+)
+
+// attendanceQuery is what the attendance routes accept besides the id
+type attendanceQuery struct {
+	bucketMinutes int
+	// csv is empty for JSON, otherwise the table to export: events or histogram
+	csv string
+}
+
+func (h *Handler) eventAttendance(c *gin.Context) {
+	query, err := parseAttendanceQuery(c, defaultEventBucketMinutes)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	stats, err := h.asAdmin(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+		return h.resolver.Query().EventAttendance(ctx, c.Param("id"), query.bucketMinutes)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if query.csv == "" {
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+	writeAttendanceCSV(c, "event-"+c.Param("id"), query.csv, []*model.EventAttendanceStats{stats.(*model.EventAttendanceStats)})
+}
+
+func (h *Handler) hackathonAttendance(c *gin.Context) {
+	query, err := parseAttendanceQuery(c, defaultHackathonBucketMinutes)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	stats, err := h.asAdmin(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+		return h.resolver.Query().HackathonAttendance(ctx, c.Param("id"), query.bucketMinutes)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if query.csv == "" {
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+	writeAttendanceCSV(c, "hackathon-"+c.Param("id"), query.csv, stats.(*model.HackathonAttendanceStats).Events)
+}
+
+func parseAttendanceQuery(c *gin.Context, defaultBucketMinutes int) (attendanceQuery, error) {
+	query := attendanceQuery{bucketMinutes: defaultBucketMinutes}
+	if raw, ok := c.GetQuery("bucketMinutes"); ok {
+		var err error
+		if query.bucketMinutes, err = strconv.Atoi(raw); err != nil || query.bucketMinutes < 1 || query.bucketMinutes > graph.MaxBucketMinutes {
+			return query, apperrors.Invalid("bucketMinutes", fmt.Sprintf("bucketMinutes must be between 1 and %d", graph.MaxBucketMinutes))
+		}
+	}
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+	case "csv":
+		query.csv = c.DefaultQuery("table", "events")
+		if query.csv != "events" && query.csv != "histogram" {
+			return query, apperrors.Invalid("table", "table must be events or histogram")
+		}
+	default:
+		return query, apperrors.Invalid("format", "format must be json or csv")
+	}
+	return query, nil
+}
+
+// writeAttendanceCSV sends a row per event, or with the histogram table a row per event and bucket. The name has the id
+// from the path in it, so the file name is quoted or encoded rather than written as it is.
+func writeAttendanceCSV(c *gin.Context, name string, table string, events []*model.EventAttendanceStats) {
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + "-attendance-" + table + ".csv"}))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	if table == "histogram" {
+		_ = writer.Write([]string{"event_id", "bucket_start", "check_ins"})
+		for _, event := range events {
+			for _, bucket := range event.Histogram {
+				_ = writer.Write([]string{event.EventID, bucket.Start.Format(time.RFC3339), strconv.Itoa(bucket.CheckIns)})
+			}
+		}
+	} else {
+		_ = writer.Write([]string{"event_id", "name", "start_date", "check_ins", "rsvps", "rsvps_attended", "conversion_rate", "returning_attendees"})
+		for _, event := range events {
+			_ = writer.Write([]string{
+				event.EventID, spreadsheetSafe(event.Name), event.StartDate.Format(time.RFC3339), strconv.Itoa(event.CheckIns),
+				strconv.Itoa(event.Rsvps), strconv.Itoa(event.RsvpsAttended), strconv.FormatFloat(event.ConversionRate, 'f', 4, 64),
+				strconv.Itoa(event.ReturningAttendees),
+			})
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// spreadsheetSafe keeps an event name from being run as a formula when the export is opened in a spreadsheet
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"html/template"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/gin-gonic/gin"
+)
+
+// display is the page on the presenter's screen, it reloads itself whenever the code rotates
+var display = template.Must(template.New("display").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>Check in to {{.Name}}</title>
+<style>
+body { margin: 0; height: 100vh; display: flex; flex-direction: column; align-items: center; justify-content: center; background: #000; color: #fff; font-family: sans-serif; }
+h1 { font-size: 4vw; font-weight: normal; }
+.code { margin: 0; font-size: 20vw; letter-spacing: 0.1em; font-variant-numeric: tabular-nums; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p class="code">{{.Code}}</p>
+<p>Enter this code to check in, it changes every {{.Step}} seconds</p>
+</body>
+</html>
+`))
+
+// checkInDisplay shows the rotating code of an event as a full screen page. Presenter screens aren't signed in, so
+// instead of a role the route needs a token from the checkInDisplay query, which only admins can run.
+func (h *Handler) checkInDisplay(c *gin.Context) {
+	eventID, err := h.resolver.CheckIn.VerifyDisplay(c.Query("token"))
+	if err == nil && eventID != c.Param("id") {
+		err = checkin.InvalidToken
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	event, err := h.resolver.Entity().FindEventByID(c.Request.Context(), eventID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	code, rotatesAt := h.resolver.CheckIn.RotatingCode(eventID)
+	// the token is in the URL, so it must not leak through the Referer of anything the page loads
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err = display.Execute(c.Writer, map[string]interface{}{
+		"Name":    event.Name,
+		"Code":    code,
+		"Step":    int(h.resolver.CheckIn.CodeStep / time.Second),
+		"Refresh": int(math.Max(1, math.Ceil(time.Until(rotatesAt).Seconds()))),
+	})
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+// Error
+// One entry of an error response, the same code, message and field a GraphQL client gets in its extensions
+type Error struct {
+	Code          string `json:"code"`
+	Message       string `json:"message"`
+	Field         string `json:"field,omitempty"`
+	CorrelationID string `json:"correlationId,omitempty"`
+}
+
+// statuses maps codes to HTTP statuses, codes missing here are answered with a 500
+var statuses = map[string]int{
+	apperrors.CodeNotFound:         http.StatusNotFound,
+	apperrors.CodeValidationFailed: http.StatusUnprocessableEntity,
+	apperrors.CodeConflict:         http.StatusConflict,
+	apperrors.CodeUnauthenticated:  http.StatusUnauthorized,
+	apperrors.CodeForbidden:        http.StatusForbidden,
+	apperrors.CodeRateLimited:      http.StatusTooManyRequests,
+}
+
+// respondError answers with {"errors": [...]}, one entry for each joined error. The status comes from the first one
+// and unexpected errors are masked with a correlation id just like the GraphQL error presenter does.
+func respondError(c *gin.Context, err error) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	ctx := c.Request.Context()
+	body := make([]Error, 0, len(errs))
+	for _, err := range errs {
+		var appErr *apperrors.Error
+		if !errors.As(err, &appErr) || appErr.Code == apperrors.CodeInternal {
+			body = append(body, Error{Code: apperrors.CodeInternal, Message: apperrors.InternalMessage, CorrelationID: apperrors.Report(ctx, err)})
+			continue
+		}
+		slog.WarnContext(ctx, "REST error", "error", err, "code", appErr.Code)
+		body = append(body, Error{Code: appErr.Code, Message: appErr.Error(), Field: appErr.Field})
+	}
+
+	status, ok := statuses[body[0].Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	c.AbortWithStatusJSON(status, gin.H{"errors": body})
+}
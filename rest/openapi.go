@@ -0,0 +1,323 @@
+package rest
+
+import (
+	"strings"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/KnightHacks/knighthacks_events/constraint"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// componentTypes are the GraphQL types the routes send and receive, each becomes a schema component of the same name
+var componentTypes = []string{
+	"Event", "EventsConnection", "PageInfo", "NewEvent", "UpdatedEvent",
+	"EventAttendanceStats", "HackathonAttendanceStats", "AttendanceBucket", "AttendanceFrequency",
+}
+
+// OpenAPI describes the routes as an OpenAPI 3 document. The bodies are generated from the GraphQL types they are
+// decoded into or encoded from, including their @constraint rules, so the document can't drift from the schema.
+func OpenAPI(schema *ast.Schema) map[string]interface{} {
+	schemas := map[string]interface{}{
+		"Errors": object(map[string]interface{}{
+			"errors": map[string]interface{}{
+				"type": "array",
+				"items": object(map[string]interface{}{
+					"code": map[string]interface{}{"type": "string", "enum": []string{
+						apperrors.CodeNotFound, apperrors.CodeValidationFailed, apperrors.CodeConflict,
+						apperrors.CodeUnauthenticated, apperrors.CodeForbidden, apperrors.CodeRateLimited, apperrors.CodeInternal,
+					}},
+					"message":       map[string]interface{}{"type": "string"},
+					"field":         map[string]interface{}{"type": "string", "description": "The input the error is about"},
+					"correlationId": map[string]interface{}{"type": "string", "description": "Ties an INTERNAL error to the service's logs"},
+				}, "code", "message"),
+			},
+		}, "errors"),
+	}
+	for _, name := range componentTypes {
+		if definition := schema.Types[name]; definition != nil {
+			schemas[name] = definitionSchema(definition)
+		}
+	}
+
+	id := map[string]interface{}{"name": "id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}}
+	admin := []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+	attendanceParameters := func(defaultBucketMinutes int) []interface{} {
+		return []interface{}{
+			map[string]interface{}{"name": "bucketMinutes", "in": "query", "description": "Width of the histogram buckets", "schema": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": graph.MaxBucketMinutes, "default": defaultBucketMinutes}},
+			map[string]interface{}{"name": "format", "in": "query", "schema": map[string]interface{}{"type": "string", "enum": []string{"json", "csv"}, "default": "json"}},
+			map[string]interface{}{"name": "table", "in": "query", "description": "What a CSV export has a row for, each event or each bucket of each event", "schema": map[string]interface{}{"type": "string", "enum": []string{"events", "histogram"}, "default": "events"}},
+		}
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "KnightHacks Events",
+			"version": "1",
+		},
+		"paths": map[string]interface{}{
+			"/v1/events": map[string]interface{}{
+				"get": map[string]interface{}{
+					"operationId": "listEvents",
+					"summary":     "Lists events in ascending id order",
+					"parameters": []interface{}{
+						map[string]interface{}{"name": "first", "in": "query", "schema": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": MaxPageSize, "default": DefaultPageSize}},
+						map[string]interface{}{"name": "after", "in": "query", "description": "The endCursor of the previous page", "schema": map[string]interface{}{"type": "string"}},
+					},
+					"responses": responses("200", "EventsConnection", "422"),
+				},
+				"post": map[string]interface{}{
+					"operationId": "createEvent",
+					"summary":     "Creates an event, admins only",
+					"security":    admin,
+					"requestBody": requestBody("NewEvent"),
+					"responses":   responses("201", "Event", "401", "403", "422"),
+				},
+			},
+			"/v1/events/{id}": map[string]interface{}{
+				"parameters": []interface{}{id},
+				"get": map[string]interface{}{
+					"operationId": "getEvent",
+					"responses":   responses("200", "Event", "404"),
+				},
+				"patch": map[string]interface{}{
+					"operationId": "updateEvent",
+					"summary":     "Updates the fields that are given, admins only",
+					"security":    admin,
+					"requestBody": requestBody("UpdatedEvent"),
+					"responses":   responses("200", "Event", "401", "403", "404", "422"),
+				},
+				"delete": map[string]interface{}{
+					"operationId": "deleteEvent",
+					"summary":     "Deletes an event, admins only",
+					"security":    admin,
+					"responses":   responses("204", "", "401", "403", "404"),
+				},
+			},
+			"/v1/events/{id}/check-in-code": map[string]interface{}{
+				"parameters": []interface{}{id},
+				"get": map[string]interface{}{
+					"operationId": "getCheckInCode",
+					"summary":     "Draws a newly signed QR code attendees scan to check in, admins only",
+					"security":    admin,
+					"parameters": []interface{}{
+						map[string]interface{}{"name": "format", "in": "query", "schema": map[string]interface{}{"type": "string", "enum": []string{"png", "svg"}, "default": "png"}},
+						map[string]interface{}{"name": "size", "in": "query", "description": "Width of the PNG in pixels", "schema": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": checkin.MaxPNGSize, "default": checkin.DefaultPNGSize}},
+					},
+					"responses": withImage(responses("200", "", "401", "403", "404", "422")),
+				},
+			},
+			"/v1/events/{id}/check-in-display": map[string]interface{}{
+				"parameters": []interface{}{id},
+				"get": map[string]interface{}{
+					"operationId": "getCheckInDisplay",
+					"summary":     "A full screen page with the rotating check-in code, it reloads whenever the code changes",
+					"parameters": []interface{}{
+						map[string]interface{}{"name": "token", "in": "query", "required": true, "description": "The token of the checkInDisplay query", "schema": map[string]interface{}{"type": "string"}},
+					},
+					"responses": withHTML(responses("200", "", "404", "422")),
+				},
+			},
+			"/v1/events/{id}/attendance": map[string]interface{}{
+				"parameters": []interface{}{id},
+				"get": map[string]interface{}{
+					"operationId": "getEventAttendance",
+					"summary":     "Counts the check-ins of an event, as JSON or CSV, admins only",
+					"security":    admin,
+					"parameters":  attendanceParameters(defaultEventBucketMinutes),
+					"responses":   withCSV(responses("200", "EventAttendanceStats", "401", "403", "404", "422")),
+				},
+			},
+			"/v1/hackathons/{id}/attendance": map[string]interface{}{
+				"parameters": []interface{}{id},
+				"get": map[string]interface{}{
+					"operationId": "getHackathonAttendance",
+					"summary":     "Counts the check-ins of every event of a hackathon, as JSON or CSV, admins only",
+					"security":    admin,
+					"parameters":  attendanceParameters(defaultHackathonBucketMinutes),
+					"responses":   withCSV(responses("200", "HackathonAttendanceStats", "401", "403", "422")),
+				},
+			},
+			"/v1/openapi.json": map[string]interface{}{
+				"get": map[string]interface{}{
+					"operationId": "getOpenAPI",
+					"summary":     "This document",
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{"description": "OK", "content": map[string]interface{}{"application/json": map[string]interface{}{}}},
+					},
+				},
+			},
+		},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// definitionSchema converts an object or input object, non-null fields are required
+func definitionSchema(definition *ast.Definition) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	for _, field := range definition.Fields {
+		if strings.HasPrefix(field.Name, "__") || resolverOnly(field) {
+			continue
+		}
+		property := typeSchema(field.Type)
+		if field.Description != "" {
+			property["description"] = field.Description
+		}
+		if rules, ok, _ := constraint.RulesOf(field); ok {
+			if rules.MinLength != nil {
+				property["minLength"] = *rules.MinLength
+			}
+			if rules.MaxLength != nil {
+				property["maxLength"] = *rules.MaxLength
+			}
+			if rules.Pattern != nil {
+				property["pattern"] = *rules.Pattern
+			}
+			if rules.Min != nil {
+				property["minimum"] = *rules.Min
+			}
+			if rules.Max != nil {
+				property["maximum"] = *rules.Max
+			}
+		}
+		properties[field.Name] = property
+		if field.Type.NonNull {
+			required = append(required, field.Name)
+		}
+	}
+
+	converted := object(properties, required...)
+	if definition.Description != "" {
+		converted["description"] = definition.Description
+	}
+	if definition.Kind == ast.InputObject {
+		converted["additionalProperties"] = false
+	}
+	return converted
+}
+
+// resolverOnly reports fields marked @goField(forceResolver: true), only GraphQL resolves them so REST never sends them
+func resolverOnly(field *ast.FieldDefinition) bool {
+	goField := field.Directives.ForName("goField")
+	if goField == nil {
+		return false
+	}
+	forceResolver := goField.Arguments.ForName("forceResolver")
+	return forceResolver != nil && forceResolver.Value.Raw == "true"
+}
+
+// typeSchema converts a field type, objects are referenced by name and nullable fields are marked as such
+func typeSchema(t *ast.Type) map[string]interface{} {
+	var converted map[string]interface{}
+	switch {
+	case t.Elem != nil:
+		converted = map[string]interface{}{"type": "array", "items": typeSchema(t.Elem)}
+	case t.NamedType == "Int":
+		converted = map[string]interface{}{"type": "integer"}
+	case t.NamedType == "Float":
+		converted = map[string]interface{}{"type": "number"}
+	case t.NamedType == "Boolean":
+		converted = map[string]interface{}{"type": "boolean"}
+	case t.NamedType == "Time":
+		converted = map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.NamedType == "String" || t.NamedType == "ID":
+		converted = map[string]interface{}{"type": "string"}
+	default:
+		// a $ref can't have siblings in OpenAPI 3.0, so a nullable reference has to be wrapped
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.NamedType}
+		if t.NonNull {
+			return ref
+		}
+		return map[string]interface{}{"allOf": []interface{}{ref}, "nullable": true}
+	}
+	if !t.NonNull {
+		converted["nullable"] = true
+	}
+	return converted
+}
+
+func object(properties map[string]interface{}, required ...string) map[string]interface{} {
+	converted := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		converted["required"] = required
+	}
+	return converted
+}
+
+func requestBody(component string) map[string]interface{} {
+	return map[string]interface{}{
+		"required": true,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/" + component}},
+		},
+	}
+}
+
+// responses describes the success status with its body, which is empty when component is, and the error statuses.
+// Every route that uses it is rate limited, so 429 is always included
+func responses(status string, component string, errorStatuses ...string) map[string]interface{} {
+	success := map[string]interface{}{"description": "OK"}
+	if component != "" {
+		success["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/" + component}},
+		}
+	}
+	converted := map[string]interface{}{status: success}
+	errorBody := map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/Errors"}},
+	}
+	for _, errorStatus := range append(errorStatuses, "429", "500") {
+		converted[errorStatus] = map[string]interface{}{"description": descriptions[errorStatus], "content": errorBody}
+	}
+	converted["429"].(map[string]interface{})["headers"] = map[string]interface{}{
+		"Retry-After": map[string]interface{}{"description": "Seconds until the request is allowed again", "schema": map[string]interface{}{"type": "integer"}},
+	}
+	return converted
+}
+
+// withImage gives the success response of responses a PNG or SVG body and the header telling when its token expires
+func withImage(responses map[string]interface{}) map[string]interface{} {
+	responses["200"] = map[string]interface{}{
+		"description": "OK",
+		"headers": map[string]interface{}{
+			HeaderCheckInExpiresAt: map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "date-time"}},
+		},
+		"content": map[string]interface{}{
+			"image/png":     map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}},
+			"image/svg+xml": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+		},
+	}
+	return responses
+}
+
+// withCSV lets the success response of responses be a CSV export as well
+func withCSV(responses map[string]interface{}) map[string]interface{} {
+	success := responses["200"].(map[string]interface{})
+	success["content"].(map[string]interface{})["text/csv"] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+	return responses
+}
+
+// withHTML gives the success response of responses an HTML body
+func withHTML(responses map[string]interface{}) map[string]interface{} {
+	responses["200"] = map[string]interface{}{
+		"description": "OK",
+		"content":     map[string]interface{}{"text/html": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}},
+	}
+	return responses
+}
+
+var descriptions = map[string]string{
+	"401": "The route needs a token",
+	"403": "The caller isn't an admin",
+	"404": "The event doesn't exist",
+	"422": "The request is invalid, every violation is listed",
+	"429": "The caller is rate limited, the code is RATE_LIMITED",
+	"500": "Unexpected error, see the correlationId",
+}
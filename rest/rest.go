@@ -0,0 +1,235 @@
+// Package rest serves events as versioned JSON routes for integrations that can't speak GraphQL. Every route calls the
+// GraphQL resolvers, so the repository, role checks, input rules, cursors and error codes are exactly the same.
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/KnightHacks/knighthacks_events/constraint"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+	"github.com/KnightHacks/knighthacks_shared/models"
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	// DefaultPageSize is used when a list request doesn't ask for a page size
+	DefaultPageSize = 20
+	// MaxPageSize is the same as for the events query over GraphQL
+	MaxPageSize = graph.MaxPageSize
+	// maxBodySize is far more than any event needs
+	maxBodySize = 1 << 20
+	// HeaderCheckInExpiresAt tells when the token of a check-in code image expires, in RFC 3339
+	HeaderCheckInExpiresAt = "X-Check-In-Expires-At"
+)
+
+// Handler
+// Serves /v1, construct it with NewHandler
+type Handler struct {
+	resolver *graph.Resolver
+	hasRole  identity.HasRoleFunc
+	schema   *ast.Schema
+	openAPI  []byte
+}
+
+// NewHandler builds the routes around the resolver and @hasRole implementation the GraphQL server uses,
+// schema is the executable schema's, it supplies the input rules and the types of the OpenAPI document
+func NewHandler(resolver *graph.Resolver, hasRole identity.HasRoleFunc, schema *ast.Schema) (*Handler, error) {
+	document, err := json.Marshal(OpenAPI(schema))
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{resolver: resolver, hasRole: hasRole, schema: schema, openAPI: document}, nil
+}
+
+// Register adds the routes to router, which needs the same auth middleware as /query.
+// Each route is limited as the GraphQL operation it stands for, limiter may be nil when rate limiting is disabled.
+func (h *Handler) Register(router gin.IRouter, limiter *ratelimit.Limiter) {
+	limit := func(operation string) gin.HandlerFunc {
+		if limiter == nil {
+			return func(c *gin.Context) {}
+		}
+		return func(c *gin.Context) {
+			if rejection := limiter.Allow(c.Request.Context(), operation); rejection != nil {
+				respondError(c, apperrors.Wrap(apperrors.CodeRateLimited, rejection))
+			}
+		}
+	}
+
+	v1 := router.Group("/v1")
+	v1.GET("/openapi.json", h.document)
+	v1.GET("/events", limit("events"), h.listEvents)
+	v1.POST("/events", limit("createEvent"), h.createEvent)
+	v1.GET("/events/:id", limit("_entities"), h.getEvent)
+	v1.PATCH("/events/:id", limit("updateEvent"), h.updateEvent)
+	v1.DELETE("/events/:id", limit("deleteEvent"), h.deleteEvent)
+	v1.GET("/events/:id/check-in-code", limit("checkInCode"), h.checkInCode)
+	v1.GET("/events/:id/check-in-display", limit("checkInDisplay"), h.checkInDisplay)
+	v1.GET("/events/:id/attendance", limit("eventAttendance"), h.eventAttendance)
+	v1.GET("/hackathons/:id/attendance", limit("hackathonAttendance"), h.hackathonAttendance)
+}
+
+func (h *Handler) document(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", h.openAPI)
+}
+
+func (h *Handler) listEvents(c *gin.Context) {
+	first := DefaultPageSize
+	if raw, ok := c.GetQuery("first"); ok {
+		var err error
+		if first, err = strconv.Atoi(raw); err != nil || first < 1 || first > MaxPageSize {
+			respondError(c, apperrors.Invalid("first", fmt.Sprintf("first must be between 1 and %d", MaxPageSize)))
+			return
+		}
+	}
+	var after *string
+	if raw, ok := c.GetQuery("after"); ok {
+		after = &raw
+	}
+
+	connection, err := h.resolver.Query().Events(c.Request.Context(), first, after)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, connection)
+}
+
+func (h *Handler) getEvent(c *gin.Context) {
+	event, err := h.resolver.Entity().FindEventByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, event)
+}
+
+func (h *Handler) createEvent(c *gin.Context) {
+	event, err := h.asAdmin(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+		var input model.NewEvent
+		if err := h.bind(c, "NewEvent", &input); err != nil {
+			return nil, err
+		}
+		return h.resolver.Mutation().CreateEvent(ctx, input)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, event)
+}
+
+func (h *Handler) updateEvent(c *gin.Context) {
+	event, err := h.asAdmin(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+		var input model.UpdatedEvent
+		if err := h.bind(c, "UpdatedEvent", &input); err != nil {
+			return nil, err
+		}
+		return h.resolver.Mutation().UpdateEvent(ctx, c.Param("id"), input)
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, event)
+}
+
+func (h *Handler) deleteEvent(c *gin.Context) {
+	_, err := h.asAdmin(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+		return h.resolver.Mutation().DeleteEvent(ctx, c.Param("id"))
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// checkInCode draws a freshly signed check-in code as a PNG, or as an SVG with ?format=svg, for displays at the door.
+// They have to fetch a new one before the time in HeaderCheckInExpiresAt.
+func (h *Handler) checkInCode(c *gin.Context) {
+	format := c.DefaultQuery("format", "png")
+	size := checkin.DefaultPNGSize
+	if raw, ok := c.GetQuery("size"); ok {
+		var err error
+		if size, err = strconv.Atoi(raw); err != nil || size < 1 || size > checkin.MaxPNGSize {
+			respondError(c, apperrors.Invalid("size", fmt.Sprintf("size must be between 1 and %d", checkin.MaxPNGSize)))
+			return
+		}
+	}
+	if format != "png" && format != "svg" {
+		respondError(c, apperrors.Invalid("format", "format must be png or svg"))
+		return
+	}
+
+	code, err := h.asAdmin(c.Request.Context(), func(ctx context.Context) (interface{}, error) {
+		return h.resolver.Query().CheckInCode(ctx, c.Param("id"))
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	token := code.(*checkin.Code).Token
+	var image []byte
+	contentType := "image/png"
+	if format == "svg" {
+		image, err = checkin.SVG(token)
+		contentType = "image/svg+xml"
+	} else {
+		image, err = checkin.PNG(token, size)
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header(HeaderCheckInExpiresAt, code.(*checkin.Code).ExpiresAt.Format(time.RFC3339))
+	c.Data(http.StatusOK, contentType, image)
+}
+
+// asAdmin runs next behind the same @hasRole(role: ADMIN) check as the mutations, before the body is even read
+func (h *Handler) asAdmin(ctx context.Context, next func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	return h.hasRole(ctx, nil, next, models.RoleAdmin)
+}
+
+// bind decodes the body into target after checking it against the rules of the GraphQL input type it stands for,
+// all violations are returned together
+func (h *Handler) bind(c *gin.Context, inputType string, target interface{}) error {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize))
+	if err != nil {
+		return apperrors.Invalid("", "unable to read the body: "+err.Error())
+	}
+	var input map[string]interface{}
+	if err = json.Unmarshal(body, &input); err != nil || input == nil {
+		return apperrors.Invalid("", "the body must be a JSON object")
+	}
+
+	violations, err := constraint.CheckInput(h.schema.Types[inputType], input)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		errs := make([]error, len(violations))
+		for i, violation := range violations {
+			errs[i] = violation
+		}
+		return errors.Join(errs...)
+	}
+
+	if err = json.Unmarshal(body, target); err != nil {
+		return apperrors.Invalid("", fmt.Sprintf("the body isn't a valid %s: %v", inputType, err))
+	}
+	return nil
+}
@@ -0,0 +1,425 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/checkin"
+	"github.com/KnightHacks/knighthacks_events/graph"
+	"github.com/KnightHacks/knighthacks_events/graph/graphtest"
+	"github.com/KnightHacks/knighthacks_events/graph/model"
+	"github.com/KnightHacks/knighthacks_events/identity"
+	"github.com/KnightHacks/knighthacks_events/ratelimit"
+	"github.com/KnightHacks/knighthacks_events/repository"
+	"github.com/KnightHacks/knighthacks_events/rest"
+	"github.com/KnightHacks/knighthacks_events/webhooks/webhookstest"
+	"github.com/KnightHacks/knighthacks_shared/auth"
+	"github.com/KnightHacks/knighthacks_shared/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+const newEvent = `{"name": "Opening Ceremony", "start_date": "2023-02-03T18:00:00Z", "end_date": "2023-02-03T19:00:00Z",
+	"description": "Kick off", "location": "UCF", "hackathonId": "1"}`
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		as     *auth.UserClaims
+		method string
+		path   string
+		body   string
+		// seed is how many events exist beforehand, they get the ids 1 through seed
+		seed       int
+		wantStatus int
+		// wantBody is compared as JSON, it's ignored when empty
+		wantBody  string
+		wantCodes []string
+	}{
+		{
+			name:       "first page",
+			method:     http.MethodGet,
+			path:       "/v1/events?first=2",
+			seed:       3,
+			wantStatus: http.StatusOK,
+			wantBody: `{"totalCount": 3, "pageInfo": {"startCursor": "` + pagination.EncodeCursor("1") + `", "endCursor": "` + pagination.EncodeCursor("2") + `"},
+				"events": [` + event(1) + `, ` + event(2) + `]}`,
+		},
+		{
+			name:       "page after a cursor",
+			method:     http.MethodGet,
+			path:       "/v1/events?first=2&after=" + pagination.EncodeCursor("2"),
+			seed:       3,
+			wantStatus: http.StatusOK,
+			wantBody: `{"totalCount": 3, "pageInfo": {"startCursor": "` + pagination.EncodeCursor("3") + `", "endCursor": "` + pagination.EncodeCursor("3") + `"},
+				"events": [` + event(3) + `]}`,
+		},
+		{name: "page size too large", method: http.MethodGet, path: "/v1/events?first=101", wantStatus: http.StatusUnprocessableEntity, wantCodes: []string{apperrors.CodeValidationFailed}},
+		{name: "invalid cursor", method: http.MethodGet, path: "/v1/events?after=not%20a%20cursor", wantStatus: http.StatusUnprocessableEntity, wantCodes: []string{apperrors.CodeValidationFailed}},
+		{name: "get", method: http.MethodGet, path: "/v1/events/2", seed: 2, wantStatus: http.StatusOK, wantBody: event(2)},
+		{name: "get missing", method: http.MethodGet, path: "/v1/events/2", seed: 1, wantStatus: http.StatusNotFound, wantCodes: []string{apperrors.CodeNotFound}},
+		{
+			name:       "create as an admin",
+			as:         graphtest.Admin,
+			method:     http.MethodPost,
+			path:       "/v1/events",
+			body:       newEvent,
+			seed:       1,
+			wantStatus: http.StatusCreated,
+			wantBody: `{"id": "2", "name": "Opening Ceremony", "start_date": "2023-02-03T18:00:00Z", "end_date": "2023-02-03T19:00:00Z",
+				"description": "Kick off", "location": "UCF", "points": 0}`,
+		},
+		{name: "create as a hacker", as: graphtest.Normal, method: http.MethodPost, path: "/v1/events", body: newEvent, wantStatus: http.StatusForbidden, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "create anonymously", method: http.MethodPost, path: "/v1/events", body: newEvent, wantStatus: http.StatusUnauthorized, wantCodes: []string{apperrors.CodeUnauthenticated}},
+		{
+			name:       "create reports every violation",
+			as:         graphtest.Admin,
+			method:     http.MethodPost,
+			path:       "/v1/events",
+			body:       `{"name": "", "start_date": "2023-02-03T18:00:00Z", "end_date": "2023-02-03T19:00:00Z", "location": "UCF", "hackathonId": "one", "color": "red"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCodes:  []string{apperrors.CodeValidationFailed, apperrors.CodeValidationFailed, apperrors.CodeValidationFailed, apperrors.CodeValidationFailed},
+		},
+		{name: "create with a body that isn't an object", as: graphtest.Admin, method: http.MethodPost, path: "/v1/events", body: `[]`, wantStatus: http.StatusUnprocessableEntity, wantCodes: []string{apperrors.CodeValidationFailed}},
+		{
+			name:       "patch",
+			as:         graphtest.Admin,
+			method:     http.MethodPatch,
+			path:       "/v1/events/1",
+			body:       `{"name": "Closing Ceremony"}`,
+			seed:       1,
+			wantStatus: http.StatusOK,
+			wantBody: `{"id": "1", "name": "Closing Ceremony", "start_date": "2023-02-03T18:00:00Z", "end_date": "2023-02-03T20:00:00Z",
+				"description": "Event 1 Description", "location": "UCF", "points": 0}`,
+		},
+		{name: "patch missing", as: graphtest.Admin, method: http.MethodPatch, path: "/v1/events/2", body: `{"name": "Closing Ceremony"}`, seed: 1, wantStatus: http.StatusNotFound, wantCodes: []string{apperrors.CodeNotFound}},
+		{name: "patch as a sponsor", as: graphtest.Sponsor, method: http.MethodPatch, path: "/v1/events/1", body: `{"name": "Closing Ceremony"}`, seed: 1, wantStatus: http.StatusForbidden, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "delete", as: graphtest.Admin, method: http.MethodDelete, path: "/v1/events/1", seed: 1, wantStatus: http.StatusNoContent},
+		{name: "delete missing", as: graphtest.Admin, method: http.MethodDelete, path: "/v1/events/1", wantStatus: http.StatusNotFound, wantCodes: []string{apperrors.CodeNotFound}},
+		{name: "check-in code as a hacker", as: graphtest.Normal, method: http.MethodGet, path: "/v1/events/1/check-in-code", seed: 1, wantStatus: http.StatusForbidden, wantCodes: []string{apperrors.CodeForbidden}},
+		{name: "check-in code of a missing event", as: graphtest.Admin, method: http.MethodGet, path: "/v1/events/2/check-in-code", seed: 1, wantStatus: http.StatusNotFound, wantCodes: []string{apperrors.CodeNotFound}},
+		{name: "check-in code as a GIF", as: graphtest.Admin, method: http.MethodGet, path: "/v1/events/1/check-in-code?format=gif", seed: 1, wantStatus: http.StatusUnprocessableEntity, wantCodes: []string{apperrors.CodeValidationFailed}},
+		{name: "check-in code too large", as: graphtest.Admin, method: http.MethodGet, path: "/v1/events/1/check-in-code?size=4096", seed: 1, wantStatus: http.StatusUnprocessableEntity, wantCodes: []string{apperrors.CodeValidationFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryRepository()
+			seed(t, repo, tt.seed)
+			recorder := serve(t, repo, tt.as, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			var errorsBody struct {
+				Errors []rest.Error `json:"errors"`
+			}
+			var codes []string
+			if err := json.Unmarshal(recorder.Body.Bytes(), &errorsBody); err == nil {
+				for _, err := range errorsBody.Errors {
+					codes = append(codes, err.Code)
+				}
+			}
+			if !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("error codes = %v, want %v", codes, tt.wantCodes)
+			}
+			if tt.wantBody != "" {
+				assertJSON(t, recorder.Body.Bytes(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandler_CheckInCode(t *testing.T) {
+	for format, contentType := range map[string]string{"png": "image/png", "svg": "image/svg+xml"} {
+		t.Run(format, func(t *testing.T) {
+			repo := repository.NewMemoryRepository()
+			seed(t, repo, 1)
+			recorder := serve(t, repo, graphtest.Admin, httptest.NewRequest(http.MethodGet, "/v1/events/1/check-in-code?size=128&format="+format, nil))
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != contentType {
+				t.Errorf("Content-Type = %q, want %q", got, contentType)
+			}
+			if got := recorder.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+			expiresAt, err := time.Parse(time.RFC3339, recorder.Header().Get(rest.HeaderCheckInExpiresAt))
+			if err != nil || !expiresAt.After(time.Now()) {
+				t.Errorf("%s = %q, want a time in the future", rest.HeaderCheckInExpiresAt, recorder.Header().Get(rest.HeaderCheckInExpiresAt))
+			}
+			if recorder.Body.Len() == 0 {
+				t.Errorf("body is empty, want the QR code")
+			}
+		})
+	}
+}
+
+func TestHandler_CheckInDisplay(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	seed(t, repo, 2)
+	signer := checkin.NewSigner(graphtest.Secret, time.Minute, 0)
+	token := signer.IssueDisplay("1").Token
+
+	recorder := serve(t, repo, nil, httptest.NewRequest(http.MethodGet, "/v1/events/1/check-in-display?token="+token, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+	}
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", got)
+	}
+	for _, header := range []string{"Cache-Control", "Referrer-Policy", "Content-Security-Policy"} {
+		if recorder.Header().Get(header) == "" {
+			t.Errorf("%s is missing", header)
+		}
+	}
+	if code, _ := signer.RotatingCode("1"); !strings.Contains(recorder.Body.String(), code) {
+		t.Errorf("body = %s, want the code %s", recorder.Body, code)
+	}
+
+	for name, path := range map[string]string{
+		"another event's token": "/v1/events/2/check-in-display?token=" + token,
+		"a check-in token":      "/v1/events/1/check-in-display?token=" + signer.Issue("1").Token,
+		"no token":              "/v1/events/1/check-in-display",
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder := serve(t, repo, nil, httptest.NewRequest(http.MethodGet, path, nil))
+			if recorder.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want %d", recorder.Code, http.StatusUnprocessableEntity)
+			}
+		})
+	}
+}
+
+func TestHandler_Attendance(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepository()
+	seed(t, repo, 1)
+	// a name a spreadsheet would run
+	formula, err := repo.CreateEvent(ctx, &model.NewEvent{HackathonID: "1", Name: "=HYPERLINK(\"https://example.com\")", StartDate: time.Date(2023, time.February, 3, 21, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("unable to seed events: %v", err)
+	}
+	for i, eventID := range []string{"1", formula.ID} {
+		if _, err = repo.CheckIn(ctx, eventID, "1", time.Date(2023, time.February, 3, 18+3*i, 5, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("CheckIn() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		as          *auth.UserClaims
+		path        string
+		wantStatus  int
+		contentType string
+		wantBody    string
+		// disposition is the Content-Disposition header, unless it is empty
+		disposition string
+	}{
+		{
+			name: "an event as JSON", as: graphtest.Admin, path: "/v1/events/1/attendance?bucketMinutes=60", wantStatus: http.StatusOK, contentType: "application/json",
+			wantBody: `"histogram":[{"start":"2023-02-03T18:00:00Z","checkIns":1}]`,
+		},
+		{
+			name: "a hackathon as CSV", as: graphtest.Admin, path: "/v1/hackathons/1/attendance?format=csv", wantStatus: http.StatusOK, contentType: "text/csv",
+			disposition: "attachment; filename=hackathon-1-attendance-events.csv",
+			wantBody: "event_id,name,start_date,check_ins,rsvps,rsvps_attended,conversion_rate,returning_attendees\n" +
+				"1,Event 1,2023-02-03T18:00:00Z,1,0,0,0.0000,0\n" +
+				"2,\"'=HYPERLINK(\"\"https://example.com\"\")\",2023-02-03T21:00:00Z,1,0,0,0.0000,1\n",
+		},
+		{
+			name: "a histogram as CSV", as: graphtest.Admin, path: "/v1/events/1/attendance?format=csv&table=histogram", wantStatus: http.StatusOK, contentType: "text/csv",
+			wantBody: "event_id,bucket_start,check_ins\n1,2023-02-03T18:00:00Z,1\n",
+		},
+		{
+			name: "an id that isn't a file name", as: graphtest.Admin, path: "/v1/hackathons/1%22%3B%20x%0D%0AX-Injected:%20y/attendance?format=csv", wantStatus: http.StatusOK,
+			contentType: "text/csv", disposition: "attachment; filename*=utf-8''hackathon-1%22%3B%20x%0D%0AX-Injected%3A%20y-attendance-events.csv",
+		},
+		{name: "an unknown format", as: graphtest.Admin, path: "/v1/events/1/attendance?format=xlsx", wantStatus: http.StatusUnprocessableEntity},
+		{name: "an unknown table", as: graphtest.Admin, path: "/v1/events/1/attendance?format=csv&table=users", wantStatus: http.StatusUnprocessableEntity},
+		{name: "buckets of no minutes", as: graphtest.Admin, path: "/v1/hackathons/1/attendance?bucketMinutes=0", wantStatus: http.StatusUnprocessableEntity},
+		{name: "a missing event", as: graphtest.Admin, path: "/v1/events/42/attendance", wantStatus: http.StatusNotFound},
+		{name: "as a sponsor", as: graphtest.Sponsor, path: "/v1/hackathons/1/attendance", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(t, repo, tt.as, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", got, tt.contentType)
+			}
+			if !strings.Contains(recorder.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", recorder.Body, tt.wantBody)
+			}
+			if got := recorder.Header().Get("Content-Disposition"); tt.disposition != "" && got != tt.disposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, tt.disposition)
+			}
+		})
+	}
+}
+
+func TestHandler_OpenAPI(t *testing.T) {
+	recorder := serve(t, repository.NewMemoryRepository(), nil, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	var document struct {
+		OpenAPI    string                            `json:"openapi"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                          `json:"required"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatalf("unable to decode document: %v", err)
+	}
+	if document.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q, want 3.0.3", document.OpenAPI)
+	}
+	for path, methods := range map[string][]string{"/v1/events": {"get", "post"}, "/v1/events/{id}": {"get", "patch", "delete"}, "/v1/events/{id}/check-in-code": {"get"}, "/v1/events/{id}/check-in-display": {"get"},
+		"/v1/events/{id}/attendance": {"get"}, "/v1/hackathons/{id}/attendance": {"get"},
+	} {
+		for _, method := range methods {
+			if _, ok := document.Paths[path][method]; !ok {
+				t.Errorf("%s %s is missing", method, path)
+			}
+		}
+	}
+
+	newEvent := document.Components.Schemas["NewEvent"]
+	wantRequired := []string{"name", "start_date", "end_date", "description", "location", "hackathonId"}
+	if !reflect.DeepEqual(newEvent.Required, wantRequired) {
+		t.Errorf("NewEvent required = %v, want %v", newEvent.Required, wantRequired)
+	}
+	if got := newEvent.Properties["name"]["maxLength"]; got != float64(100) {
+		t.Errorf("NewEvent.name maxLength = %v, want 100", got)
+	}
+	if got := newEvent.Properties["hackathonId"]["pattern"]; got != "^[0-9]+$" {
+		t.Errorf("NewEvent.hackathonId pattern = %v, want ^[0-9]+$", got)
+	}
+	if got := newEvent.Properties["start_date"]["format"]; got != "date-time" {
+		t.Errorf("NewEvent.start_date format = %v, want date-time", got)
+	}
+	if _, ok := document.Components.Schemas["Event"].Properties["feedbackSummary"]; ok {
+		t.Error("Event has feedbackSummary, which only GraphQL resolves")
+	}
+	responses, _ := document.Paths["/v1/events"]["get"].(map[string]interface{})["responses"].(map[string]interface{})
+	if _, ok := responses["429"]; !ok {
+		t.Errorf("GET /v1/events responses = %v, want a 429", responses)
+	}
+}
+
+func TestHandler_RateLimited(t *testing.T) {
+	rules, err := ratelimit.ParseRules("events=1/h")
+	if err != nil {
+		t.Fatalf("ParseRules() error = %v", err)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), rules)
+	resolver := &graph.Resolver{Repository: repository.NewMemoryRepository()}
+	handler, err := rest.NewHandler(resolver, graphtest.HasRole, graph.NewExecutableSchema(resolver, graphtest.HasRole).Schema())
+	if err != nil {
+		t.Fatalf("unable to build handler: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(limiter.Middleware())
+	handler.Register(router, limiter)
+
+	var recorder *httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/events", nil))
+	}
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	if retryAfter := recorder.Header().Get("Retry-After"); retryAfter == "" {
+		t.Error("Retry-After header is missing")
+	}
+	var body struct {
+		Errors []rest.Error `json:"errors"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || len(body.Errors) != 1 || body.Errors[0].Code != apperrors.CodeRateLimited {
+		t.Errorf("body = %s, want a single %s error", recorder.Body, apperrors.CodeRateLimited)
+	}
+}
+
+// serve sends request through a router with the REST routes, as stands in for the auth middleware
+func serve(t *testing.T, repo repository.Repository, as *auth.UserClaims, request *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	resolver := &graph.Resolver{
+		Repository:      repo,
+		Webhooks:        webhookstest.NewMemoryStore(),
+		CheckIn:         checkin.NewSigner(graphtest.Secret, time.Minute, 0),
+		CheckInAttempts: checkin.NewAttempts(ratelimit.NewMemoryStore(), graphtest.CodeAttempts),
+	}
+	handler, err := rest.NewHandler(resolver, graphtest.HasRole, graph.NewExecutableSchema(resolver, graphtest.HasRole).Schema())
+	if err != nil {
+		t.Fatalf("unable to build handler: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if as != nil {
+			c.Request = c.Request.WithContext(identity.WithClaims(c.Request.Context(), as))
+		}
+	})
+	handler.Register(router, nil)
+
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func seed(t *testing.T, repo repository.Repository, count int) {
+	t.Helper()
+	for i := 1; i <= count; i++ {
+		if _, err := repo.CreateEvent(context.Background(), &model.NewEvent{
+			HackathonID: "1",
+			Name:        "Event " + strconv.Itoa(i),
+			StartDate:   time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC),
+			EndDate:     time.Date(2023, time.February, 3, 20, 0, 0, 0, time.UTC),
+			Description: "Event " + strconv.Itoa(i) + " Description",
+			Location:    "UCF",
+		}); err != nil {
+			t.Fatalf("unable to seed events: %v", err)
+		}
+	}
+}
+
+// event is how seed's i-th event is encoded
+func event(i int) string {
+	return `{"id": "` + strconv.Itoa(i) + `", "name": "Event ` + strconv.Itoa(i) + `", "start_date": "2023-02-03T18:00:00Z",
+		"end_date": "2023-02-03T20:00:00Z", "description": "Event ` + strconv.Itoa(i) + ` Description", "location": "UCF", "points": 0}`
+}
+
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("unable to decode body %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("unable to decode want %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Extension
+// Wraps every GraphQL operation in a span with a child span for each resolver, fields that are plain struct
+// reads are skipped so the traces stay readable
+type Extension struct{}
+
+var _ interface {
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+	graphql.HandlerExtension
+} = Extension{}
+
+func (e Extension) ExtensionName() string {
+	return "Tracing"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	rc := graphql.GetOperationContext(ctx)
+	operationName := rc.OperationName
+	operationType := "unknown"
+	if rc.Operation != nil {
+		operationName = rc.Operation.Name
+		operationType = string(rc.Operation.Operation)
+	}
+	spanName := operationType
+	if operationName != "" {
+		spanName += " " + operationName
+	}
+
+	// the document is left out on purpose, it carries the literals clients inline instead of passing variables
+	ctx, span := tracer().Start(ctx, spanName, trace.WithAttributes(
+		semconv.GraphqlOperationName(operationName),
+		semconv.GraphqlOperationTypeKey.String(operationType),
+	))
+	defer span.End()
+
+	response := next(ctx)
+	if response != nil && len(response.Errors) > 0 {
+		span.SetStatus(codes.Error, response.Errors.Error())
+	}
+	return response
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !(fc.IsResolver || fc.IsMethod) {
+		return next(ctx)
+	}
+
+	ctx, span := tracer().Start(ctx, fc.Object+"."+fc.Field.Name, trace.WithAttributes(
+		attribute.String("graphql.field.path", fc.Path().String()),
+	))
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+func TestExtension_InterceptResponse(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	rc := &graphql.OperationContext{
+		RawQuery:  `query Event { event(id: "42") { name } }`,
+		Operation: &ast.OperationDefinition{Name: "Event", Operation: ast.Query},
+	}
+	ctx := graphql.WithOperationContext(context.Background(), rc)
+	Extension{}.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{}
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(spans))
+	}
+	if name := spans[0].Name(); name != "query Event" {
+		t.Errorf("span name = %q, want %q", name, "query Event")
+	}
+	attributes := map[string]string{}
+	for _, attribute := range spans[0].Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	if attributes[string(semconv.GraphqlOperationNameKey)] != "Event" || attributes[string(semconv.GraphqlOperationTypeKey)] != "query" {
+		t.Errorf("attributes = %v, want the operation name and type", attributes)
+	}
+	if document, ok := attributes[string(semconv.GraphqlDocumentKey)]; ok {
+		t.Errorf("attributes recorded the document %q, its literals must never be recorded", document)
+	}
+}
@@ -0,0 +1,168 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy
+// How failed deliveries are retried, the n-th retry waits BaseDelay * 2^(n-1) capped at MaxDelay
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt, the delivery fails for good after the last one
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Delay is how long to wait before retrying after attempts failed attempts
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// Dispatcher
+// Posts due deliveries from a Store, any number of dispatchers can share one store
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	policy RetryPolicy
+	// BatchSize is how many deliveries are claimed at once
+	BatchSize int
+	// LeaseMargin is added to the time a whole batch may take to post, see lease
+	LeaseMargin time.Duration
+	now         func() time.Time
+}
+
+// NewDispatcher posts deliveries with client, whose timeout bounds how long an endpoint gets to respond
+func NewDispatcher(store Store, client *http.Client, policy RetryPolicy) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		client:      client,
+		policy:      policy,
+		BatchSize:   20,
+		LeaseMargin: time.Minute,
+		now:         time.Now,
+	}
+}
+
+// Run dispatches due deliveries every interval until ctx is cancelled, a full batch is followed by the next one
+// straight away so a backlog drains as fast as endpoints respond
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		dispatched, err := d.DispatchDue(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Unable to dispatch webhooks", "error", err)
+		}
+		if err == nil && dispatched == d.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue claims one batch of due deliveries and posts each of them, it returns how many it claimed
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	now := d.now()
+	jobs, err := d.store.Claim(ctx, now, now.Add(d.lease()), d.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, job := range jobs {
+		d.deliver(ctx, job)
+	}
+	return len(jobs), nil
+}
+
+// lease is how long a claimed batch is hidden from other dispatchers, its deliveries are posted one after the other
+func (d *Dispatcher) lease() time.Duration {
+	return time.Duration(d.BatchSize)*d.client.Timeout + d.LeaseMargin
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job *Job) {
+	attempt := d.post(ctx, job)
+	attempts := job.Attempts + 1
+
+	status := StatusDelivered
+	var nextAttemptAt *time.Time
+	if attempt.Error != nil {
+		status = StatusFailed
+		if attempts < d.policy.MaxAttempts {
+			status = StatusPending
+			next := attempt.AttemptedAt.Add(d.policy.Delay(attempts))
+			nextAttemptAt = &next
+		}
+	}
+
+	logger := slog.With("delivery_id", job.ID, "webhook_id", job.WebhookID, "topic", job.Topic, "attempt", attempts)
+	switch status {
+	case StatusDelivered:
+		logger.DebugContext(ctx, "Delivered webhook")
+	case StatusPending:
+		logger.InfoContext(ctx, "Webhook delivery failed, retrying", "error", *attempt.Error, "next_attempt_at", *nextAttemptAt)
+	case StatusFailed:
+		logger.WarnContext(ctx, "Webhook delivery failed for good", "error", *attempt.Error)
+	}
+
+	if err := d.store.Record(ctx, job.ID, attempt, status, nextAttemptAt); err != nil && ctx.Err() == nil {
+		// the lease runs out and the delivery is posted again, receivers dedupe by its id
+		logger.ErrorContext(ctx, "Unable to record webhook attempt", "error", err)
+	}
+}
+
+// post sends one attempt, anything but a 2xx response is a failure
+func (d *Dispatcher) post(ctx context.Context, job *Job) Attempt {
+	attempt := Attempt{AttemptedAt: d.now()}
+	fail := func(err error) Attempt {
+		message := err.Error()
+		attempt.Error = &message
+		attempt.DurationMs = int(d.now().Sub(attempt.AttemptedAt).Milliseconds())
+		return attempt
+	}
+
+	body := []byte(job.Payload)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return fail(err)
+	}
+	timestamp := attempt.AttemptedAt.Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "knighthacks-events-webhooks")
+	request.Header.Set(HeaderDelivery, job.ID)
+	request.Header.Set(HeaderTopic, string(job.Topic))
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return fail(err)
+	}
+	// drained so the connection can be reused, receivers are expected to answer with an empty body
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	response.Body.Close()
+
+	statusCode := response.StatusCode
+	attempt.StatusCode = &statusCode
+	if statusCode < 200 || statusCode > 299 {
+		return fail(fmt.Errorf("endpoint responded with %s", response.Status))
+	}
+	attempt.DurationMs = int(d.now().Sub(attempt.AttemptedAt).Milliseconds())
+	return attempt
+}
@@ -0,0 +1,200 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+	"github.com/KnightHacks/knighthacks_events/webhooks/webhookstest"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := webhooks.RetryPolicy{MaxAttempts: 10, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 4, want: 8 * time.Minute},
+		{attempts: 5, want: 10 * time.Minute},
+		{attempts: 60, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Delay(tt.attempts); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// receiver is a stand-in for a subscriber, it answers with the next of its statuses and keeps what it was sent
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(request.Body)
+	r.requests = append(r.requests, request)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// newTestDispatcher sends to a new receiver, now can be moved to make retries due
+func newTestDispatcher(t *testing.T, policy webhooks.RetryPolicy, statuses ...int) (*webhooks.Dispatcher, *webhookstest.MemoryStore, *receiver, *time.Time) {
+	t.Helper()
+	stand := &receiver{statuses: statuses}
+	server := httptest.NewServer(stand)
+	t.Cleanup(server.Close)
+
+	store := webhookstest.NewMemoryStore()
+	if _, err := store.CreateEndpoint(context.Background(), server.URL, []webhooks.Topic{webhooks.TopicEventCreated}, "secret"); err != nil {
+		t.Fatalf("CreateEndpoint() error = %v", err)
+	}
+	now := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	dispatcher := webhooks.NewDispatcher(store, &http.Client{Timeout: time.Second}, policy)
+	dispatcher.SetNow(func() time.Time { return now })
+	return dispatcher, store, stand, &now
+}
+
+func dispatch(t *testing.T, dispatcher *webhooks.Dispatcher, want int) {
+	t.Helper()
+	got, err := dispatcher.DispatchDue(context.Background())
+	if err != nil {
+		t.Fatalf("DispatchDue() error = %v", err)
+	}
+	if got != want {
+		t.Fatalf("DispatchDue() dispatched %d deliveries, want %d", got, want)
+	}
+}
+
+func onlyDelivery(t *testing.T, store *webhookstest.MemoryStore) *webhooks.Delivery {
+	t.Helper()
+	deliveries, _, err := store.GetDeliveries(context.Background(), "1", 10, "0")
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("GetDeliveries() = %v, %v, want a single delivery", deliveries, err)
+	}
+	return deliveries[0]
+}
+
+func TestDispatcher_RetriesUntilDelivered(t *testing.T) {
+	dispatcher, store, stand, now := newTestDispatcher(t, webhooks.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		http.StatusInternalServerError, http.StatusNoContent)
+	event := map[string]string{"id": "1", "name": "Opening Ceremony"}
+	if err := webhooks.Publish(context.Background(), store, webhooks.TopicEventCreated, event, *now); err != nil {
+		t.Fatalf("webhooks.Publish() error = %v", err)
+	}
+
+	dispatch(t, dispatcher, 1)
+	delivery := onlyDelivery(t, store)
+	if delivery.Status != webhooks.StatusPending || delivery.Attempts != 1 || !delivery.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("after a 500 got = %+v, want a retry in a minute", delivery)
+	}
+
+	// neither the lease nor the backoff have run out
+	*now = now.Add(30 * time.Second)
+	dispatch(t, dispatcher, 0)
+
+	*now = now.Add(30 * time.Second)
+	dispatch(t, dispatcher, 1)
+	delivery = onlyDelivery(t, store)
+	if delivery.Status != webhooks.StatusDelivered || delivery.Attempts != 2 || delivery.DeliveredAt == nil || *delivery.LastStatusCode != http.StatusNoContent {
+		t.Fatalf("after a 204 got = %+v, want it delivered", delivery)
+	}
+
+	if len(stand.requests) != 2 {
+		t.Fatalf("receiver got %d requests, want 2", len(stand.requests))
+	}
+	request, body := stand.requests[1], stand.bodies[1]
+	timestamp, err := strconv.ParseInt(request.Header.Get(webhooks.HeaderTimestamp), 10, 64)
+	if err != nil || timestamp != now.Unix() {
+		t.Errorf("%s = %q, want %d", webhooks.HeaderTimestamp, request.Header.Get(webhooks.HeaderTimestamp), now.Unix())
+	}
+	if !webhooks.Verify("secret", timestamp, body, request.Header.Get(webhooks.HeaderSignature)) {
+		t.Errorf("%s = %q doesn't verify", webhooks.HeaderSignature, request.Header.Get(webhooks.HeaderSignature))
+	}
+	if request.Header.Get(webhooks.HeaderDelivery) != delivery.ID || request.Header.Get(webhooks.HeaderTopic) != string(webhooks.TopicEventCreated) {
+		t.Errorf("headers = %v", request.Header)
+	}
+	var message struct {
+		Topic webhooks.Topic    `json:"topic"`
+		Data  map[string]string `json:"data"`
+	}
+	if err = json.Unmarshal(body, &message); err != nil || message.Topic != webhooks.TopicEventCreated || message.Data["name"] != "Opening Ceremony" {
+		t.Errorf("body = %s, want the created event", body)
+	}
+
+	attempts, err := store.GetAttempts(context.Background(), delivery.ID)
+	if err != nil || len(attempts) != 2 || *attempts[0].StatusCode != http.StatusInternalServerError || attempts[0].Error == nil || attempts[1].Error != nil {
+		t.Errorf("GetAttempts() = %v, %v, want the 500 then the 204", attempts, err)
+	}
+}
+
+func TestDispatcher_GivesUp(t *testing.T) {
+	dispatcher, store, _, now := newTestDispatcher(t, webhooks.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour},
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK)
+	if err := webhooks.Publish(context.Background(), store, webhooks.TopicEventCreated, map[string]string{"id": "1"}, *now); err != nil {
+		t.Fatalf("webhooks.Publish() error = %v", err)
+	}
+
+	dispatch(t, dispatcher, 1)
+	*now = now.Add(time.Minute)
+	dispatch(t, dispatcher, 1)
+	delivery := onlyDelivery(t, store)
+	if delivery.Status != webhooks.StatusFailed || delivery.Attempts != 2 || delivery.NextAttemptAt != nil {
+		t.Fatalf("after the last attempt got = %+v, want it failed", delivery)
+	}
+	*now = now.Add(time.Hour)
+	dispatch(t, dispatcher, 0)
+
+	// redelivering starts over with every attempt available again
+	if _, err := store.Redeliver(context.Background(), delivery.ID, *now); err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	dispatch(t, dispatcher, 1)
+	if delivery = onlyDelivery(t, store); delivery.Status != webhooks.StatusPending || delivery.Attempts != 1 {
+		t.Fatalf("after redelivering got = %+v, want a retry", delivery)
+	}
+	*now = now.Add(time.Minute)
+	dispatch(t, dispatcher, 1)
+	if delivery = onlyDelivery(t, store); delivery.Status != webhooks.StatusDelivered {
+		t.Fatalf("after the retry got = %+v, want it delivered", delivery)
+	}
+}
+
+func TestDispatcher_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	store := webhookstest.NewMemoryStore()
+	if _, err := store.CreateEndpoint(context.Background(), server.URL, []webhooks.Topic{webhooks.TopicEventDeleted}, "secret"); err != nil {
+		t.Fatalf("CreateEndpoint() error = %v", err)
+	}
+	now := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	dispatcher := webhooks.NewDispatcher(store, &http.Client{Timeout: time.Second}, webhooks.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour})
+	dispatcher.SetNow(func() time.Time { return now })
+	if err := webhooks.Publish(context.Background(), store, webhooks.TopicEventDeleted, map[string]string{"id": "1"}, now); err != nil {
+		t.Fatalf("webhooks.Publish() error = %v", err)
+	}
+
+	dispatch(t, dispatcher, 1)
+	delivery := onlyDelivery(t, store)
+	if delivery.Status != webhooks.StatusPending || delivery.LastStatusCode != nil || delivery.LastError == nil {
+		t.Errorf("got = %+v, want a retry without a status code", delivery)
+	}
+}
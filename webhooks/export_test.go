@@ -0,0 +1,10 @@
+package webhooks
+
+import "time"
+
+// SetNow lets the tests of package webhooks_test move the dispatcher's clock
+func (d *Dispatcher) SetNow(now func() time.Time) {
+	d.now = now
+}
+
+var IsPublic = isPublic
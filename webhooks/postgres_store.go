@@ -0,0 +1,306 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PostgresStore
+// The Store used in production, every replica shares the queue and claims deliveries with SKIP LOCKED so each one
+// is only posted by a single dispatcher at a time. The tables are created by the migrations package, endpoint secrets
+// are kept encrypted by Secrets.
+type PostgresStore struct {
+	DatabasePool *pgxpool.Pool
+	Secrets      *SecretBox
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+func NewPostgresStore(databasePool *pgxpool.Pool, secrets *SecretBox) *PostgresStore {
+	return &PostgresStore{DatabasePool: databasePool, Secrets: secrets}
+}
+
+const deliveryColumns = "id, webhook_id, topic, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at"
+
+func (s *PostgresStore) CreateEndpoint(ctx context.Context, url string, topics []Topic, secret string) (*Endpoint, error) {
+	sealed, err := s.Secrets.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	var id int
+	var createdAt time.Time
+	err = s.DatabasePool.QueryRow(ctx, "INSERT INTO webhooks (url, secret, topics) VALUES ($1, $2, $3) RETURNING id, created_at",
+		url, sealed, topicStrings(topics)).Scan(&id, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return &Endpoint{ID: strconv.Itoa(id), URL: url, Topics: topics, Secret: secret, CreatedAt: createdAt}, nil
+}
+
+func (s *PostgresStore) DeleteEndpoint(ctx context.Context, id string) (bool, error) {
+	key, err := strconv.Atoi(id)
+	if err != nil {
+		return false, WebhookNotFound
+	}
+	// deliveries and their attempts go with it
+	commandTag, err := s.DatabasePool.Exec(ctx, "DELETE FROM webhooks WHERE id = $1", key)
+	if err != nil {
+		return false, err
+	}
+	if commandTag.RowsAffected() != 1 {
+		return false, WebhookNotFound
+	}
+	return true, nil
+}
+
+func (s *PostgresStore) GetEndpoints(ctx context.Context) ([]*Endpoint, error) {
+	rows, err := s.DatabasePool.Query(ctx, "SELECT id, url, secret, topics, created_at FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	endpoints := []*Endpoint{}
+	for rows.Next() {
+		var id int
+		var topics []string
+		endpoint := &Endpoint{}
+		if err = rows.Scan(&id, &endpoint.URL, &endpoint.Secret, &topics, &endpoint.CreatedAt); err != nil {
+			return nil, err
+		}
+		endpoint.ID = strconv.Itoa(id)
+		if endpoint.Secret, err = s.openSecret(endpoint.ID, endpoint.Secret); err != nil {
+			return nil, err
+		}
+		for _, topic := range topics {
+			endpoint.Topics = append(endpoint.Topics, Topic(topic))
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, rows.Err()
+}
+
+func (s *PostgresStore) Enqueue(ctx context.Context, topic Topic, payload []byte, now time.Time) (int, error) {
+	commandTag, err := s.DatabasePool.Exec(ctx, `INSERT INTO webhook_deliveries (webhook_id, topic, payload, status, next_attempt_at, created_at)
+		SELECT id, $1, $2, 'pending', $3, $3 FROM webhooks WHERE $1 = ANY(topics) ORDER BY id`,
+		string(topic), payload, now)
+	if err != nil {
+		return 0, err
+	}
+	return int(commandTag.RowsAffected()), nil
+}
+
+func (s *PostgresStore) Claim(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]*Job, error) {
+	rows, err := s.DatabasePool.Query(ctx, `WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = $2
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING d.id, d.webhook_id, d.topic, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_status_code,
+			d.last_error, d.created_at, d.delivered_at, w.url, w.secret`,
+		now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job := &Job{}
+		if err = scanDelivery(rows, &job.Delivery, &job.URL, &job.Secret); err != nil {
+			return nil, err
+		}
+		if job.Secret, err = s.openSecret(job.WebhookID, job.Secret); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *PostgresStore) Record(ctx context.Context, deliveryID string, attempt Attempt, status Status, nextAttemptAt *time.Time) error {
+	key, err := strconv.ParseInt(deliveryID, 10, 64)
+	if err != nil {
+		return DeliveryNotFound
+	}
+	if status != StatusPending {
+		nextAttemptAt = nil
+	}
+	var deliveredAt *time.Time
+	if status == StatusDelivered {
+		deliveredAt = &attempt.AttemptedAt
+	}
+
+	return pgx.BeginTxFunc(ctx, s.DatabasePool, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		commandTag, err := tx.Exec(ctx, `UPDATE webhook_deliveries
+			SET attempts = attempts + 1, status = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5,
+				delivered_at = coalesce($6, delivered_at)
+			WHERE id = $1`,
+			key, string(status), nextAttemptAt, attempt.StatusCode, attempt.Error, deliveredAt)
+		if err != nil {
+			return err
+		}
+		// the endpoint was deleted while the delivery was being posted
+		if commandTag.RowsAffected() != 1 {
+			return DeliveryNotFound
+		}
+		_, err = tx.Exec(ctx, "INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, error, duration_ms) VALUES ($1, $2, $3, $4, $5)",
+			key, attempt.AttemptedAt, attempt.StatusCode, attempt.Error, attempt.DurationMs)
+		return err
+	})
+}
+
+func (s *PostgresStore) GetDeliveries(ctx context.Context, webhookID string, first int, after string) ([]*Delivery, int, error) {
+	first = max(first, 0)
+	endpointKey, err := strconv.Atoi(webhookID)
+	if err != nil {
+		return nil, 0, WebhookNotFound
+	}
+	afterKey, err := strconv.ParseInt(after, 10, 64)
+	if err != nil {
+		afterKey = 0
+	}
+
+	var deliveries []*Delivery
+	var total int
+	// a single snapshot, so the total can't disagree with the page
+	err = pgx.BeginTxFunc(ctx, s.DatabasePool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1)", endpointKey).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return WebhookNotFound
+		}
+		if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1", endpointKey).Scan(&total); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx, "SELECT "+deliveryColumns+` FROM webhook_deliveries
+			WHERE webhook_id = $1 AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3`,
+			endpointKey, afterKey, first)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		deliveries = make([]*Delivery, 0, first)
+		for rows.Next() {
+			delivery := &Delivery{}
+			if err = scanDelivery(rows, delivery); err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (s *PostgresStore) GetAttempts(ctx context.Context, deliveryID string) ([]*Attempt, error) {
+	key, err := strconv.ParseInt(deliveryID, 10, 64)
+	if err != nil {
+		return nil, DeliveryNotFound
+	}
+
+	var attempts []*Attempt
+	err = pgx.BeginTxFunc(ctx, s.DatabasePool, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE id = $1)", key).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return DeliveryNotFound
+		}
+
+		rows, err := tx.Query(ctx, "SELECT attempted_at, status_code, error, duration_ms FROM webhook_attempts WHERE delivery_id = $1 ORDER BY attempted_at",
+			key)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		attempts = []*Attempt{}
+		for rows.Next() {
+			attempt := &Attempt{}
+			if err = rows.Scan(&attempt.AttemptedAt, &attempt.StatusCode, &attempt.Error, &attempt.DurationMs); err != nil {
+				return err
+			}
+			attempts = append(attempts, attempt)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (s *PostgresStore) Redeliver(ctx context.Context, deliveryID string, now time.Time) (*Delivery, error) {
+	key, err := strconv.ParseInt(deliveryID, 10, 64)
+	if err != nil {
+		return nil, DeliveryNotFound
+	}
+
+	delivery := &Delivery{}
+	err = scanDelivery(s.DatabasePool.QueryRow(ctx, `UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = $2, delivered_at = NULL
+		WHERE id = $1
+		RETURNING `+deliveryColumns, key, now), delivery)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, DeliveryNotFound
+		}
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// scanDelivery reads the columns in deliveryColumns order followed by extra
+func scanDelivery(row pgx.Row, delivery *Delivery, extra ...interface{}) error {
+	var id int64
+	var webhookID int
+	var topic, status string
+	var payload []byte
+	destinations := append([]interface{}{
+		&id, &webhookID, &topic, &payload, &status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode,
+		&delivery.LastError, &delivery.CreatedAt, &delivery.DeliveredAt,
+	}, extra...)
+	if err := row.Scan(destinations...); err != nil {
+		return err
+	}
+	delivery.ID = strconv.FormatInt(id, 10)
+	delivery.WebhookID = strconv.Itoa(webhookID)
+	delivery.Topic = Topic(topic)
+	delivery.Payload = string(payload)
+	delivery.Status = Status(status)
+	return nil
+}
+
+func topicStrings(topics []Topic) []string {
+	converted := make([]string, len(topics))
+	for i, topic := range topics {
+		converted[i] = string(topic)
+	}
+	return converted
+}
+
+func (s *PostgresStore) openSecret(webhookID string, stored string) (string, error) {
+	secret, err := s.Secrets.Open(stored)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt the secret of webhook %s, was webhooks.secret_key changed: %w", webhookID, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,35 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps the endpoints, the queue of deliveries and their log. Ids that aren't numbers are never found.
+type Store interface {
+	CreateEndpoint(ctx context.Context, url string, topics []Topic, secret string) (*Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id string) (bool, error)
+	GetEndpoints(ctx context.Context) ([]*Endpoint, error)
+	// Enqueue queues payload for every endpoint subscribed to topic, due at now, and returns how many deliveries it queued
+	Enqueue(ctx context.Context, topic Topic, payload []byte, now time.Time) (int, error)
+	// Claim returns up to limit pending deliveries that are due at now, oldest first, and pushes them back to leaseUntil
+	// so no other dispatcher picks them up meanwhile. A dispatcher that dies mid-delivery leaves them due again.
+	Claim(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]*Job, error)
+	// Record logs attempt and moves the delivery to status, a pending delivery is retried at nextAttemptAt
+	Record(ctx context.Context, deliveryID string, attempt Attempt, status Status, nextAttemptAt *time.Time) error
+	// GetDeliveries pages through the deliveries of an endpoint, newest first. after is the id of the last delivery
+	// of the previous page, "0" starts from the newest.
+	GetDeliveries(ctx context.Context, webhookID string, first int, after string) ([]*Delivery, int, error)
+	GetAttempts(ctx context.Context, deliveryID string) ([]*Attempt, error)
+	// Redeliver queues a delivery again as if it was new, due at now. Its attempts start over so it gets every retry
+	// again, the log of earlier attempts is kept.
+	Redeliver(ctx context.Context, deliveryID string, now time.Time) (*Delivery, error)
+}
+
+// Job
+// A claimed delivery along with where it goes
+type Job struct {
+	Delivery
+	URL    string
+	Secret string
+}
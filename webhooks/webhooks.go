@@ -0,0 +1,211 @@
+// Package webhooks notifies endpoints registered by admins when events change. Every message is queued as one
+// delivery per subscribed endpoint in a durable Store, a Dispatcher then posts it signed with HMAC-SHA256 and retries
+// failures with exponential backoff. Every attempt is kept in a log so a delivery can be inspected and redelivered.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/apperrors"
+	"github.com/KnightHacks/knighthacks_events/outbox"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTopic     = "X-Webhook-Topic"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+var (
+	WebhookNotFound  = apperrors.New(apperrors.CodeNotFound, "webhook was not found")
+	DeliveryNotFound = apperrors.New(apperrors.CodeNotFound, "webhook delivery was not found")
+)
+
+// Topic
+// What happened, endpoints only receive the topics they subscribed to. GraphQL spells them in upper snake case,
+// such as EVENT_CREATED, while payloads and headers use the dotted name.
+type Topic string
+
+const (
+	TopicEventCreated        Topic = outbox.TopicEventCreated
+	TopicEventUpdated        Topic = outbox.TopicEventUpdated
+	TopicEventDeleted        Topic = outbox.TopicEventDeleted
+	TopicAttendanceCheckedIn Topic = outbox.TopicAttendanceCheckedIn
+)
+
+// Topics lists every topic an endpoint can subscribe to
+var Topics = []Topic{TopicEventCreated, TopicEventUpdated, TopicEventDeleted, TopicAttendanceCheckedIn}
+
+func (t Topic) IsValid() bool {
+	for _, topic := range Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Topic) enumName() string {
+	return strings.ToUpper(strings.ReplaceAll(string(t), ".", "_"))
+}
+
+func (t *Topic) UnmarshalGQL(v interface{}) error {
+	name, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+	for _, topic := range Topics {
+		if topic.enumName() == name {
+			*t = topic
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a valid WebhookTopic", name)
+}
+
+func (t Topic) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(t.enumName()))
+}
+
+// Status
+// Where a delivery is at, it stays pending until it either succeeds or runs out of attempts
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusFailed    Status = "failed"
+)
+
+func (s *Status) UnmarshalGQL(v interface{}) error {
+	name, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+	status := Status(strings.ToLower(name))
+	if status != StatusPending && status != StatusDelivered && status != StatusFailed {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", name)
+	}
+	*s = status
+	return nil
+}
+
+func (s Status) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(strings.ToUpper(string(s))))
+}
+
+// Endpoint
+// A URL registered by an admin, Secret signs its deliveries and is never exposed after registration
+type Endpoint struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Topics    []Topic   `json:"topics"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Delivery
+// One message queued for one endpoint. NextAttemptAt is only set while it is pending.
+type Delivery struct {
+	ID             string     `json:"id"`
+	WebhookID      string     `json:"webhookId"`
+	Topic          Topic      `json:"topic"`
+	Payload        string     `json:"payload"`
+	Status         Status     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt"`
+	LastStatusCode *int       `json:"lastStatusCode"`
+	LastError      *string    `json:"lastError"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+}
+
+// Attempt
+// One entry of the delivery log, StatusCode is nil when the endpoint couldn't be reached at all
+type Attempt struct {
+	AttemptedAt time.Time `json:"attemptedAt"`
+	StatusCode  *int      `json:"statusCode"`
+	Error       *string   `json:"error"`
+	DurationMs  int       `json:"durationMs"`
+}
+
+// Message
+// The body of every delivery, Data is whatever the topic is about such as the Event that was created. ID is the id of
+// the outbox message it came from, a message can be queued more than once so receivers should dedupe by it.
+type Message struct {
+	ID         string      `json:"id,omitempty"`
+	Topic      Topic       `json:"topic"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// Sign computes the X-Webhook-Signature of a body sent at timestamp, in unix seconds. The timestamp is signed along
+// with the body so receivers can reject old deliveries that are replayed.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify is what a receiver runs on a delivery, it compares signatures in constant time
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Publish queues data as a Message about topic for every endpoint subscribed to it
+func Publish(ctx context.Context, store Store, topic Topic, data interface{}, now time.Time) error {
+	payload, err := json.Marshal(Message{Topic: topic, OccurredAt: now.UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+	_, err = store.Enqueue(ctx, topic, payload, now)
+	return err
+}
+
+// Publisher
+// Queues webhooks for the messages relayed from the outbox, messages about topics endpoints can't subscribe to are skipped
+type Publisher struct {
+	store Store
+}
+
+var _ outbox.Publisher = (*Publisher)(nil)
+
+func NewPublisher(store Store) *Publisher {
+	return &Publisher{store: store}
+}
+
+func (p *Publisher) Publish(ctx context.Context, message *outbox.Message) error {
+	topic := Topic(message.Topic)
+	if !topic.IsValid() {
+		return nil
+	}
+	payload, err := json.Marshal(Message{ID: message.ID, Topic: topic, OccurredAt: message.CreatedAt.UTC(), Data: message.Payload})
+	if err != nil {
+		return err
+	}
+	_, err = p.store.Enqueue(ctx, topic, payload, time.Now())
+	return err
+}
+
+// NewSecret generates the key a new endpoint's deliveries are signed with
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
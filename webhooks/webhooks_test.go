@@ -0,0 +1,176 @@
+package webhooks_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/outbox"
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+	"github.com/KnightHacks/knighthacks_events/webhooks/webhookstest"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"topic":"event.created"}`)
+	signature := webhooks.Sign("secret", 1700000000, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		want      bool
+	}{
+		{name: "same delivery", secret: "secret", timestamp: 1700000000, body: body, want: true},
+		{name: "other secret", secret: "other", timestamp: 1700000000, body: body, want: false},
+		{name: "replayed later", secret: "secret", timestamp: 1700000060, body: body, want: false},
+		{name: "tampered body", secret: "secret", timestamp: 1700000000, body: []byte(`{"topic":"event.deleted"}`), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhooks.Verify(tt.secret, tt.timestamp, tt.body, signature); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopic_GQL(t *testing.T) {
+	tests := map[webhooks.Topic]string{
+		webhooks.TopicEventCreated:        "EVENT_CREATED",
+		webhooks.TopicEventUpdated:        "EVENT_UPDATED",
+		webhooks.TopicEventDeleted:        "EVENT_DELETED",
+		webhooks.TopicAttendanceCheckedIn: "ATTENDANCE_CHECKED_IN",
+	}
+	for topic, name := range tests {
+		var buffer bytes.Buffer
+		topic.MarshalGQL(&buffer)
+		if got := buffer.String(); got != strconv.Quote(name) {
+			t.Errorf("MarshalGQL(%s) = %s, want %q", topic, got, name)
+		}
+
+		var got webhooks.Topic
+		if err := got.UnmarshalGQL(name); err != nil || got != topic {
+			t.Errorf("UnmarshalGQL(%s) = %s, %v, want %s", name, got, err, topic)
+		}
+	}
+
+	var topic webhooks.Topic
+	if err := topic.UnmarshalGQL("EVENT_RENAMED"); err == nil {
+		t.Errorf("UnmarshalGQL(EVENT_RENAMED) error = nil, want an error")
+	}
+}
+
+func TestPublisher(t *testing.T) {
+	ctx := context.Background()
+	store := webhookstest.NewMemoryStore()
+	endpoint, err := store.CreateEndpoint(ctx, "http://localhost/webhooks", []webhooks.Topic{webhooks.TopicEventCreated}, "secret")
+	if err != nil {
+		t.Fatalf("CreateEndpoint() error = %v", err)
+	}
+
+	publisher := webhooks.NewPublisher(store)
+	createdAt := time.Date(2023, time.February, 3, 18, 0, 0, 0, time.UTC)
+	messages := []*outbox.Message{
+		{ID: "7", AggregateID: "1", Topic: outbox.TopicEventCreated, Payload: []byte(`{"id":"1","name":"Opening Ceremony"}`), CreatedAt: createdAt},
+		{ID: "8", AggregateID: "1", Topic: outbox.TopicEventDeleted, Payload: []byte(`{"id":"1"}`), CreatedAt: createdAt},
+		{ID: "9", AggregateID: "1", Topic: "event.archived", Payload: []byte(`{"id":"1"}`), CreatedAt: createdAt},
+	}
+	for _, message := range messages {
+		if err = publisher.Publish(ctx, message); err != nil {
+			t.Fatalf("Publish(%s) error = %v", message.Topic, err)
+		}
+	}
+
+	// the endpoint isn't subscribed to deletions and nobody can subscribe to an unknown topic
+	deliveries, _, err := store.GetDeliveries(ctx, endpoint.ID, 10, "0")
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("GetDeliveries() = %v, %v, want the creation only", deliveries, err)
+	}
+	want := `{"id":"7","topic":"event.created","occurredAt":"2023-02-03T18:00:00Z","data":{"id":"1","name":"Opening Ceremony"}}`
+	if deliveries[0].Payload != want {
+		t.Errorf("payload = %s, want %s", deliveries[0].Payload, want)
+	}
+}
+
+func TestSecretBox(t *testing.T) {
+	box, err := webhooks.NewSecretBox("key")
+	if err != nil {
+		t.Fatalf("NewSecretBox() error = %v", err)
+	}
+	sealed, err := box.Seal("secret")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if strings.Contains(sealed, "secret") {
+		t.Errorf("Seal() = %s, want the secret encrypted", sealed)
+	}
+	if opened, err := box.Open(sealed); err != nil || opened != "secret" {
+		t.Errorf("Open() = %s, %v, want secret", opened, err)
+	}
+	if opened, err := box.Open("legacy"); err != nil || opened != "legacy" {
+		t.Errorf("Open() of an unencrypted secret = %s, %v, want it unchanged", opened, err)
+	}
+
+	other, _ := webhooks.NewSecretBox("other key")
+	if _, err = other.Open(sealed); err == nil {
+		t.Error("Open() with another key error = nil, want an error")
+	}
+	var missing *webhooks.SecretBox
+	if _, err = missing.Seal("secret"); !errors.Is(err, webhooks.ErrNoSecretKey) {
+		t.Errorf("Seal() without a key error = %v, want %v", err, webhooks.ErrNoSecretKey)
+	}
+	if _, err = missing.Open(sealed); !errors.Is(err, webhooks.ErrNoSecretKey) {
+		t.Errorf("Open() without a key error = %v, want %v", err, webhooks.ErrNoSecretKey)
+	}
+	if opened, err := missing.Open("legacy"); err != nil || opened != "legacy" {
+		t.Errorf("Open() of an unencrypted secret without a key = %s, %v, want it unchanged", opened, err)
+	}
+	if _, err = webhooks.NewSecretBox(""); err == nil {
+		t.Error("NewSecretBox(\"\") error = nil, want an error")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client := webhooks.NewClient(time.Second)
+	if _, err := client.Post(server.URL, "application/json", nil); !errors.Is(err, webhooks.ErrInternalAddress) {
+		t.Errorf("Post() to %s error = %v, want %v", server.URL, err, webhooks.ErrInternalAddress)
+	}
+	if err := client.CheckRedirect(nil, nil); !errors.Is(err, http.ErrUseLastResponse) {
+		t.Errorf("CheckRedirect() = %v, want redirects not to be followed", err)
+	}
+
+	tests := map[string]bool{
+		"93.184.216.34":      true,
+		"2606:2800:220:1::1": true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"10.0.0.1":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"fd00::1":            false,
+		"100.64.0.1":         false,
+		"0.0.0.0":            false,
+		"::ffff:127.0.0.1":   false,
+		"64:ff9b::a00:1":     false,
+		"224.0.0.1":          false,
+		"255.255.255.255":    false,
+	}
+	for addr, want := range tests {
+		if got := webhooks.IsPublic(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isPublic(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package webhookstest is the test suite every webhooks.Store has to pass and a MemoryStore for tests
+package webhookstest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+)
+
+// missingID is a valid serial that no test ever gets to
+const missingID = "2147483647"
+
+// payload is already in the form jsonb prints it in, so it comes back unchanged from every store
+const payload = `{"id": "1"}`
+
+// Run checks store against the Store contract. The store may already hold endpoints and deliveries, every test
+// deletes the endpoints it creates and only looks at deliveries queued for them.
+func Run(t *testing.T, store webhooks.Store) {
+	ctx := context.Background()
+	// far enough ahead that deliveries queued by anything else are due too, which is why claimed jobs are filtered
+	start := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	create := func(t *testing.T, topics ...webhooks.Topic) *webhooks.Endpoint {
+		t.Helper()
+		endpoint, err := store.CreateEndpoint(ctx, "http://localhost/webhooks", topics, "secret")
+		if err != nil {
+			t.Fatalf("CreateEndpoint() error = %v", err)
+		}
+		t.Cleanup(func() {
+			_, _ = store.DeleteEndpoint(ctx, endpoint.ID)
+		})
+		return endpoint
+	}
+	enqueue := func(t *testing.T, topic webhooks.Topic, now time.Time) {
+		t.Helper()
+		if _, err := store.Enqueue(ctx, topic, []byte(payload), now); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	deliveries := func(t *testing.T, endpoint *webhooks.Endpoint) []*webhooks.Delivery {
+		t.Helper()
+		got, _, err := store.GetDeliveries(ctx, endpoint.ID, 100, "0")
+		if err != nil {
+			t.Fatalf("GetDeliveries() error = %v", err)
+		}
+		return got
+	}
+	claim := func(t *testing.T, endpoint *webhooks.Endpoint, now time.Time) []*webhooks.Job {
+		t.Helper()
+		jobs, err := store.Claim(ctx, now, now.Add(time.Minute), 1000)
+		if err != nil {
+			t.Fatalf("Claim() error = %v", err)
+		}
+		var own []*webhooks.Job
+		for _, job := range jobs {
+			if job.WebhookID == endpoint.ID {
+				own = append(own, job)
+			}
+		}
+		return own
+	}
+
+	t.Run("CreateEndpoint then GetEndpoints", func(t *testing.T) {
+		created := create(t, webhooks.TopicEventCreated, webhooks.TopicAttendanceCheckedIn)
+		if created.ID == "" {
+			t.Fatalf("CreateEndpoint() returned an endpoint without an id")
+		}
+
+		endpoints, err := store.GetEndpoints(ctx)
+		if err != nil {
+			t.Fatalf("GetEndpoints() error = %v", err)
+		}
+		var got *webhooks.Endpoint
+		for _, endpoint := range endpoints {
+			if endpoint.ID == created.ID {
+				got = endpoint
+			}
+		}
+		if got == nil {
+			t.Fatalf("GetEndpoints() is missing endpoint %s", created.ID)
+		}
+		if got.URL != created.URL || got.Secret != "secret" || !reflect.DeepEqual(got.Topics, created.Topics) || !got.CreatedAt.Equal(created.CreatedAt) {
+			t.Errorf("GetEndpoints() got = %+v, want %+v", got, created)
+		}
+	})
+
+	t.Run("DeleteEndpoint", func(t *testing.T) {
+		endpoint := create(t, webhooks.TopicEventCreated)
+		enqueue(t, webhooks.TopicEventCreated, start)
+
+		if deleted, err := store.DeleteEndpoint(ctx, endpoint.ID); err != nil || !deleted {
+			t.Fatalf("DeleteEndpoint() = %v, %v, want true", deleted, err)
+		}
+		// its deliveries go with it
+		if _, _, err := store.GetDeliveries(ctx, endpoint.ID, 10, "0"); !errors.Is(err, webhooks.WebhookNotFound) {
+			t.Errorf("GetDeliveries() error = %v, want %v", err, webhooks.WebhookNotFound)
+		}
+		for _, id := range []string{endpoint.ID, missingID, "not-a-number"} {
+			if _, err := store.DeleteEndpoint(ctx, id); !errors.Is(err, webhooks.WebhookNotFound) {
+				t.Errorf("DeleteEndpoint(%q) error = %v, want %v", id, err, webhooks.WebhookNotFound)
+			}
+		}
+	})
+
+	t.Run("Enqueue only reaches subscribed endpoints", func(t *testing.T) {
+		subscribed := create(t, webhooks.TopicEventCreated, webhooks.TopicEventDeleted)
+		other := create(t, webhooks.TopicEventDeleted)
+		enqueue(t, webhooks.TopicEventCreated, start)
+
+		got := deliveries(t, subscribed)
+		if len(got) != 1 {
+			t.Fatalf("GetDeliveries() got %d deliveries, want 1", len(got))
+		}
+		delivery := got[0]
+		if delivery.WebhookID != subscribed.ID || delivery.Topic != webhooks.TopicEventCreated || delivery.Payload != payload ||
+			delivery.Status != webhooks.StatusPending || delivery.Attempts != 0 || delivery.NextAttemptAt == nil ||
+			!delivery.NextAttemptAt.Equal(start) || !delivery.CreatedAt.Equal(start) {
+			t.Errorf("GetDeliveries() got = %+v", delivery)
+		}
+		if got := deliveries(t, other); len(got) != 0 {
+			t.Errorf("GetDeliveries() of an endpoint that didn't subscribe got %d deliveries, want 0", len(got))
+		}
+	})
+
+	t.Run("Claim leases deliveries", func(t *testing.T) {
+		endpoint := create(t, webhooks.TopicEventUpdated)
+		enqueue(t, webhooks.TopicEventUpdated, start)
+
+		if jobs := claim(t, endpoint, start.Add(-time.Second)); len(jobs) != 0 {
+			t.Errorf("Claim() before the delivery is due got %d jobs, want 0", len(jobs))
+		}
+		jobs := claim(t, endpoint, start)
+		if len(jobs) != 1 {
+			t.Fatalf("Claim() got %d jobs, want 1", len(jobs))
+		}
+		if jobs[0].URL != endpoint.URL || jobs[0].Secret != "secret" || jobs[0].Payload != payload {
+			t.Errorf("Claim() got = %+v", jobs[0])
+		}
+		if jobs := claim(t, endpoint, start); len(jobs) != 0 {
+			t.Errorf("Claim() while leased got %d jobs, want 0", len(jobs))
+		}
+		if jobs := claim(t, endpoint, start.Add(2*time.Minute)); len(jobs) != 1 {
+			t.Errorf("Claim() after the lease ran out got %d jobs, want 1", len(jobs))
+		}
+	})
+
+	t.Run("Record", func(t *testing.T) {
+		endpoint := create(t, webhooks.TopicEventCreated)
+		enqueue(t, webhooks.TopicEventCreated, start)
+		id := deliveries(t, endpoint)[0].ID
+
+		serverError, ok := 500, 200
+		message := "endpoint responded with 500 Internal Server Error"
+		failed := webhooks.Attempt{AttemptedAt: start, StatusCode: &serverError, Error: &message, DurationMs: 12}
+		retryAt := start.Add(time.Minute)
+		if err := store.Record(ctx, id, failed, webhooks.StatusPending, &retryAt); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		got := deliveries(t, endpoint)[0]
+		if got.Status != webhooks.StatusPending || got.Attempts != 1 || got.NextAttemptAt == nil || !got.NextAttemptAt.Equal(retryAt) ||
+			got.LastStatusCode == nil || *got.LastStatusCode != 500 || got.LastError == nil || *got.LastError != message || got.DeliveredAt != nil {
+			t.Errorf("after a failed attempt got = %+v", got)
+		}
+		if jobs := claim(t, endpoint, start.Add(30*time.Second)); len(jobs) != 0 {
+			t.Errorf("Claim() before the retry is due got %d jobs, want 0", len(jobs))
+		}
+
+		delivered := webhooks.Attempt{AttemptedAt: retryAt, StatusCode: &ok, DurationMs: 3}
+		if err := store.Record(ctx, id, delivered, webhooks.StatusDelivered, nil); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		got = deliveries(t, endpoint)[0]
+		if got.Status != webhooks.StatusDelivered || got.Attempts != 2 || got.NextAttemptAt != nil || got.LastError != nil ||
+			got.DeliveredAt == nil || !got.DeliveredAt.Equal(retryAt) {
+			t.Errorf("after a successful attempt got = %+v", got)
+		}
+
+		attempts, err := store.GetAttempts(ctx, id)
+		if err != nil {
+			t.Fatalf("GetAttempts() error = %v", err)
+		}
+		if len(attempts) != 2 {
+			t.Fatalf("GetAttempts() got %d attempts, want 2", len(attempts))
+		}
+		for i, want := range []webhooks.Attempt{failed, delivered} {
+			if !attempts[i].AttemptedAt.Equal(want.AttemptedAt) || !reflect.DeepEqual(attempts[i].StatusCode, want.StatusCode) ||
+				!reflect.DeepEqual(attempts[i].Error, want.Error) || attempts[i].DurationMs != want.DurationMs {
+				t.Errorf("GetAttempts()[%d] got = %+v, want %+v", i, attempts[i], want)
+			}
+		}
+	})
+
+	t.Run("Record and GetAttempts missing", func(t *testing.T) {
+		for _, id := range []string{missingID, "not-a-number"} {
+			if err := store.Record(ctx, id, webhooks.Attempt{AttemptedAt: start}, webhooks.StatusDelivered, nil); !errors.Is(err, webhooks.DeliveryNotFound) {
+				t.Errorf("Record(%q) error = %v, want %v", id, err, webhooks.DeliveryNotFound)
+			}
+			if _, err := store.GetAttempts(ctx, id); !errors.Is(err, webhooks.DeliveryNotFound) {
+				t.Errorf("GetAttempts(%q) error = %v, want %v", id, err, webhooks.DeliveryNotFound)
+			}
+		}
+	})
+
+	t.Run("GetDeliveries pages newest first", func(t *testing.T) {
+		endpoint := create(t, webhooks.TopicEventCreated)
+		for i := 0; i < 3; i++ {
+			enqueue(t, webhooks.TopicEventCreated, start.Add(time.Duration(i)*time.Second))
+		}
+		all := deliveries(t, endpoint)
+		if len(all) != 3 || !all[0].CreatedAt.After(all[2].CreatedAt) {
+			t.Fatalf("GetDeliveries() got = %v, want the 3 deliveries newest first", all)
+		}
+
+		page, total, err := store.GetDeliveries(ctx, endpoint.ID, 2, "0")
+		if err != nil {
+			t.Fatalf("GetDeliveries() error = %v", err)
+		}
+		if total != 3 || len(page) != 2 || page[0].ID != all[0].ID || page[1].ID != all[1].ID {
+			t.Errorf("first page got = %v, %d", page, total)
+		}
+		page, _, err = store.GetDeliveries(ctx, endpoint.ID, 2, page[1].ID)
+		if err != nil {
+			t.Fatalf("GetDeliveries() error = %v", err)
+		}
+		if len(page) != 1 || page[0].ID != all[2].ID {
+			t.Errorf("second page got = %v", page)
+		}
+		for _, first := range []int{0, -1} {
+			if page, total, err = store.GetDeliveries(ctx, endpoint.ID, first, "0"); err != nil || len(page) != 0 || total != 3 {
+				t.Errorf("GetDeliveries(first %d) = %v, %d, %v, want an empty page of 3", first, page, total, err)
+			}
+		}
+		for _, id := range []string{missingID, "not-a-number"} {
+			if _, _, err := store.GetDeliveries(ctx, id, 2, "0"); !errors.Is(err, webhooks.WebhookNotFound) {
+				t.Errorf("GetDeliveries(%q) error = %v, want %v", id, err, webhooks.WebhookNotFound)
+			}
+		}
+	})
+
+	t.Run("Redeliver", func(t *testing.T) {
+		endpoint := create(t, webhooks.TopicEventDeleted)
+		enqueue(t, webhooks.TopicEventDeleted, start)
+		id := deliveries(t, endpoint)[0].ID
+		message := "connection refused"
+		if err := store.Record(ctx, id, webhooks.Attempt{AttemptedAt: start, Error: &message}, webhooks.StatusFailed, nil); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+
+		now := start.Add(time.Hour)
+		got, err := store.Redeliver(ctx, id, now)
+		if err != nil {
+			t.Fatalf("Redeliver() error = %v", err)
+		}
+		if got.ID != id || got.Status != webhooks.StatusPending || got.Attempts != 0 || got.NextAttemptAt == nil || !got.NextAttemptAt.Equal(now) {
+			t.Errorf("Redeliver() got = %+v", got)
+		}
+		if jobs := claim(t, endpoint, now); len(jobs) != 1 {
+			t.Errorf("Claim() after Redeliver() got %d jobs, want 1", len(jobs))
+		}
+		// the log of the failed attempt is kept
+		if attempts, err := store.GetAttempts(ctx, id); err != nil || len(attempts) != 1 {
+			t.Errorf("GetAttempts() = %v, %v, want 1 attempt", attempts, err)
+		}
+		for _, id := range []string{missingID, "not-a-number"} {
+			if _, err := store.Redeliver(ctx, id, now); !errors.Is(err, webhooks.DeliveryNotFound) {
+				t.Errorf("Redeliver(%q) error = %v, want %v", id, err, webhooks.DeliveryNotFound)
+			}
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package webhookstest
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KnightHacks/knighthacks_events/webhooks"
+)
+
+// MemoryStore
+// A webhooks.Store kept in memory for tests
+type MemoryStore struct {
+	mu             sync.Mutex
+	endpoints      map[int]*webhooks.Endpoint
+	deliveries     map[int]*webhooks.Delivery
+	attempts       map[int][]*webhooks.Attempt
+	lastEndpointID int
+	lastDeliveryID int
+}
+
+var _ webhooks.Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{endpoints: map[int]*webhooks.Endpoint{}, deliveries: map[int]*webhooks.Delivery{}, attempts: map[int][]*webhooks.Attempt{}}
+}
+
+func (s *MemoryStore) CreateEndpoint(ctx context.Context, url string, topics []webhooks.Topic, secret string) (*webhooks.Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastEndpointID++
+	endpoint := &webhooks.Endpoint{
+		ID:        strconv.Itoa(s.lastEndpointID),
+		URL:       url,
+		Topics:    append([]webhooks.Topic(nil), topics...),
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	s.endpoints[s.lastEndpointID] = endpoint
+	copied := *endpoint
+	return &copied, nil
+}
+
+func (s *MemoryStore) DeleteEndpoint(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := strconv.Atoi(id)
+	if _, ok := s.endpoints[key]; err != nil || !ok {
+		return false, webhooks.WebhookNotFound
+	}
+	delete(s.endpoints, key)
+	for deliveryKey, delivery := range s.deliveries {
+		if delivery.WebhookID == id {
+			delete(s.deliveries, deliveryKey)
+			delete(s.attempts, deliveryKey)
+		}
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) GetEndpoints(ctx context.Context) ([]*webhooks.Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]int, 0, len(s.endpoints))
+	for key := range s.endpoints {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	endpoints := make([]*webhooks.Endpoint, len(keys))
+	for i, key := range keys {
+		copied := *s.endpoints[key]
+		endpoints[i] = &copied
+	}
+	return endpoints, nil
+}
+
+func (s *MemoryStore) Enqueue(ctx context.Context, topic webhooks.Topic, payload []byte, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]int, 0, len(s.endpoints))
+	for key := range s.endpoints {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	queued := 0
+	for _, key := range keys {
+		endpoint := s.endpoints[key]
+		subscribed := false
+		for _, t := range endpoint.Topics {
+			subscribed = subscribed || t == topic
+		}
+		if !subscribed {
+			continue
+		}
+		s.lastDeliveryID++
+		due := now
+		s.deliveries[s.lastDeliveryID] = &webhooks.Delivery{
+			ID:            strconv.Itoa(s.lastDeliveryID),
+			WebhookID:     endpoint.ID,
+			Topic:         topic,
+			Payload:       string(payload),
+			Status:        webhooks.StatusPending,
+			NextAttemptAt: &due,
+			CreatedAt:     now,
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]*webhooks.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*webhooks.Delivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status == webhooks.StatusPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(*due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(*due[j].NextAttemptAt)
+		}
+		a, _ := strconv.Atoi(due[i].ID)
+		b, _ := strconv.Atoi(due[j].ID)
+		return a < b
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	jobs := make([]*webhooks.Job, len(due))
+	for i, delivery := range due {
+		lease := leaseUntil
+		delivery.NextAttemptAt = &lease
+		key, _ := strconv.Atoi(delivery.WebhookID)
+		endpoint := s.endpoints[key]
+		jobs[i] = &webhooks.Job{Delivery: *delivery, URL: endpoint.URL, Secret: endpoint.Secret}
+	}
+	return jobs, nil
+}
+
+func (s *MemoryStore) Record(ctx context.Context, deliveryID string, attempt webhooks.Attempt, status webhooks.Status, nextAttemptAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := strconv.Atoi(deliveryID)
+	delivery, ok := s.deliveries[key]
+	if err != nil || !ok {
+		return webhooks.DeliveryNotFound
+	}
+	delivery.Attempts++
+	delivery.Status = status
+	delivery.NextAttemptAt = nil
+	if status == webhooks.StatusPending && nextAttemptAt != nil {
+		next := *nextAttemptAt
+		delivery.NextAttemptAt = &next
+	}
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+	if status == webhooks.StatusDelivered {
+		deliveredAt := attempt.AttemptedAt
+		delivery.DeliveredAt = &deliveredAt
+	}
+	logged := attempt
+	s.attempts[key] = append(s.attempts[key], &logged)
+	return nil
+}
+
+func (s *MemoryStore) GetDeliveries(ctx context.Context, webhookID string, first int, after string) ([]*webhooks.Delivery, int, error) {
+	first = max(first, 0)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpointKey, err := strconv.Atoi(webhookID)
+	if _, ok := s.endpoints[endpointKey]; err != nil || !ok {
+		return nil, 0, webhooks.WebhookNotFound
+	}
+	afterKey, err := strconv.Atoi(after)
+	if err != nil {
+		afterKey = 0
+	}
+
+	var keys []int
+	for key, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID {
+			keys = append(keys, key)
+		}
+	}
+	total := len(keys)
+	sort.Sort(sort.Reverse(sort.IntSlice(keys)))
+
+	deliveries := make([]*webhooks.Delivery, 0, first)
+	for _, key := range keys {
+		if afterKey > 0 && key >= afterKey {
+			continue
+		}
+		if len(deliveries) == first {
+			break
+		}
+		copied := *s.deliveries[key]
+		deliveries = append(deliveries, &copied)
+	}
+	return deliveries, total, nil
+}
+
+func (s *MemoryStore) GetAttempts(ctx context.Context, deliveryID string) ([]*webhooks.Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := strconv.Atoi(deliveryID)
+	if _, ok := s.deliveries[key]; err != nil || !ok {
+		return nil, webhooks.DeliveryNotFound
+	}
+	attempts := make([]*webhooks.Attempt, len(s.attempts[key]))
+	for i, attempt := range s.attempts[key] {
+		copied := *attempt
+		attempts[i] = &copied
+	}
+	return attempts, nil
+}
+
+func (s *MemoryStore) Redeliver(ctx context.Context, deliveryID string, now time.Time) (*webhooks.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := strconv.Atoi(deliveryID)
+	delivery, ok := s.deliveries[key]
+	if err != nil || !ok {
+		return nil, webhooks.DeliveryNotFound
+	}
+	due := now
+	delivery.Status = webhooks.StatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &due
+	delivery.DeliveredAt = nil
+	copied := *delivery
+	return &copied, nil
+}
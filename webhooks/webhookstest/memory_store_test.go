@@ -0,0 +1,11 @@
+package webhookstest_test
+
+import (
+	"testing"
+
+	"github.com/KnightHacks/knighthacks_events/webhooks/webhookstest"
+)
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	webhookstest.Run(t, webhookstest.NewMemoryStore())
+}